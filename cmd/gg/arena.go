@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Kaamkiya/gg/internal/arena"
+)
+
+// runArena plays a tournament between the AIs given as arguments and
+// prints the results.
+func runArena(w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("gg arena", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), `usage: gg arena [options] <player> <player>...
+
+Plays every pair of players against each other and prints their wins,
+draws and losses, and their Elo ratings.
+
+A player is random, solver, mcts or a difficulty (easy, medium, hard or
+perfect). The settings of the last two can be changed after a colon, as
+in hard:iterations=5000,c=1. The settings are iterations, c (the
+exploration constant), think (e.g. 100ms), rollout (random, win, block,
+center or heuristic), blunder and exact.
+
+options:
+`)
+		fs.PrintDefaults()
+	}
+
+	gameName := fs.String("game", "tictactoe", "`game` to play: tictactoe or connect4")
+	size := fs.Int("size", 3, "width and height of the tictactoe board")
+	win := fs.Int("win", 0, "marks in a row needed to win tictactoe (0 for a whole row)")
+	games := fs.Int("games", 100, "games each pair of players plays")
+	seed := fs.Uint64("seed", 1, "seed of the first game")
+	workers := fs.Int("workers", 0, "games played at once (0 for one per CPU)")
+
+	if err := fs.Parse(args); errors.Is(err, flag.ErrHelp) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	t := arena.Tournament{Games: *games, Seed: *seed, Workers: *workers}
+
+	switch *gameName {
+	case "tictactoe":
+		if *size < 3 || *win < 0 || *win > *size {
+			return errors.New("the board must be at least 3x3, and the win length at most its size")
+		}
+		t.Game = arena.TicTacToe(*size, *win)
+	case "connect4":
+		t.Game = arena.Connect4
+	default:
+		return fmt.Errorf("unknown game %q, choose tictactoe or connect4", *gameName)
+	}
+
+	for _, spec := range fs.Args() {
+		p, err := arena.ParsePlayer(spec)
+		if err != nil {
+			return err
+		}
+		t.Players = append(t.Players, p)
+	}
+
+	pairs := len(t.Players) * (len(t.Players) - 1) / 2
+	fmt.Fprintf(os.Stderr, "playing %d games of %s...\n", pairs*t.Games, t.Game.Name)
+
+	results, err := t.Run()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "%s, %d games per pair, seed %d\n\n", t.Game.Name, t.Games, t.Seed)
+	return results.Write(w)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/Kaamkiya/gg/internal/theme"
+	"github.com/Kaamkiya/gg/internal/xdg"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// config is read from config.json in the config directory. Options given
+// on the command line win over it.
+type config struct {
+	// Theme names the color theme, see 'gg help'.
+	Theme string `json:"theme"`
+}
+
+// loadConfig reads the config file. A missing file gives the defaults.
+func loadConfig() (config, error) {
+	var c config
+
+	dir, err := xdg.ConfigDir()
+	if err != nil {
+		return c, err
+	}
+
+	path := filepath.Join(dir, "config.json")
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return c, err
+	}
+
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("invalid config in %s: %w", path, err)
+	}
+
+	return c, nil
+}
+
+// useTheme makes the theme named name current, falling back to monochrome
+// when the terminal has too few colors for it.
+func useTheme(name string) error {
+	return theme.Use(name, lipgloss.ColorProfile())
+}
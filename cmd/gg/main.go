@@ -1,77 +1,190 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"os"
+	"strings"
 
-	"github.com/Kaamkiya/gg/internal/app/connect4"
-	"github.com/Kaamkiya/gg/internal/app/dodger"
-	"github.com/Kaamkiya/gg/internal/app/hangman"
-	"github.com/Kaamkiya/gg/internal/app/maze"
-	"github.com/Kaamkiya/gg/internal/app/pong"
-	"github.com/Kaamkiya/gg/internal/app/snake"
-	"github.com/Kaamkiya/gg/internal/app/sudoku"
-	"github.com/Kaamkiya/gg/internal/app/tetris"
-	"github.com/Kaamkiya/gg/internal/app/tictactoe"
-	"github.com/Kaamkiya/gg/internal/app/twenty48"
-	"github.com/Kaamkiya/gg/internal/app/blackjack"
-
-	"github.com/charmbracelet/huh"
+	"github.com/Kaamkiya/gg/internal/keymap"
+	"github.com/Kaamkiya/gg/internal/launcher"
+	"github.com/Kaamkiya/gg/internal/registry"
+	"github.com/Kaamkiya/gg/internal/replay"
+	"github.com/Kaamkiya/gg/internal/theme"
+
+	// Games register themselves with the registry when imported.
+	_ "github.com/Kaamkiya/gg/internal/app/blackjack"
+	_ "github.com/Kaamkiya/gg/internal/app/connect4"
+	_ "github.com/Kaamkiya/gg/internal/app/dodger"
+	_ "github.com/Kaamkiya/gg/internal/app/hangman"
+	_ "github.com/Kaamkiya/gg/internal/app/maze"
+	_ "github.com/Kaamkiya/gg/internal/app/pong"
+	_ "github.com/Kaamkiya/gg/internal/app/snake"
+	_ "github.com/Kaamkiya/gg/internal/app/sudoku"
+	_ "github.com/Kaamkiya/gg/internal/app/tetris"
+	_ "github.com/Kaamkiya/gg/internal/app/tictactoe"
+	_ "github.com/Kaamkiya/gg/internal/app/twenty48"
+
+	tea "github.com/charmbracelet/bubbletea"
 )
 
 func main() {
-	var game string
-
-	fmt.Println("gg - a tui for small offline games")
-
-	err := huh.NewSelect[string]().
-		Title("choose a game:").
-		Options(
-			huh.NewOption("blackjack", "blackjack"),
-			huh.NewOption("2048", "twenty48"),
-			huh.NewOption("sudoku", "sudoku"),
-			huh.NewOption("dodger", "dodger"),
-			huh.NewOption("maze", "maze"),
-			huh.NewOption("hangman", "hangman"),
-			huh.NewOption("snake", "snake"),
-			huh.NewOption("tetris", "tetris"),
-			huh.NewOption("connect 4 (2 player)", "connect4"),
-			huh.NewOption("pong (2 player)", "pong"),
-			huh.NewOption("tictactoe (2 player)", "tictactoe"),
-			huh.NewOption("tictactoe (vs AI)", "tictactoe-ai"),
-		).
-		Value(&game).
-		Run()
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "gg: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	if err := loadKeys(); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig()
 	if err != nil {
-		fmt.Println("Error: failed to run selection menu.")
-		panic(err)
+		return err
+	}
+
+	// Options shared by every command come before it.
+	fs := flag.NewFlagSet("gg", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	themeName := fs.String("theme", cfg.Theme, "")
+	if err := fs.Parse(args); errors.Is(err, flag.ErrHelp) {
+		usage(os.Stdout)
+		return nil
+	} else if err != nil {
+		usage(os.Stderr)
+		return err
+	}
+	args = fs.Args()
+
+	if err := useTheme(*themeName); err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		_, err := tea.NewProgram(launcher.New(), tea.WithAltScreen()).Run()
+		return err
 	}
 
-	switch game {
-	case "blackjack":
-		blackjack.Run()
-	case "maze":
-		maze.Run()
-	case "pong":
-		pong.Run()
-	case "tictactoe":
-		tictactoe.Run()
-	case "tictactoe-ai":
-		tictactoe.RunVsAi()
-	case "dodger":
-		dodger.Run()
-	case "hangman":
-		hangman.Run()
-	case "twenty48":
-		twenty48.Run()
-	case "connect4":
-		connect4.Run()
-	case "snake":
-		snake.Run()
-	case "sudoku":
-		sudoku.Run()
-	case "tetris":
-		tetris.Run()
+	switch args[0] {
+	case "list":
+		return list(os.Stdout)
+	case "play":
+		return play(args[1:])
+	case "scores":
+		return showScores(os.Stdout, args[1:])
+	case "replay":
+		return watchReplay(args[1:])
+	case "arena":
+		return runArena(os.Stdout, args[1:])
+	case "host":
+		return host(args[1:])
+	case "join":
+		return join(args[1:])
+	case "help":
+		usage(os.Stdout)
+		return nil
 	default:
-		panic("This game either doesn't exist or hasn't been implemented.")
+		usage(os.Stderr)
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// loadKeys reads the key bindings players set in the config directory.
+func loadKeys() error {
+	path, err := keymap.File()
+	if err != nil {
+		return err
 	}
+
+	return keymap.Load(path)
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, `gg - a tui for small offline games
+
+usage:
+  gg [--theme <theme>] [command]
+
+commands:
+  (none)                     choose a game from the menu
+  list                       list the available games
+  play <game> [options]      start a game directly
+  scores [game]              show the high scores of every game, or of one
+  replay <file>              watch a recorded game
+  arena <player>...          play AIs against each other and rate them
+  host <game> [options]      host tictactoe, connect4 or pong for a player
+                             on another computer
+  join <host>[:port]         join a game hosted with gg host
+  help                       show this help
+
+Run 'gg play <game> --help' to see the options of a game, and
+'gg play <game> --record <file>' to record a game. Run 'gg arena --help'
+to see the players and options of a tournament.
+
+The colors of the games come from a theme: %s.
+`, strings.Join(theme.Names(), ", "))
+}
+
+// runGame starts game with opts and blocks until it is over. The last screen
+// of the game is printed afterwards, so the result stays visible, together
+// with the seed needed to play the same game again. The game is recorded to
+// the file named by record, unless it is empty.
+func runGame(game registry.Game, opts registry.Options, record string) error {
+	if record == "" {
+		m, err := launcher.NewWithGame(game, opts)
+		if err != nil {
+			return err
+		}
+
+		if err := runDirect(m); err != nil {
+			return err
+		}
+	} else {
+		f, err := os.Create(record)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		rec, err := replay.NewRecorder(f, game, opts)
+		if err != nil {
+			f.Close()
+			os.Remove(record)
+			return err
+		}
+
+		if err := runDirect(launcher.NewWithModel(rec)); err != nil {
+			return err
+		}
+
+		if err := f.Close(); err != nil {
+			return err
+		}
+		fmt.Printf("replay saved to %s, watch it with gg replay %s\n", record, record)
+	}
+
+	fmt.Printf("seed: %d (gg play %s --seed %d)\n", opts.Seed, game.Name, opts.Seed)
+	return nil
+}
+
+// runDirect runs a launcher holding a single game, and prints the last
+// screen of the game once it is over.
+func runDirect(m launcher.Model) error {
+	return runProgram(tea.NewProgram(m, tea.WithAltScreen()))
+}
+
+// runProgram runs p, whose model is a launcher, and prints the last screen
+// of its game once it is over.
+func runProgram(p *tea.Program) error {
+	final, err := p.Run()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(final.(launcher.Model).LastView())
+	return nil
 }
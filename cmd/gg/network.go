@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Kaamkiya/gg/internal/launcher"
+	"github.com/Kaamkiya/gg/internal/netplay"
+	"github.com/Kaamkiya/gg/internal/registry"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// defaultPort is the port games are hosted on, unless told otherwise.
+const defaultPort = 7777
+
+// host waits for a player to join the game named by the first argument,
+// and plays it with them.
+func host(args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		if len(args) > 0 && isHelpFlag(args[0]) {
+			fmt.Fprintln(os.Stderr, "usage: gg host <game> [options]\n\nThe game is tictactoe, connect4 or pong. The other player joins it with\n'gg join <host>:<port>'.")
+			return nil
+		}
+		return fmt.Errorf("host needs the name of a game: %s", strings.Join(playable(), ", "))
+	}
+
+	game, ok := registry.Lookup(args[0])
+	if !ok || !netplay.Playable(game) {
+		return fmt.Errorf("%q can't be played over the network, choose one of %s", args[0], strings.Join(playable(), ", "))
+	}
+
+	fs := flag.NewFlagSet("gg host "+game.Name, flag.ContinueOnError)
+	port := fs.Int("port", defaultPort, "TCP `port` to wait for the other player on")
+
+	opts, err := parseGameFlags(fs, game, args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	l, err := net.Listen("tcp", net.JoinHostPort("", strconv.Itoa(*port)))
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+
+	fmt.Fprintf(os.Stderr, "waiting for a player to join with 'gg join <this computer>:%d'...\n", *port)
+	s, err := netplay.Accept(l, game, opts)
+	if err != nil {
+		return err
+	}
+	l.Close()
+
+	return runSession(s)
+}
+
+// join joins the game hosted at the address given as argument. The port
+// may be left out if it is the default one.
+func join(args []string) error {
+	if len(args) != 1 || isHelpFlag(args[0]) {
+		return errors.New("usage: gg join <host>[:port]")
+	}
+
+	addr := args[0]
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, strconv.Itoa(defaultPort))
+	}
+
+	s, err := netplay.Join(addr)
+	if err != nil {
+		return err
+	}
+
+	return runSession(s)
+}
+
+// playable returns the names of the games that can be played over the
+// network.
+func playable() []string {
+	var names []string
+	for _, g := range registry.Games() {
+		if netplay.Playable(g) {
+			names = append(names, g.Name)
+		}
+	}
+
+	return names
+}
+
+// runSession plays a game over the network until it is over, or either
+// player quits.
+func runSession(s *netplay.Session) error {
+	defer s.Close()
+
+	p := tea.NewProgram(launcher.NewWithModel(s), tea.WithAltScreen())
+	s.Run(p.Send)
+
+	return runProgram(p)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Kaamkiya/gg/internal/registry"
+	"github.com/Kaamkiya/gg/internal/theme"
+)
+
+// list prints every game with its description.
+func list(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tPLAYERS\tDESCRIPTION")
+
+	for _, g := range registry.Games() {
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", g.Name, g.Players, g.Description)
+	}
+
+	return tw.Flush()
+}
+
+// play starts the game named by the first argument, skipping the menu.
+func play(args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		if len(args) > 0 && isHelpFlag(args[0]) {
+			fmt.Fprintln(os.Stderr, "usage: gg play <game> [options]\n\nRun 'gg list' to see the available games.")
+			return nil
+		}
+		return errors.New("play needs the name of a game, run 'gg list' to see them")
+	}
+
+	game, ok := registry.Lookup(args[0])
+	if !ok {
+		return fmt.Errorf("unknown game %q, run 'gg list' to see the available games", args[0])
+	}
+
+	opts, record, err := parseOptions(game, args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return runGame(game, opts, record)
+}
+
+// parseOptions parses the command line options of game, and the file to
+// record a replay to, if any.
+func parseOptions(game registry.Game, args []string) (opts registry.Options, record string, err error) {
+	fs := flag.NewFlagSet("gg play "+game.Name, flag.ContinueOnError)
+	fs.StringVar(&record, "record", "", "record a replay of the game to `file`")
+
+	opts, err = parseGameFlags(fs, game, args)
+	return opts, record, err
+}
+
+// parseGameFlags parses args with fs, to which it adds the options shared
+// by every game, the seed and the theme, and the settings of game.
+func parseGameFlags(fs *flag.FlagSet, game registry.Game, args []string) (registry.Options, error) {
+	opts := game.DefaultOptions()
+
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: %s [options]\n\n%s\n\noptions:\n", fs.Name(), game.Description)
+		fs.PrintDefaults()
+	}
+
+	fs.Func("seed", "seed for the random number generator (random by default)", func(s string) error {
+		seed, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return errors.New("the seed must be a positive number")
+		}
+		opts.Seed = seed
+		return nil
+	})
+	fs.Func("theme", "color `theme`: "+strings.Join(theme.Names(), ", "), useTheme)
+
+	values := make(map[string]*string, len(game.Settings))
+	for _, s := range game.Settings {
+		values[s.Name] = fs.String(s.Name, s.Default, s.Usage)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+
+	if fs.NArg() > 0 {
+		return opts, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	for name, value := range values {
+		opts.Settings[name] = *value
+	}
+	if err := game.CheckSettings(opts.Settings); err != nil {
+		return opts, err
+	}
+
+	return opts, nil
+}
+
+func isHelpFlag(arg string) bool {
+	return arg == "-h" || arg == "-help" || arg == "--help"
+}
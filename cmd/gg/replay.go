@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"os"
+
+	"github.com/Kaamkiya/gg/internal/launcher"
+	"github.com/Kaamkiya/gg/internal/replay"
+)
+
+// watchReplay plays back the replay file given as argument.
+func watchReplay(args []string) error {
+	if len(args) != 1 || isHelpFlag(args[0]) {
+		return errors.New("usage: gg replay <file>")
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	rep, err := replay.Load(f)
+	if err != nil {
+		return err
+	}
+
+	// The replayed game must not touch the real high scores and saves, so
+	// it gets a data directory of its own.
+	dir, err := os.MkdirTemp("", "gg-replay-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.Setenv("XDG_DATA_HOME", dir); err != nil {
+		return err
+	}
+
+	p, err := replay.NewPlayer(rep)
+	if err != nil {
+		return err
+	}
+
+	return runDirect(launcher.NewWithModel(p))
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Kaamkiya/gg/internal/registry"
+	"github.com/Kaamkiya/gg/internal/scores"
+)
+
+// showScores prints the leaderboard of the game given as argument, or of
+// every game that was played. Games with several modes, such as the maze,
+// keep the results of each mode under game/mode, e.g. maze/fog.
+func showScores(w io.Writer, args []string) error {
+	if len(args) > 1 {
+		return errors.New("usage: gg scores [game]")
+	}
+
+	store, err := scores.Default()
+	if err != nil {
+		return err
+	}
+
+	all, err := store.All()
+	if err != nil {
+		return err
+	}
+
+	names := slices.Sorted(maps.Keys(all))
+	if len(args) == 1 {
+		if _, ok := registry.Lookup(args[0]); !ok {
+			return fmt.Errorf("unknown game %q, run 'gg list' to see the available games", args[0])
+		}
+		names = slices.DeleteFunc(names, func(name string) bool {
+			game, _, _ := strings.Cut(name, "/")
+			return game != args[0]
+		})
+		if len(names) == 0 {
+			names = []string{args[0]}
+		}
+	}
+
+	if len(names) == 0 {
+		fmt.Fprintln(w, "No games played yet.")
+		return nil
+	}
+
+	for i, name := range names {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+
+		title := name
+		game, mode, _ := strings.Cut(name, "/")
+		if g, ok := registry.Lookup(game); ok {
+			title = g.Title
+			if mode != "" {
+				title += " (" + mode + ")"
+			}
+		}
+
+		stats := all[name]
+		fmt.Fprintf(w, "%s: played %d times, %s in total\n", title, stats.Played, stats.Playtime.Round(time.Second))
+
+		if len(stats.Best) == 0 {
+			continue
+		}
+
+		tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(tw, "  #\tSCORE\tDURATION\tSEED\tDATE\tDETAIL")
+		for rank, r := range stats.Best {
+			seed := "-"
+			if r.Seed != 0 {
+				seed = strconv.FormatUint(r.Seed, 10)
+			}
+
+			fmt.Fprintf(tw, "  %d\t%d\t%s\t%s\t%s\t%s\n",
+				rank+1, r.Score, r.Duration.Round(time.Second), seed, r.Time.Format("2006-01-02 15:04"), r.Detail)
+		}
+
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
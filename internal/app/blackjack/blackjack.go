@@ -2,13 +2,30 @@ package blackjack
 
 import (
 	"fmt"
-	"math/rand"
-	"time"
+	"math/rand/v2"
+
+	"github.com/Kaamkiya/gg/internal/keymap"
+	"github.com/Kaamkiya/gg/internal/registry"
+	"github.com/Kaamkiya/gg/internal/theme"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
+func init() {
+	registry.Register(registry.Game{
+		Name:        "blackjack",
+		Title:       "blackjack",
+		Description: "beat the dealer without going over 21",
+		Players:     1,
+		New:         newModel,
+	})
+}
+
+func newModel(opts registry.Options) (tea.Model, error) {
+	return initialModel(opts.Rand().Rand), nil
+}
+
 type Card struct {
 	Suit string
 	Rank string
@@ -16,6 +33,13 @@ type Card struct {
 
 type Deck []Card
 
+var actions = []keymap.Action{
+	{Name: "hit", Keys: []string{"h"}, Help: "hit"},
+	{Name: "stand", Keys: []string{"s"}, Help: "stand"},
+	{Name: "new", Keys: []string{"n"}, Help: "new game"},
+	keymap.Quit,
+}
+
 type model struct {
 	deck         Deck
 	playerHand   []Card
@@ -23,6 +47,8 @@ type model struct {
 	playerTurn   bool
 	gameOver     bool
 	message      string
+	rng          *rand.Rand
+	keys         *keymap.Map
 	playerStyle  lipgloss.Style
 	dealerStyle  lipgloss.Style
 	defaultStyle lipgloss.Style
@@ -41,9 +67,9 @@ func NewDeck() Deck {
 	return deck
 }
 
-func (d Deck) Shuffle() {
-	rand.Seed(time.Now().UnixNano())
-	rand.Shuffle(len(d), func(i, j int) {
+// Shuffle shuffles the deck in place using the random numbers from r.
+func (d Deck) Shuffle(r *rand.Rand) {
+	r.Shuffle(len(d), func(i, j int) {
 		d[i], d[j] = d[j], d[i]
 	})
 }
@@ -78,9 +104,9 @@ func HandValue(hand []Card) int {
 	return value
 }
 
-func initialModel() tea.Model {
+func initialModel(r *rand.Rand) tea.Model {
 	deck := NewDeck()
-	deck.Shuffle()
+	deck.Shuffle(r)
 
 	playerHand := []Card{deck.Draw(), deck.Draw()}
 	dealerHand := []Card{deck.Draw(), deck.Draw()}
@@ -91,10 +117,12 @@ func initialModel() tea.Model {
 		dealerHand:   dealerHand,
 		playerTurn:   true,
 		gameOver:     false,
-		message:      "Hit (h) or Stand (s)?",
-		playerStyle:  lipgloss.NewStyle().Foreground(lipgloss.Color("99")),
-		dealerStyle:  lipgloss.NewStyle().Foreground(lipgloss.Color("208")),
-		defaultStyle: lipgloss.NewStyle().Foreground(lipgloss.Color("255")),
+		message:      "Hit or stand?",
+		rng:          r,
+		keys:         keymap.New("blackjack", actions...),
+		playerStyle:  lipgloss.NewStyle().Foreground(theme.Current().Player1),
+		dealerStyle:  lipgloss.NewStyle().Foreground(theme.Current().Player2),
+		defaultStyle: lipgloss.NewStyle().Foreground(theme.Current().Text),
 	}
 }
 
@@ -105,10 +133,10 @@ func (m model) Init() tea.Cmd {
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
+		switch {
+		case m.keys.Matches(msg, "quit"):
 			return m, tea.Quit
-		case "h":
+		case m.keys.Matches(msg, "hit"):
 			if m.playerTurn && !m.gameOver {
 				m.playerHand = append(m.playerHand, m.deck.Draw())
 				if HandValue(m.playerHand) > 21 {
@@ -116,7 +144,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.gameOver = true
 				}
 			}
-		case "s":
+		case m.keys.Matches(msg, "stand"):
 			if m.playerTurn && !m.gameOver {
 				m.playerTurn = false
 				// Dealer's turn
@@ -136,9 +164,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				m.gameOver = true
 			}
-		case "n":
+		case m.keys.Matches(msg, "new"):
 			if m.gameOver {
-				return initialModel(), nil
+				return initialModel(m.rng), nil
 			}
 		}
 	}
@@ -165,16 +193,7 @@ func (m model) View() string {
 	s += fmt.Sprintf(" (Value: %d)\n", HandValue(m.playerHand))
 
 	s += "\n" + m.defaultStyle.Render(m.message) + "\n"
-	if m.gameOver {
-		s += "\nPress 'q' to quit or 'n' to start a new game.\n"
-	}
+	s += "\n" + m.keys.Help() + "\n"
 
 	return s
 }
-
-func Run() {
-	p := tea.NewProgram(initialModel())
-	if _, err := p.Run(); err != nil {
-		fmt.Printf("Alas, there's been an error: %v", err)
-	}
-}
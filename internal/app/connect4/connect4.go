@@ -1,34 +1,193 @@
 package connect4
 
 import (
+	"errors"
 	"fmt"
-	"strconv"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Kaamkiya/gg/internal/app/tictactoe/engine"
+	"github.com/Kaamkiya/gg/internal/keymap"
+	"github.com/Kaamkiya/gg/internal/netplay"
+	"github.com/Kaamkiya/gg/internal/registry"
+	"github.com/Kaamkiya/gg/internal/rng"
+	"github.com/Kaamkiya/gg/internal/savegame"
+	"github.com/Kaamkiya/gg/internal/theme"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
-type model struct {
-	board [6][7]rune // [y][x]
-	turn  rune
+func init() {
+	registry.Register(registry.Game{
+		Name:        "connect4",
+		Title:       "connect 4",
+		Description: "drop pieces and be the first to line up four",
+		Players:     2,
+		Networked:   true,
+		New:         newModel,
+		Resume:      resume,
+	})
+	registry.Register(registry.Game{
+		Name:        "connect4-ai",
+		Title:       "connect 4 (vs AI)",
+		Description: "drop pieces and line up four before the computer does",
+		Players:     1,
+		Settings: []registry.Setting{
+			{Name: "difficulty", Usage: "how well the AI plays: " + strings.Join(difficultyNames(), ", "), Default: "medium", Choices: difficultyNames()},
+			{Name: "think", Usage: "time the AI thinks per move on every CPU, e.g. 500ms, instead of the difficulty's fixed search (0); such games can't be recorded", Default: "0", Wallclock: true, Check: registry.CheckWith(engine.ParseThink)},
+		},
+		New: newAIModel,
+	})
+}
+
+// difficulties are the levels of the AI. Connect 4 is too large for the
+// solver, so there is no perfect one. Games played out at random miss too
+// many wins and threats, so all but the easy AI play them out with the
+// heuristic policy, which makes the hard one win about two games out of
+// three against its random self.
+var difficulties = []engine.Difficulty{engine.Easy, heuristic(engine.Medium), heuristic(engine.Hard)}
 
-	xStyle lipgloss.Style
-	oStyle lipgloss.Style
+func heuristic(d engine.Difficulty) engine.Difficulty {
+	d.Rollout = engine.HeuristicRollout
+	return d
 }
 
-func initialModel() tea.Model {
-	board := [6][7]rune{}
-	for y := range board {
-		for x := range board[y] {
-			board[y][x] = ' '
+func difficultyNames() []string {
+	names := make([]string, 0, len(difficulties))
+	for _, d := range difficulties {
+		names = append(names, d.Name)
+	}
+
+	return names
+}
+
+// saveVersion is the version of saveState. The board is kept as marks, not
+// engine players, so that changes to the engine leave it alone.
+const saveVersion = 1
+
+// saveState is what is kept of a suspended game. Cells hold 'x', 'o' or ' '.
+type saveState struct {
+	Board [6][7]rune `json:"board"`
+	Turn  rune       `json:"turn"`
+}
+
+func resume(s savegame.Save) (tea.Model, error) {
+	var state saveState
+	if err := s.Decode(saveVersion, &state); err != nil {
+		return nil, err
+	}
+
+	m := initialModel().(model)
+	for y, row := range state.Board {
+		for x, cell := range row {
+			m.board.Cells[y*Width+x] = player(cell)
+			if cell != ' ' && m.board.Cells[y*Width+x] == engine.EMPTY {
+				return nil, fmt.Errorf("invalid save: unknown piece %q", cell)
+			}
 		}
 	}
+	m.turn = player(state.Turn)
+	if m.turn == engine.EMPTY {
+		return nil, fmt.Errorf("invalid save: unknown player %q to move", state.Turn)
+	}
+	return m, nil
+}
+
+func newModel(opts registry.Options) (tea.Model, error) {
+	m := initialModel().(model)
+	m.seed = opts.Seed
+	return m, nil
+}
+
+func newAIModel(opts registry.Options) (tea.Model, error) {
+	i := slices.IndexFunc(difficulties, func(d engine.Difficulty) bool {
+		return d.Name == opts.Get("difficulty")
+	})
+	if i < 0 {
+		return nil, fmt.Errorf("unknown difficulty %q, choose one of %s", opts.Get("difficulty"), strings.Join(difficultyNames(), ", "))
+	}
+	d := difficulties[i]
+
+	var err error
+	d.Think, err = engine.ParseThink(opts.Get("think"))
+	if err != nil {
+		return nil, err
+	}
+
+	m := initialModel().(model)
+	m.ai = engine.NewAI(Rules{}, d, rng.New(opts.Seed).Rand)
+	m.seed = opts.Seed
+	m.think = d.Think
+	m.keys = keymap.New("connect4-ai", aiActions...)
+	return m, nil
+}
+
+// actions drop a piece in a column, the n-th key of "drop" in the n-th one.
+var actions = []keymap.Action{
+	{Name: "drop", Keys: []string{"1", "2", "3", "4", "5", "6", "7"}, Help: "drop a piece"},
+	{Name: "hint", Keys: []string{"?"}, Help: "hint"},
+	keymap.Save,
+	keymap.Quit,
+}
+
+// aiActions are the actions of a game against the AI, which isn't saved.
+var aiActions = []keymap.Action{actions[0], actions[1], keymap.Quit}
 
+var _ netplay.Model = model{}
+
+type model struct {
+	board *engine.Board
+	turn  engine.Player
+	// ai plays o, or is nil when two people play.
+	ai   engine.AI
+	over bool
+	// winner is the player who won, or EMPTY for a tie.
+	winner engine.Player
+	keys   *keymap.Map
+
+	// hints are what a search found about the columns for the player
+	// whose turn it is, shown until a piece is dropped. The search thinks
+	// for think, or as long as the hard AI if it is 0, drawing from seed.
+	hints []engine.MoveStat
+	seed  uint64
+	think time.Duration
+
+	xStyle    lipgloss.Style
+	oStyle    lipgloss.Style
+	hintStyle lipgloss.Style
+	bestStyle lipgloss.Style
+
+	err error
+}
+
+// aiMoveMsg carries the column the AI drops its piece in, or why it
+// couldn't.
+type aiMoveMsg struct {
+	col int
+	err error
+}
+
+// hintMsg carries the hints for the board whose cells were cells, or why
+// there are none.
+type hintMsg struct {
+	cells []int
+	hints []engine.MoveStat
+	err   error
+}
+
+func initialModel() tea.Model {
 	return model{
-		board:  board,
-		turn:   'x',
-		xStyle: lipgloss.NewStyle().Foreground(lipgloss.Color("2")),
-		oStyle: lipgloss.NewStyle().Foreground(lipgloss.Color("9")),
+		board:  NewBoard(),
+		turn:   engine.P1,
+		keys:   keymap.New("connect4", actions...),
+		xStyle: lipgloss.NewStyle().Foreground(theme.Current().Player1),
+		oStyle: lipgloss.NewStyle().Foreground(theme.Current().Player2),
+
+		hintStyle: lipgloss.NewStyle().Foreground(theme.Current().Info),
+		bestStyle: lipgloss.NewStyle().Foreground(theme.Current().Good).Bold(true),
 	}
 }
 
@@ -38,157 +197,259 @@ func (m model) Init() tea.Cmd {
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case aiMoveMsg:
+		if msg.err != nil {
+			m.err = fmt.Errorf("the AI failed: %w", msg.err)
+			return m, nil
+		}
+		if !slices.Contains((Rules{}).GetLegalMoves(m.board), msg.col) {
+			m.err = fmt.Errorf("the AI failed: it can't drop a piece in column %d", msg.col+1)
+			return m, nil
+		}
+		return m.drop(msg.col)
+	case hintMsg:
+		if msg.err != nil {
+			m.err = fmt.Errorf("could not find a hint: %w", msg.err)
+			return m, nil
+		}
+		if !m.over && slices.Equal(msg.cells, m.board.Cells) {
+			m.hints = msg.hints
+		}
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
+		switch {
+		case m.keys.Matches(msg, "quit"):
+			return m, tea.Quit
+		case m.keys.Matches(msg, "save"):
+			if err := savegame.Write("connect4", saveVersion, m.saveState()); err != nil {
+				m.err = fmt.Errorf("could not save: %w", err)
+				return m, nil
+			}
 			return m, tea.Quit
-		case "1", "2", "3", "4", "5", "6", "7":
-			/* Don't check for errors because there can't be one.
-			 * This only gets called if an integer was inputted.
-			 */
-			col, _ := strconv.Atoi(msg.String())
-			col-- // Go is 0 indexed, inputs are not.
-
-			// A piece can only go in that column if it's not full.
-			if m.board[0][col] == ' ' {
-				for y := len(m.board) - 1; y >= 0; y-- {
-					if m.board[y][col] == ' ' {
-						m.board[y][col] = m.turn
-
-						if m.turn == 'x' {
-							m.turn = 'o'
-						} else {
-							m.turn = 'x'
-						}
-
-						break
-					}
-				}
+		case m.keys.Matches(msg, "hint"):
+			if m.hints != nil {
+				m.hints = nil
+				return m, nil
+			}
+			if m.over || m.thinking() {
+				return m, nil
 			}
+			return m, m.hintCmd()
+		case m.keys.Matches(msg, "drop"):
+			if m.thinking() {
+				return m, nil
+			}
+			return m.drop(m.keys.Index(msg, "drop"))
 		}
 	}
 
-	if m.CheckForWin() != ' ' {
+	return m, nil
+}
+
+// drop drops a piece of the player whose turn it is in column col, unless
+// the column is full, and lets the AI answer.
+func (m model) drop(col int) (tea.Model, tea.Cmd) {
+	if m.over || (Rules{}).PlayMove(m.board, m.turn, col) != nil {
+		return m, nil
+	}
+	m.hints = nil
+
+	if over, win := (Rules{}).CheckGameOver(m.board, col); over {
+		m.over = true
+		if win > 0 {
+			m.winner = m.turn
+		}
 		return m, tea.Quit
 	}
 
+	m.turn = (Rules{}).GetOpponent(m.turn)
+	if m.thinking() {
+		return m, m.aiMove()
+	}
+
 	return m, nil
 }
 
+// Action returns the column the player on side drops a piece in with key.
+func (m model) Action(_ netplay.Side, key tea.KeyMsg) (netplay.Action, bool) {
+	if !m.keys.Matches(key, "drop") {
+		return netplay.Action{}, false
+	}
+
+	return netplay.Action{Move: m.keys.Index(key, "drop")}, true
+}
+
+// Play drops a piece of the player on side, the host playing x, in the
+// column action.Move.
+func (m model) Play(side netplay.Side, action netplay.Action) (tea.Model, tea.Cmd, error) {
+	p := engine.P1
+	if side == netplay.Guest {
+		p = engine.P2
+	}
+
+	switch {
+	case action.Input != "" || action.Move < 0 || action.Move >= Width:
+		return m, nil, fmt.Errorf("invalid move %+v", action)
+	case m.over:
+		return m, nil, errors.New("the game is over")
+	case m.turn != p:
+		return m, nil, fmt.Errorf("it is %c's turn", mark(m.turn))
+	case !slices.Contains((Rules{}).GetLegalMoves(m.board), action.Move):
+		return m, nil, fmt.Errorf("column %d is full", action.Move+1)
+	}
+
+	model, cmd := m.drop(action.Move)
+	return model, cmd, nil
+}
+
+// Player returns the piece of the player on side.
+func (m model) Player(side netplay.Side) string {
+	if side == netplay.Host {
+		return string(mark(engine.P1))
+	}
+
+	return string(mark(engine.P2))
+}
+
+// thinking reports whether it is the AI's turn.
+func (m model) thinking() bool {
+	return m.ai != nil && m.turn == engine.P2
+}
+
+// aiMove asks the AI for its move, on a copy of the board seen from its
+// side as it always plays P1.
+func (m model) aiMove() tea.Cmd {
+	board := m.board.Copy()
+	board.ChangePerspective()
+
+	return func() tea.Msg {
+		col, err := m.ai.Solve(board)
+		return aiMoveMsg{col: col, err: err}
+	}
+}
+
+// hintCmd analyzes the board for the player whose turn it is.
+func (m model) hintCmd() tea.Cmd {
+	cells := slices.Clone(m.board.Cells)
+	board := m.board.Copy()
+	if m.turn == engine.P2 {
+		board.ChangePerspective()
+	}
+
+	moves := 0
+	for _, cell := range cells {
+		if cell != engine.EMPTY {
+			moves++
+		}
+	}
+
+	d := heuristic(engine.Hard)
+	d.Think = m.think
+	analyzer := engine.NewAnalyzer(Rules{}, d, rand.New(rand.NewPCG(m.seed, uint64(moves))))
+
+	return func() tea.Msg {
+		hints, err := analyzer.Analyze(board)
+		return hintMsg{cells: cells, hints: hints, err: err}
+	}
+}
+
+func (m model) saveState() saveState {
+	var state saveState
+	for y := range state.Board {
+		for x := range state.Board[y] {
+			state.Board[y][x] = mark(m.board.Cells[y*Width+x])
+		}
+	}
+	state.Turn = mark(m.turn)
+
+	return state
+}
+
 func (m model) View() string {
 	s := "| 1 | 2 | 3 | 4 | 5 | 6 | 7 |\n"
 	s += "+---------------------------+\n"
 
-	for _, row := range m.board {
+	for y := range Height {
 		s += "| "
-		for _, cell := range row {
+		for _, cell := range m.board.Cells[y*Width : (y+1)*Width] {
 			style := m.oStyle
 
-			if cell == 'x' {
+			if cell == engine.P1 {
 				style = m.xStyle
 			}
 
-			s += style.Render(string(cell)) + " | "
+			s += style.Render(string(mark(cell))) + " | "
 		}
 		s += "\n"
 	}
 
 	s += "+---------------------------+\n"
 
-	switch m.CheckForWin() {
-	case ' ':
-		s += fmt.Sprintf("\n%c's turn\n", m.turn)
-	case 't':
-		s += "\ntie!\n"
-	default:
-		s += fmt.Sprintf("\n%c wins!\n", m.CheckForWin())
+	if m.hints != nil {
+		s += m.hintView()
 	}
 
-	return s
-}
-
-func (m model) CheckForWin() rune {
-	// Check for a win horizontally.
-	for y := 0; y < len(m.board[0]); y++ {
-		for x := 0; x < len(m.board)-3; x++ {
-			tile1 := m.board[x][y]
-			tile2 := m.board[x+1][y]
-			tile3 := m.board[x+2][y]
-			tile4 := m.board[x+3][y]
-			if tile1 == 'x' && tile2 == 'x' && tile3 == 'x' && tile4 == 'x' {
-				return 'x'
-			}
-			if tile1 == 'o' && tile2 == 'o' && tile3 == 'o' && tile4 == 'o' {
-				return 'o'
-			}
-		}
+	switch {
+	case !m.over && m.thinking():
+		s += fmt.Sprintf("\n%c is thinking...\n", mark(m.turn))
+	case !m.over:
+		s += fmt.Sprintf("\n%c's turn\n", mark(m.turn))
+		s += "\n" + m.keys.Help() + "\n"
+	case m.winner == engine.EMPTY:
+		s += "\ntie!\n"
+	default:
+		s += fmt.Sprintf("\n%c wins!\n", mark(m.winner))
 	}
 
-	// Check for a win vertically.
-	for y := 0; y < len(m.board[0])-3; y++ {
-		for x := 0; x < len(m.board); x++ {
-			tile1 := m.board[x][y]
-			tile2 := m.board[x][y+1]
-			tile3 := m.board[x][y+2]
-			tile4 := m.board[x][y+3]
-			if tile1 == 'x' && tile2 == 'x' && tile3 == 'x' && tile4 == 'x' {
-				return 'x'
-			}
-			if tile1 == 'o' && tile2 == 'o' && tile3 == 'o' && tile4 == 'o' {
-				return 'o'
-			}
-		}
+	if m.err != nil {
+		s += fmt.Sprintf("Error: %v\n", m.err)
 	}
 
-	for y := 0; y < len(m.board[0])-3; y++ {
-		for x := 0; x < len(m.board)-3; x++ {
-			// Check left down diagonal.
-			tile1 := m.board[x][y]
-			tile2 := m.board[x+1][y+1]
-			tile3 := m.board[x+2][y+2]
-			tile4 := m.board[x+3][y+3]
-			if tile1 == 'x' && tile2 == 'x' && tile3 == 'x' && tile4 == 'x' {
-				return 'x'
-			}
-			if tile1 == 'o' && tile2 == 'o' && tile3 == 'o' && tile4 == 'o' {
-				return 'o'
-			}
+	return s
+}
 
-			// Check right down diagonal.
-			tile1 = m.board[x+3][y]
-			tile2 = m.board[x+2][y+1]
-			tile3 = m.board[x+1][y+2]
-			tile4 = m.board[x][y+3]
-			if tile1 == 'x' && tile2 == 'x' && tile3 == 'x' && tile4 == 'x' {
-				return 'x'
-			}
-			if tile1 == 'o' && tile2 == 'o' && tile3 == 'o' && tile4 == 'o' {
-				return 'o'
-			}
-		}
+// mark returns the piece of a player as saved and drawn: 'x' for the first
+// player, 'o' for the second and ' ' for an empty cell.
+func mark(p engine.Player) rune {
+	switch p {
+	case engine.P1:
+		return 'x'
+	case engine.P2:
+		return 'o'
 	}
 
-	emptyCount := len(m.board) * len(m.board[0])
-
-	for _, row := range m.board {
-		for _, cell := range row {
-			if cell != ' ' {
-				emptyCount--
-			}
-		}
-	}
+	return ' '
+}
 
-	if emptyCount <= 0 {
-		return 't'
+// player is the inverse of mark.
+func player(mark rune) engine.Player {
+	switch mark {
+	case 'x':
+		return engine.P1
+	case 'o':
+		return engine.P2
 	}
 
-	return ' '
+	return engine.EMPTY
 }
 
-func Run() {
-	p := tea.NewProgram(initialModel())
+// hintView shows the chance to win of every column under the board, the
+// best column in bold.
+func (m model) hintView() string {
+	best := engine.Best(m.hints)
 
-	if _, err := p.Run(); err != nil {
-		panic(err)
+	s := "|"
+	for col := range Width {
+		i := slices.IndexFunc(m.hints, func(h engine.MoveStat) bool { return h.Move == col })
+		switch {
+		case i < 0:
+			s += "   "
+		case col == best:
+			s += m.bestStyle.Render(fmt.Sprintf("%2d%%", min(99, int(m.hints[i].WinRate*100))))
+		default:
+			s += m.hintStyle.Render(fmt.Sprintf("%2d%%", min(99, int(m.hints[i].WinRate*100))))
+		}
+		s += "|"
 	}
+
+	return s + "\nhint: chance to win, the best column in bold\n"
 }
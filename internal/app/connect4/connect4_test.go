@@ -0,0 +1,158 @@
+package connect4
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Kaamkiya/gg/internal/app/tictactoe/engine"
+	"github.com/Kaamkiya/gg/internal/gametest"
+	"github.com/Kaamkiya/gg/internal/netplay"
+	"github.com/Kaamkiya/gg/internal/registry"
+	"github.com/Kaamkiya/gg/internal/savegame"
+)
+
+func TestAgainstAI(t *testing.T) {
+	h := gametest.New(t, "connect4-ai", registry.Options{Seed: 1})
+	h.Golden("ai-start")
+
+	// The player drops a piece and the AI answers at once.
+	h.Press("4")
+	board, _, _ := strings.Cut(h.View(), "+\n\n")
+	if x, o := strings.Count(board, "x"), strings.Count(board, "o"); x != 1 || o != 1 {
+		t.Fatalf("expected a piece of each side, got %d x and %d o:\n%s", x, o, h.View())
+	}
+	if !strings.Contains(h.View(), "x's turn") {
+		t.Fatalf("expected the player's turn:\n%s", h.View())
+	}
+}
+
+func TestHint(t *testing.T) {
+	h := gametest.New(t, "connect4", registry.Options{Seed: 1})
+	h.Press("4", "?")
+
+	view := h.View()
+	if got := strings.Count(view, "%"); got != Width {
+		t.Fatalf("expected the chance to win of every column, got %d:\n%s", got, view)
+	}
+
+	h.Press("4")
+	if strings.Contains(h.View(), "%") {
+		t.Fatalf("expected the hints to go away after a move:\n%s", h.View())
+	}
+}
+
+func TestInvalidDifficulty(t *testing.T) {
+	g, _ := registry.Lookup("connect4-ai")
+
+	for _, settings := range []map[string]string{
+		{"difficulty": "perfect", "think": "0"},
+		{"difficulty": "medium", "think": "soon"},
+	} {
+		if _, err := g.New(registry.Options{Settings: settings}); err == nil {
+			t.Errorf("expected an error for %v", settings)
+		}
+	}
+}
+
+func TestSaveAndResume(t *testing.T) {
+	h := gametest.New(t, "connect4", registry.Options{})
+	h.Press("4", "4", "5", "s")
+
+	s, err := savegame.Read("connect4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := resume(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := m.View(), h.View(); got != want {
+		t.Fatalf("the resumed game differs from the saved one:\n%s\n%s", got, want)
+	}
+	if m.(model).turn != engine.P2 {
+		t.Fatal("expected o to play next")
+	}
+}
+
+func TestResumeInvalidSave(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	var turn, piece saveState
+	for y := range turn.Board {
+		for x := range turn.Board[y] {
+			turn.Board[y][x], piece.Board[y][x] = ' ', ' '
+		}
+	}
+	turn.Turn, piece.Turn = '?', 'x'
+	piece.Board[5][0] = '?'
+
+	for _, state := range []saveState{turn, piece} {
+		if err := savegame.Write("connect4", saveVersion, state); err != nil {
+			t.Fatal(err)
+		}
+		s, err := savegame.Read("connect4")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := resume(s); err == nil {
+			t.Errorf("expected an error resuming %+v", state)
+		}
+	}
+}
+
+func TestNetwork(t *testing.T) {
+	host, guest := gametest.Connect(t, "connect4", registry.Options{})
+
+	// x lines up four in column 1, o only gets three in column 2.
+	for i := range 7 {
+		if i%2 == 0 {
+			host.Press("1")
+			guest.Receive()
+		} else {
+			guest.Press("2")
+			host.Receive()
+			guest.Receive()
+		}
+
+		hostGame := host.Model().(*netplay.Session).Game()
+		guestGame := guest.Model().(*netplay.Session).Game()
+		if got, want := guestGame.View(), hostGame.View(); got != want {
+			t.Fatalf("the games differ after %d moves:\n%s\n%s", i+1, got, want)
+		}
+	}
+
+	if !strings.Contains(guest.View(), "x wins!") || !host.Quit() || !guest.Quit() {
+		t.Fatalf("expected x to win on both sides:\n%s", guest.View())
+	}
+}
+
+func TestNetworkOutOfTurn(t *testing.T) {
+	host, guest := gametest.Connect(t, "connect4", registry.Options{})
+
+	// The host has the last word: the guest can't play first, and the host
+	// can't play twice.
+	guest.Press("3")
+	host.Receive()
+	host.Press("4", "5")
+	guest.Receive()
+
+	for _, h := range []*gametest.Harness{host, guest} {
+		board := h.Model().(*netplay.Session).Game().(model).board
+		if x, o := count(board, engine.P1), count(board, engine.P2); x != 1 || o != 0 {
+			t.Fatalf("expected a single piece of x, got %d x and %d o", x, o)
+		}
+	}
+}
+
+// count counts the pieces of p on board.
+func count(board *engine.Board, p engine.Player) int {
+	n := 0
+	for _, cell := range board.Cells {
+		if cell == p {
+			n++
+		}
+	}
+
+	return n
+}
@@ -0,0 +1,124 @@
+package connect4
+
+import (
+	"fmt"
+
+	"github.com/Kaamkiya/gg/internal/app/tictactoe/engine"
+)
+
+// The size of the board, and the number of pieces in a row that wins.
+const (
+	Width     = 7
+	Height    = 6
+	winLength = 4
+)
+
+// Rules are the rules of connect 4 for the engine package, so that its AI
+// can play it. A move is the column a piece is dropped in; the piece falls
+// to the lowest free row of that column.
+type Rules struct{}
+
+var (
+	_ engine.GameEngine = Rules{}
+	_ engine.Locator    = Rules{}
+)
+
+// NewBoard returns an empty board, its top row first.
+func NewBoard() *engine.Board {
+	return engine.NewGrid(Width, Height)
+}
+
+// GetLegalMoves returns the columns that aren't full.
+func (Rules) GetLegalMoves(board *engine.Board) []int {
+	if !valid(board) {
+		return nil
+	}
+
+	var moves []int
+	for col := range board.Width {
+		if board.Cells[col] == engine.EMPTY {
+			moves = append(moves, col)
+		}
+	}
+
+	return moves
+}
+
+// PlayMove drops a piece of player in column col.
+func (Rules) PlayMove(board *engine.Board, player int, col int) error {
+	if !valid(board) {
+		return fmt.Errorf("invalid board size: %dx%d with %d cells", board.Width, board.Height, len(board.Cells))
+	}
+	if player != engine.P1 && player != engine.P2 {
+		return fmt.Errorf("invalid player: %d", player)
+	}
+	if col < 0 || col >= board.Width {
+		return fmt.Errorf("invalid column: %d", col)
+	}
+
+	row := top(board, col) - 1
+	if row < 0 {
+		return fmt.Errorf("column %d is full", col+1)
+	}
+
+	return board.SetCell(row*board.Width+col, player)
+}
+
+func (Rules) GetOpponent(player int) int {
+	return -player
+}
+
+// CheckGameOver reports whether the piece last dropped, in column lastMove,
+// ended the game, and a positive value if it won it.
+func (r Rules) CheckGameOver(board *engine.Board, lastMove int) (bool, int) {
+	if !valid(board) || lastMove < 0 || lastMove >= board.Width {
+		return false, 0
+	}
+
+	row := top(board, lastMove)
+	if row == board.Height {
+		return false, 0
+	}
+	cell := row*board.Width + lastMove
+	if player := board.Cells[cell]; player != engine.P1 && player != engine.P2 {
+		return false, 0
+	}
+
+	if board.InARow(cell, winLength) {
+		return true, 1
+	}
+
+	if len(r.GetLegalMoves(board)) == 0 {
+		return true, 0
+	}
+
+	return false, 0
+}
+
+// Locate returns the cell a piece dropped in column col lands on, so that
+// rollout policies preferring the center know how high it is, or -1 if the
+// column is full or off the board.
+func (Rules) Locate(board *engine.Board, col int) int {
+	if !valid(board) || col < 0 || col >= board.Width || top(board, col) == 0 {
+		return -1
+	}
+
+	return (top(board, col)-1)*board.Width + col
+}
+
+// valid reports whether board is a grid, whose cells can all be indexed.
+func valid(board *engine.Board) bool {
+	return board.Width > 0 && board.Height > 0 && len(board.Cells) == board.Width*board.Height
+}
+
+// top returns the row of the highest piece in column col, or the height of
+// the board if the column is empty. The board must be valid.
+func top(board *engine.Board, col int) int {
+	for row := range board.Height {
+		if board.Cells[row*board.Width+col] != engine.EMPTY {
+			return row
+		}
+	}
+
+	return board.Height
+}
@@ -0,0 +1,248 @@
+package connect4
+
+import (
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Kaamkiya/gg/internal/app/tictactoe/engine"
+)
+
+// parse reads a board drawn row by row from the top, with x and o for the
+// pieces and . for empty cells.
+func parse(t *testing.T, rows ...string) *engine.Board {
+	t.Helper()
+
+	board := NewBoard()
+	if len(rows) != Height {
+		t.Fatalf("expected %d rows, got %d", Height, len(rows))
+	}
+	for y, row := range rows {
+		for x, cell := range strings.ReplaceAll(row, " ", "") {
+			board.Cells[y*Width+x] = player(cell)
+		}
+	}
+
+	return board
+}
+
+func TestRules_PlayMove(t *testing.T) {
+	board := NewBoard()
+
+	for range Height {
+		if err := (Rules{}).PlayMove(board, engine.P1, 3); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if board.Cells[(Height-1)*Width+3] != engine.P1 || board.Cells[3] != engine.P1 {
+		t.Fatalf("expected the pieces to fill column 4 from the bottom:\n%v", board.Cells)
+	}
+
+	if err := (Rules{}).PlayMove(board, engine.P2, 3); err == nil {
+		t.Fatal("expected an error for a full column")
+	}
+	if err := (Rules{}).PlayMove(board, engine.P2, Width); err == nil {
+		t.Fatal("expected an error for a column off the board")
+	}
+
+	if moves := (Rules{}).GetLegalMoves(board); len(moves) != Width-1 {
+		t.Fatalf("expected every column but the full one, got %v", moves)
+	}
+}
+
+func TestRules_CheckGameOver(t *testing.T) {
+	tests := []struct {
+		name  string
+		board []string
+		last  int
+		over  bool
+		win   int
+	}{
+		{"empty", []string{
+			". . . . . . .",
+			". . . . . . .",
+			". . . . . . .",
+			". . . . . . .",
+			". . . . . . .",
+			". . . . . . .",
+		}, -1, false, 0},
+		{"three", []string{
+			". . . . . . .",
+			". . . . . . .",
+			". . . . . . .",
+			". . . . . . .",
+			". . . . . . .",
+			"x x x . o o .",
+		}, 2, false, 0},
+		{"row", []string{
+			". . . . . . .",
+			". . . . . . .",
+			". . . . . . .",
+			". . . . . . .",
+			". . . o o o .",
+			". x x x x o .",
+		}, 2, true, 1},
+		{"column", []string{
+			". . . . . . .",
+			". . . . . . .",
+			". . . . . . o",
+			". . . . . x o",
+			". . . . . x o",
+			". . . . . x o",
+		}, 6, true, 1},
+		{"diagonal", []string{
+			". . . . . . .",
+			". . . . . . .",
+			". . . o . . .",
+			". . o x . . .",
+			". o x x . . .",
+			"o x x x o . .",
+		}, 3, true, 1},
+		{"anti-diagonal", []string{
+			". . . . . . .",
+			". . . . . . .",
+			"x . . . . . .",
+			"o x . . . . .",
+			"o o x . . . .",
+			"o o x x . . .",
+		}, 0, true, 1},
+		{"tie", []string{
+			"o x o x o x o",
+			"o x o x o x o",
+			"x o x o x o x",
+			"x o x o x o x",
+			"o x o x o x o",
+			"o x o x o x o",
+		}, 6, true, 0},
+	}
+
+	for _, tt := range tests {
+		over, win := (Rules{}).CheckGameOver(parse(t, tt.board...), tt.last)
+		if over != tt.over || win != tt.win {
+			t.Errorf("%s: expected (%v, %d), got (%v, %d)", tt.name, tt.over, tt.win, over, win)
+		}
+	}
+}
+
+func TestAI(t *testing.T) {
+	tests := []struct {
+		name  string
+		board []string
+		want  int
+	}{
+		// x, the AI, has three in a row and takes the fourth.
+		{"win", []string{
+			". . . . . . .",
+			". . . . . . .",
+			". . . . . . .",
+			". . . . . . .",
+			". o o . . . .",
+			"o x x x . . .",
+		}, 4},
+		// o threatens to complete a row and must be stopped.
+		{"block", []string{
+			". . . . . . .",
+			". . . . . . .",
+			". . . . . . .",
+			". . . . . . .",
+			". . . . . . .",
+			"x o o o . . x",
+		}, 4},
+	}
+
+	for _, tt := range tests {
+		ai := engine.NewAI(Rules{}, engine.Hard, rand.New(rand.NewPCG(1, 1)))
+		got, err := ai.Solve(parse(t, tt.board...))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected column %d, got %d", tt.name, tt.want+1, got+1)
+		}
+	}
+}
+
+func TestRules_Rollout(t *testing.T) {
+	board := parse(t,
+		". . . . . . .",
+		". . . . . . .",
+		". . . . . . .",
+		". o . . . . .",
+		". o . . . . .",
+		". o x x x . .",
+	)
+	moves := (Rules{}).GetLegalMoves(board)
+	r := rand.New(rand.NewPCG(1, 1))
+
+	if move, ok := engine.TakeWins.Move(Rules{}, board, engine.P1, moves, r); !ok || move != 5 {
+		t.Errorf("expected x to win in column 6, got %d (%v)", move+1, ok)
+	}
+	if move, ok := engine.BlockLosses.Move(Rules{}, board, engine.P1, moves, r); !ok || move != 1 {
+		t.Errorf("expected x to block column 2, got %d (%v)", move+1, ok)
+	}
+
+	// A piece dropped in column 2 lands above the three o's.
+	if cell := (Rules{}).Locate(board, 1); cell != 2*Width+1 {
+		t.Errorf("expected column 2 to land on row 3, got cell %d", cell)
+	}
+}
+
+// FuzzRules drops pieces in any columns of any board, valid or not, and
+// checks that nothing panics, that only legal moves change the board, and
+// that the AI refuses invalid boards and plays legal moves on valid ones.
+func FuzzRules(f *testing.F) {
+	f.Add(int8(Width), int8(Height), make([]byte, Width*Height), []byte{3, 3, 4, 9, 255})
+	f.Add(int8(Width), int8(Height), []byte{1, 2, 3}, []byte{0})
+	f.Add(int8(4), int8(4), []byte{1, 1, 1, 1, 2, 2, 2, 0, 0, 0, 0, 0, 0, 0, 0, 0}, []byte{3, 3, 3, 3})
+	f.Add(int8(-2), int8(0), []byte{}, []byte{0, 1})
+
+	level := engine.Difficulty{Name: "fuzz", Iterations: 20, Exploration: 1.41, Rollout: engine.HeuristicRollout}
+
+	f.Fuzz(func(t *testing.T, width, height int8, cells, cols []byte) {
+		board := &engine.Board{Width: int(width), Height: int(height)}
+		for _, c := range cells[:min(len(cells), 64)] {
+			board.Cells = append(board.Cells, [4]int{engine.EMPTY, engine.P1, engine.P2, 2}[c%4])
+		}
+		rules := Rules{}
+		player := engine.P1
+
+		for _, c := range cols {
+			col := int(int8(c))
+			legal := slices.Contains(rules.GetLegalMoves(board), col)
+			rules.Locate(board, col)
+
+			before := slices.Clone(board.Cells)
+			if err := rules.PlayMove(board, player, col); err != nil {
+				if !slices.Equal(before, board.Cells) {
+					t.Fatalf("the failed drop in column %d changed the board", col)
+				}
+				continue
+			} else if !legal {
+				t.Fatalf("dropped a piece in column %d, which isn't a legal move", col)
+			}
+
+			rules.CheckGameOver(board, col)
+			player = rules.GetOpponent(player)
+		}
+
+		moves := rules.GetLegalMoves(board)
+		ai := engine.NewAI(rules, level, rand.New(rand.NewPCG(1, 2)))
+		col, err := ai.Solve(board.Copy())
+		switch {
+		case board.Validate() != nil:
+			if err == nil {
+				t.Fatalf("expected an error for the invalid board %dx%d %v", board.Width, board.Height, board.Cells)
+			}
+		case len(moves) == 0:
+			if !errors.Is(err, engine.ErrNoMoves) {
+				t.Fatalf("expected ErrNoMoves for the full board, got %d, %v", col, err)
+			}
+		case err != nil:
+			t.Fatal(err)
+		case !slices.Contains(moves, col):
+			t.Fatalf("the AI played column %d, which isn't a legal move", col)
+		}
+	})
+}
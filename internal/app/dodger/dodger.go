@@ -2,17 +2,40 @@ package dodger
 
 import (
 	"fmt"
-	"math/rand/v2"
 	"time"
 
+	"github.com/Kaamkiya/gg/internal/clock"
+	"github.com/Kaamkiya/gg/internal/keymap"
+	"github.com/Kaamkiya/gg/internal/registry"
+	"github.com/Kaamkiya/gg/internal/rng"
+	"github.com/Kaamkiya/gg/internal/scores"
+	"github.com/Kaamkiya/gg/internal/theme"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
-type (
-	spawnBlockMsg struct{}
-	moveBlockMsg  struct{}
-)
+func init() {
+	registry.Register(registry.Game{
+		Name:        "dodger",
+		Title:       "dodger",
+		Description: "dodge the falling blocks for as long as you can",
+		Players:     1,
+		Settings: []registry.Setting{
+			{Name: "size", Usage: "size of the playing field as WIDTHxHEIGHT", Default: "30x20", Check: registry.CheckWith(parseSize)},
+		},
+		New: initialModel,
+	})
+}
+
+// tickMsg spawns a new block and moves every block down one line.
+type tickMsg struct{}
+
+func tick(c clock.Clock) tea.Cmd {
+	return c.Tick(200*time.Millisecond, func(time.Time) tea.Msg {
+		return tickMsg{}
+	})
+}
 
 type vector struct {
 	x int
@@ -25,56 +48,124 @@ type model struct {
 	blocks []vector // The positions of each block on the screen.
 	score  int      // The amount of blocks that have gone off-screen.
 
+	seed uint64    // The seed the game was started with.
+	rng  *rng.Rand // Decides where new blocks fall.
+
+	clock clock.Clock // Schedules the ticks that move the blocks.
+	keys  *keymap.Map // The keys that move the player.
+
+	started time.Time // When the game started.
+	over    bool      // Set once the player is hit, while the score is saved.
+	err     error     // Set when the score couldn't be saved.
+
 	blockStyle  lipgloss.Style
 	playerStyle lipgloss.Style
 }
 
-func initialModel() tea.Model {
-	size := vector{30, 20}
+func initialModel(opts registry.Options) (tea.Model, error) {
+	size, err := parseSize(opts.Get("size"))
+	if err != nil {
+		return nil, err
+	}
+
 	return model{
 		size:        size,
 		player:      vector{int(size.x / 2), size.y - 1},
 		blocks:      []vector{},
 		score:       0,
-		blockStyle:  lipgloss.NewStyle().Foreground(lipgloss.Color("#cccccc")),
-		playerStyle: lipgloss.NewStyle().Foreground(lipgloss.Color("#aaaaff")),
+		seed:        opts.Seed,
+		rng:         opts.Rand(),
+		clock:       opts.Ticker(),
+		keys:        keymap.New("dodger", keymap.Left, keymap.Right, keymap.Quit),
+		started:     time.Now(),
+		blockStyle:  lipgloss.NewStyle().Foreground(theme.Current().Muted),
+		playerStyle: lipgloss.NewStyle().Foreground(theme.Current().Player1),
+	}, nil
+}
+
+// parseSize parses the size of the playing field, at least 5x5.
+func parseSize(s string) (vector, error) {
+	width, height, err := registry.ParseSize(s)
+	if err != nil {
+		return vector{}, err
+	}
+
+	if width < 5 || height < 5 {
+		return vector{}, fmt.Errorf("dodger needs a playing field of at least 5x5, got %dx%d", width, height)
 	}
+
+	return vector{width, height}, nil
 }
 
 func (m model) Init() tea.Cmd {
-	return nil
+	return tick(m.clock)
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case scores.RecordedMsg:
+		m.err = msg.Err
+		return m, tea.Quit
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
+		switch {
+		case m.keys.Matches(msg, "quit"):
 			return m, tea.Quit
-		case "left", "h":
+		case m.over:
+			return m, nil
+		case m.keys.Matches(msg, "left"):
 			m.player.x--
 			if m.player.x < 0 {
 				m.player.x = m.size.x - 1
 			}
-		case "right", "l":
+		case m.keys.Matches(msg, "right"):
 			m.player.x++
 			if m.player.x >= m.size.x {
 				m.player.x = 0
 			}
 		}
-	case spawnBlockMsg:
-		m.blocks = append(m.blocks, vector{rand.IntN(m.size.x), 0})
-	case moveBlockMsg:
+	case tickMsg:
+		// The tick scheduled before the player ran into a block.
+		if m.over {
+			return m, nil
+		}
+		m.blocks = append(m.blocks, vector{m.rng.IntN(m.size.x), 0})
 		m.moveBlocks()
+
+		if m.hit() {
+			return m.gameOver()
+		}
+
+		return m, tick(m.clock)
+	}
+
+	if m.hit() && !m.over {
+		return m.gameOver()
 	}
 
+	return m, nil
+}
+
+// gameOver stops the blocks and records how many the player dodged,
+// quitting once that is saved.
+func (m model) gameOver() (tea.Model, tea.Cmd) {
+	m.over = true
+
+	return m, scores.RecordCmd("dodger", scores.Result{
+		Score:    m.score,
+		Duration: time.Since(m.started),
+		Seed:     m.seed,
+	})
+}
+
+// hit reports whether the player has been hit by a block.
+func (m model) hit() bool {
 	for _, b := range m.blocks {
 		if b.x == m.player.x && b.y == m.player.y {
-			return m, tea.Quit
+			return true
 		}
 	}
 
-	return m, nil
+	return false
 }
 
 func (m model) View() string {
@@ -100,7 +191,10 @@ func (m model) View() string {
 		s += "\n"
 	}
 
-	s += "hjkl or arrows to move"
+	s += m.keys.Help()
+	if m.err != nil {
+		s += fmt.Sprintf("\nCould not save the score: %v", m.err)
+	}
 
 	return s
 }
@@ -119,19 +213,3 @@ func (m *model) moveBlocks() {
 		}
 	}
 }
-
-func Run() {
-	prog := tea.NewProgram(initialModel())
-
-	go func() {
-		for {
-			time.Sleep(200 * time.Millisecond)
-			prog.Send(spawnBlockMsg{})
-			prog.Send(moveBlockMsg{})
-		}
-	}()
-
-	if _, err := prog.Run(); err != nil {
-		panic(err)
-	}
-}
@@ -0,0 +1,79 @@
+package dodger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Kaamkiya/gg/internal/gametest"
+	"github.com/Kaamkiya/gg/internal/registry"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var opts = registry.Options{
+	Seed:     3,
+	Settings: map[string]string{"size": "10x8"},
+}
+
+func TestBlocksFallOnEveryTick(t *testing.T) {
+	h := gametest.New(t, "dodger", opts)
+	h.Golden("start")
+
+	h.Advance(600 * time.Millisecond)
+	m := h.Model().(model)
+	if len(m.blocks) != 3 {
+		t.Fatalf("expected a block for every tick, got %d", len(m.blocks))
+	}
+
+	h.Golden("falling")
+}
+
+func TestPlayerWrapsAround(t *testing.T) {
+	h := gametest.New(t, "dodger", opts)
+
+	h.Press("right", "right", "right", "right", "right")
+	if x := h.Model().(model).player.x; x != 0 {
+		t.Fatalf("expected the player to wrap to the left edge, got x=%d", x)
+	}
+
+	h.Press("h")
+	if x := h.Model().(model).player.x; x != 9 {
+		t.Fatalf("expected the player to wrap to the right edge, got x=%d", x)
+	}
+}
+
+func TestSameSeedSameBlocks(t *testing.T) {
+	a := gametest.New(t, "dodger", opts)
+	b := gametest.New(t, "dodger", opts)
+
+	a.Advance(2 * time.Second)
+	b.Advance(2 * time.Second)
+
+	if a.View() != b.View() {
+		t.Fatalf("expected the same game, got\n%s\nand\n%s", a.View(), b.View())
+	}
+}
+
+func TestBlocksStopOnceHit(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	g, _ := registry.Lookup("dodger")
+	start, err := g.New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := start.(model)
+	m.blocks = []vector{{m.player.x + 1, m.player.y}}
+
+	// Running into a block ends the game while a tick is on its way.
+	next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRight})
+	m = next.(model)
+	if !m.over {
+		t.Fatal("expected the game to be over")
+	}
+
+	next, cmd := m.Update(tickMsg{})
+	if cmd != nil || len(next.(model).blocks) != 1 {
+		t.Fatal("expected the blocks to stop once the game is over")
+	}
+}
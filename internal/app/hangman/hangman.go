@@ -1,22 +1,51 @@
 package hangman
 
 import (
-	"math/rand/v2"
+	"fmt"
 	"slices"
+	"time"
+
+	"github.com/Kaamkiya/gg/internal/keymap"
+	"github.com/Kaamkiya/gg/internal/registry"
+	"github.com/Kaamkiya/gg/internal/scores"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+func init() {
+	registry.Register(registry.Game{
+		Name:        "hangman",
+		Title:       "hangman",
+		Description: "guess the word one letter at a time",
+		Players:     1,
+		New:         newModel,
+	})
+}
+
+func newModel(opts registry.Options) (tea.Model, error) {
+	return initialModel(opts), nil
+}
+
+// quit can't be bound to a letter by default, as every letter is a guess.
+var quit = keymap.Action{Name: "quit", Keys: []string{"ctrl+c"}, Help: "quit"}
+
 type model struct {
 	word     string
 	showWord []rune
 	guesses  int
 	guessed  []string
 	art      []string
+	seed     uint64
+	keys     *keymap.Map
+	started  time.Time
+	// over is set once the word is found or the man hanged, while the
+	// score is saved.
+	over bool
+	err  error
 }
 
-func initialModel() tea.Model {
-	word := wordlist[rand.IntN(len(wordlist))]
+func initialModel(opts registry.Options) tea.Model {
+	word := wordlist[opts.Rand().IntN(len(wordlist))]
 
 	showWord := make([]rune, len(word))
 	for i := range word {
@@ -81,6 +110,9 @@ func initialModel() tea.Model {
 		guesses:  6,
 		guessed:  []string{},
 		art:      art,
+		seed:     opts.Seed,
+		keys:     keymap.New("hangman", quit),
+		started:  time.Now(),
 	}
 }
 
@@ -90,10 +122,18 @@ func (m model) Init() tea.Cmd {
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case scores.RecordedMsg:
+		m.err = msg.Err
+		return m, tea.Quit
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c":
+		if m.keys.Matches(msg, "quit") {
 			return m, tea.Quit
+		}
+		if m.over {
+			return m, nil
+		}
+
+		switch msg.String() {
 		case "a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l", "m", "n", "o", "p", "q", "r", "s", "t", "u", "v", "w", "x", "y", "z":
 			letter := msg.String()
 			if slices.Contains(m.guessed, letter) {
@@ -115,8 +155,15 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	}
 
-	if m.guesses <= -1 || m.word == string(m.showWord) {
-		return m, tea.Quit
+	if !m.over && (m.guesses <= -1 || m.word == string(m.showWord)) {
+		// Winning scores one point for every wrong guess that was left.
+		// The game ends once the score is saved.
+		m.over = true
+		return m, scores.RecordCmd("hangman", scores.Result{
+			Score:    m.guesses + 1,
+			Duration: time.Since(m.started),
+			Seed:     m.seed,
+		})
 	}
 
 	return m, nil
@@ -147,13 +194,10 @@ func (m model) View() string {
 		s += `The word was "` + m.word + "\".\n\n"
 	}
 
-	return s
-}
-
-func Run() {
-	p := tea.NewProgram(initialModel())
-
-	if _, err := p.Run(); err != nil {
-		panic(err)
+	s += "type a letter to guess, " + m.keys.Help() + "\n"
+	if m.err != nil {
+		s += fmt.Sprintf("Could not save the score: %v\n", m.err)
 	}
+
+	return s
 }
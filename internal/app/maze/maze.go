@@ -1,128 +1,567 @@
 package maze
 
 import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/Kaamkiya/gg/internal/app/maze/mazegenerator"
+	"github.com/Kaamkiya/gg/internal/app/maze/mazesolver"
+	"github.com/Kaamkiya/gg/internal/clock"
+	"github.com/Kaamkiya/gg/internal/keymap"
+	"github.com/Kaamkiya/gg/internal/registry"
+	"github.com/Kaamkiya/gg/internal/rng"
+	"github.com/Kaamkiya/gg/internal/scores"
+	"github.com/Kaamkiya/gg/internal/theme"
+
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func init() {
+	registry.Register(registry.Game{
+		Name:        "maze",
+		Title:       "maze",
+		Description: "find your way from the start to the exit",
+		Players:     1,
+		Settings: []registry.Setting{
+			{Name: "size", Usage: "size of the maze as WIDTHxHEIGHT, or fit to fill the terminal", Default: "fit", Check: registry.CheckWith(parseSize)},
+			{Name: "algorithm", Usage: "how the maze is built: " + strings.Join(mazegenerator.Algorithms, ", "), Default: "prim", Choices: mazegenerator.Algorithms},
+			{Name: "levels", Usage: "number of levels of the maze, joined by stairs, from 1 to " + strconv.Itoa(maxLevels), Default: "1", Check: registry.CheckWith(parseLevels)},
+			{Name: "braid", Usage: "share of the dead ends opened into loops, from 0 to 1", Default: "0", Check: registry.CheckWith(parseBraid)},
+			{Name: "solver", Usage: "the solver to watch with e: " + strings.Join(mazesolver.Algorithms, ", "), Default: "astar", Choices: mazesolver.Algorithms},
+			{Name: "mode", Usage: "the challenge: " + strings.Join(modes, ", "), Default: classic, Choices: modes},
+			{Name: "sight", Usage: "how far the player sees in the fog and memory modes", Default: "3", Check: registry.CheckWith(parseSight)},
+			{Name: "time", Usage: "time limit of the timed mode, e.g. 45s, or 0 for a quarter second per step of the shortest way", Default: "0", Check: registry.CheckWith(parseLimit)},
+		},
+		New: initialModel,
+	})
+}
+
+// minSize is the smallest maze, in either direction, and maxSize the
+// largest.
+const (
+	minSize = 8
+	maxSize = 500
 )
 
+// maxLevels is the most levels a maze can have.
+const maxLevels = 9
+
+// fitSize is the size of a maze filling the terminal until the size of the
+// terminal is known.
+var fitSize = vector{25, 15}
+
+// footerHeight is the number of lines below the maze, which a maze filling
+// the terminal leaves free, without the help that wraps on narrow ones.
+const footerHeight = 4
+
 type vector struct {
 	x int
 	y int
 }
 
+var actions = []keymap.Action{
+	keymap.Up, keymap.Down, keymap.Left, keymap.Right,
+	{Name: "new", Keys: []string{"g"}, Help: "new maze"},
+	{Name: "algorithm", Keys: []string{"a"}, Help: "next algorithm"},
+	keymap.Quit,
+}
+
+// solverActions show the way, in the classic mode only.
+var solverActions = []keymap.Action{
+	{Name: "solution", Keys: []string{"p"}, Help: "show the way"},
+	{Name: "explore", Keys: []string{"e"}, Help: "watch the solver"},
+}
+
+// stairsAction takes the stairs, in mazes of more than one level only.
+var stairsAction = keymap.Action{Name: "stairs", Keys: []string{"<", ">", "enter"}, Help: "take the stairs"}
+
+// exploreTick is how often the solver visits the next cells when watched.
+const exploreTick = 20 * time.Millisecond
+
+// exploreMsg shows more of the search of the solver. run tells which time
+// the search was watched, so that the ticks of an earlier one are dropped.
+type exploreMsg struct{ run int }
+
+func explore(c clock.Clock, run int) tea.Cmd {
+	return c.Tick(exploreTick, func(time.Time) tea.Msg {
+		return exploreMsg{run}
+	})
+}
+
 type model struct {
-	maze   [][]rune
-	pos    vector
-	endpos vector
+	// tower holds the levels of the maze, and maze is the level the player
+	// is on.
+	tower     *mazegenerator.Tower
+	maze      *mazegenerator.Maze
+	level     int
+	levels    int
+	braid     float64
+	algorithm string
+	mode      string
+	keys      *keymap.Map
+	clock     clock.Clock
+	// round counts the mazes played.
+	round int
+	// seed is the seed the maze being played is built from, so that it can
+	// be played again with it, and seeds draws the seeds of the next ones.
+	seed  uint64
+	seeds *rand.Rand
+
+	// size is the size of the next maze. It follows the terminal if fit is
+	// set.
+	size vector
+	fit  bool
+
+	pos     vector
+	moves   int
+	started time.Time
+	// elapsed is the time the game took, set once it's over.
+	elapsed time.Duration
+	over    bool
+	won     bool
+
+	// sight is how far the player sees in the fog, and seen the cells they
+	// saw, on every level.
+	sight int
+	seen  map[mazesolver.Point]bool
+
+	// left is the time left in the timed mode, and limit the time limit
+	// set, if any.
+	left  time.Duration
+	limit time.Duration
+
+	// shortest is the shortest way through, shown if showPath is set.
+	shortest []mazesolver.Point
+	showPath bool
+
+	// search is what the solver found. While exploring, the first shown of
+	// the cells it visited are drawn, a few more on every tick, and its
+	// path once they all are.
+	solver    string
+	search    mazesolver.Solution
+	exploring bool
+	shown     int
+	run       int
+
+	pathStyle    lipgloss.Style
+	visitedStyle lipgloss.Style
+
+	err error
+}
+
+func initialModel(opts registry.Options) (tea.Model, error) {
+	m := model{
+		algorithm:    opts.Get("algorithm"),
+		seeds:        opts.Rand().Rand,
+		clock:        opts.Ticker(),
+		fit:          opts.Get("size") == "fit",
+		solver:       opts.Get("solver"),
+		mode:         opts.Get("mode"),
+		seed:         opts.Seed,
+		pathStyle:    lipgloss.NewStyle().Foreground(theme.Current().Good),
+		visitedStyle: lipgloss.NewStyle().Foreground(theme.Current().Muted),
+	}
+
+	if !slices.Contains(modes, m.mode) {
+		return nil, fmt.Errorf("unknown mode %q, choose one of %s", m.mode, strings.Join(modes, ", "))
+	}
+	var err error
+	m.levels, err = parseLevels(opts.Get("levels"))
+	if err != nil {
+		return nil, err
+	}
+	m.braid, err = parseBraid(opts.Get("braid"))
+	if err != nil {
+		return nil, err
+	}
+
+	// After the moves.
+	bindings := slices.Clone(actions)
+	if m.levels > 1 {
+		bindings = slices.Insert(bindings, 4, stairsAction)
+	}
+	if m.mode == classic {
+		bindings = slices.Insert(bindings, 4, solverActions...)
+	}
+	m.keys = keymap.New("maze", bindings...)
+
+	m.sight, err = parseSight(opts.Get("sight"))
+	if err != nil {
+		return nil, err
+	}
+	m.limit, err = parseLimit(opts.Get("time"))
+	if err != nil {
+		return nil, err
+	}
+	m.size, err = parseSize(opts.Get("size"))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.generate(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
 }
 
-func initialModel() tea.Model {
-	maze := mazegenerator.GenerateMaze(25, 15, "prim")
+// parseSize parses the size of the maze, from minSize to maxSize in either
+// direction. A maze filling the terminal starts at fitSize.
+func parseSize(s string) (vector, error) {
+	if s == "fit" {
+		return fitSize, nil
+	}
+
+	width, height, err := registry.ParseSize(s)
+	if err != nil {
+		return vector{}, err
+	}
+	if width < minSize || height < minSize {
+		return vector{}, fmt.Errorf("a maze needs to be at least %dx%d, got %dx%d", minSize, minSize, width, height)
+	}
+	if width > maxSize || height > maxSize {
+		return vector{}, fmt.Errorf("a maze can be at most %dx%d, got %dx%d", maxSize, maxSize, width, height)
+	}
 
-	startpos := vector{}
-	endpos := vector{}
+	return vector{width, height}, nil
+}
 
-	for y := range maze.Grid {
-		for x := range maze.Grid[y] {
-			if maze.Get(x, y) == 'S' {
-				startpos.x = y
-				startpos.y = x
-			}
-			if maze.Get(x, y) == 'E' {
-				endpos.x = y
-				endpos.y = x
-			}
-		}
+// parseLevels parses the number of levels, from 1 to maxLevels.
+func parseLevels(s string) (int, error) {
+	levels, err := strconv.Atoi(s)
+	if err != nil || levels < 1 || levels > maxLevels {
+		return 0, fmt.Errorf("invalid levels %q: expected a number of levels, from 1 to %d", s, maxLevels)
 	}
 
-	return model{
-		maze:   maze.Grid,
-		pos:    startpos,
-		endpos: endpos,
+	return levels, nil
+}
+
+// parseBraid parses the share of the dead ends opened into loops.
+func parseBraid(s string) (float64, error) {
+	braid, err := strconv.ParseFloat(s, 64)
+	if err != nil || braid < 0 || braid > 1 {
+		return 0, fmt.Errorf("invalid braid %q: expected a share of the dead ends, from 0 to 1", s)
 	}
+
+	return braid, nil
 }
 
-func (m model) Init() tea.Cmd {
+// parseSight parses how many cells away the player sees.
+func parseSight(s string) (int, error) {
+	sight, err := strconv.Atoi(s)
+	if err != nil || sight < 1 {
+		return 0, fmt.Errorf("invalid sight %q: expected a number of cells, at least 1", s)
+	}
+
+	return sight, nil
+}
+
+// parseLimit parses the time limit of the timed mode.
+func parseLimit(s string) (time.Duration, error) {
+	limit, err := time.ParseDuration(s)
+	if err != nil || limit < 0 {
+		return 0, fmt.Errorf("invalid time limit %q: expected a duration such as 45s", s)
+	}
+
+	return limit, nil
+}
+
+// generate replaces the maze with one of the size and the algorithm of m,
+// built from its seed, and starts over.
+func (m *model) generate() error {
+	r := rng.New(m.seed).Rand
+	tower, err := mazegenerator.GenerateTower(m.size.x, m.size.y, m.levels, m.algorithm, r)
+	if err != nil {
+		return err
+	}
+	tower.Braid(m.braid, r)
+
+	bottom, above := tower.Levels[0], make([]mazesolver.Maze, 0, len(tower.Levels)-1)
+	for _, level := range tower.Levels[1:] {
+		above = append(above, level)
+	}
+	search, err := mazesolver.Solve(m.solver, bottom, above...)
+	if err != nil {
+		return err
+	}
+
+	startX, startY := bottom.GetStartPos()
+	m.tower = tower
+	m.maze = bottom
+	m.level = 0
+	m.pos = vector{startX, startY}
+	m.moves = 0
+	m.started = time.Now()
+	m.elapsed = 0
+	m.over, m.won = false, false
+	m.shortest = mazesolver.BFS(bottom, above...).Path
+	m.seen = make(map[mazesolver.Point]bool)
+	m.look()
+	m.left = m.timeLimit(len(m.shortest) - 1)
+	m.round++
+	m.showPath = false
+	m.search = search
+	m.exploring = false
+	m.shown = 0
+	m.run++
+
 	return nil
 }
 
+// start returns the command starting the countdown of the timed mode.
+func (m model) start() tea.Cmd {
+	if m.mode != timed || m.err != nil {
+		return nil
+	}
+
+	return countdown(m.clock, m.round)
+}
+
+func (m model) Init() tea.Cmd {
+	return m.start()
+}
+
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		if !m.fit {
+			return m, nil
+		}
+		help := (lipgloss.Width(m.keys.Help()) + msg.Width - 1) / max(msg.Width, 1)
+		size := vector{
+			min(max(msg.Width, minSize), maxSize),
+			min(max(msg.Height-footerHeight-help, minSize), maxSize),
+		}
+		if size == m.size {
+			return m, nil
+		}
+		m.size = size
+		// A maze being played, or lost, is kept, the next one fits.
+		if m.moves == 0 && !m.over {
+			m.err = m.generate()
+			return m, m.start()
+		}
+
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
+		switch {
+		case m.keys.Matches(msg, "quit"):
 			return m, tea.Quit
-		case "up", "k":
-			m.MovePlayer("up")
-		case "down", "j":
-			m.MovePlayer("down")
-		case "left", "h":
-			m.MovePlayer("left")
-		case "right", "l":
-			m.MovePlayer("right")
+		case m.keys.Matches(msg, "new"):
+			m.seed = m.seeds.Uint64()
+			m.err = m.generate()
+			return m, m.start()
+		case m.keys.Matches(msg, "algorithm"):
+			i := slices.Index(mazegenerator.Algorithms, m.algorithm)
+			m.algorithm = mazegenerator.Algorithms[(i+1)%len(mazegenerator.Algorithms)]
+			m.seed = m.seeds.Uint64()
+			m.err = m.generate()
+			return m, m.start()
+		case m.keys.Matches(msg, "up"):
+			return m.move(vector{0, -1})
+		case m.keys.Matches(msg, "down"):
+			return m.move(vector{0, 1})
+		case m.keys.Matches(msg, "left"):
+			return m.move(vector{-1, 0})
+		case m.keys.Matches(msg, "right"):
+			return m.move(vector{1, 0})
+		case m.keys.Matches(msg, "stairs"):
+			m.TakeStairs()
+		case m.keys.Matches(msg, "solution"):
+			m.showPath = !m.showPath
+		case m.keys.Matches(msg, "explore"):
+			m.run++
+			m.exploring = !m.exploring
+			m.shown = 0
+			if m.exploring {
+				return m, explore(m.clock, m.run)
+			}
+		}
 
+	case countdownMsg:
+		if msg.round != m.round || m.over {
+			return m, nil
 		}
-	}
+		m.left -= time.Second
+		if m.left <= 0 {
+			cmd := m.finish(false)
+			return m, cmd
+		}
+		return m, countdown(m.clock, m.round)
 
-	if m.pos == m.endpos {
-		return m, tea.Quit
+	case scores.RecordedMsg:
+		m.err = msg.Err
+		return m, nil
+
+	case exploreMsg:
+		if msg.run != m.run || !m.exploring {
+			return m, nil
+		}
+		// Long searches are sped up so that watching them takes no more
+		// than about five seconds.
+		m.shown = min(m.shown+max(1, len(m.search.Visited)/250), len(m.search.Visited))
+		if m.shown < len(m.search.Visited) {
+			return m, explore(m.clock, m.run)
+		}
+		return m, nil
 	}
 
 	return m, nil
 }
 
 func (m model) View() string {
-	s := ""
-
-	for i, row := range m.maze {
-		for j := range m.maze[i] {
-			if i == m.pos.x && j == m.pos.y {
-				s += "@"
-			} else if row[j] == 'E' {
-				s += "X"
-			} else if row[j] == '#' {
-				s += string(rune(9608))
-			} else {
-				s += " "
+	path := make(map[mazesolver.Point]bool)
+	visited := make(map[mazesolver.Point]bool)
+	if m.showPath {
+		for _, p := range m.shortest {
+			path[p] = true
+		}
+	}
+	if m.exploring {
+		for _, p := range m.search.Visited[:m.shown] {
+			visited[p] = true
+		}
+		if m.shown == len(m.search.Visited) {
+			for _, p := range m.search.Path {
+				path[p] = true
 			}
 		}
-		s += "\n"
 	}
 
-	s += "\n\nhjkl or arrows to move\n"
+	var s strings.Builder
+	endX, endY := m.maze.GetEndPos()
 
-	return s
-}
+	for y, row := range m.maze.Grid {
+		for x, cell := range row {
+			p := mazesolver.Point{X: x, Y: y, Z: m.level}
+			switch {
+			case x == m.pos.x && y == m.pos.y:
+				s.WriteString("@")
+			case x == endX && y == endY && m.maze == m.tower.Top():
+				s.WriteString("X")
+			case x == endX && y == endY:
+				s.WriteString("<")
+			case m.hidden(x, y):
+				s.WriteString(m.visitedStyle.Render("░"))
+			case cell == mazegenerator.WALL:
+				s.WriteString("█")
+			case cell == mazegenerator.DOWN:
+				s.WriteString(">")
+			case cell == mazegenerator.HBRIDGE:
+				s.WriteString("─")
+			case cell == mazegenerator.VBRIDGE:
+				s.WriteString("│")
+			case path[p]:
+				s.WriteString(m.pathStyle.Render("·"))
+			case visited[p]:
+				s.WriteString(m.visitedStyle.Render("░"))
+			default:
+				s.WriteString(" ")
+			}
+		}
+		s.WriteString("\n")
+	}
 
-func (m *model) MovePlayer(dir string) {
-	switch dir {
-	case "left":
-		m.pos.y--
-		if m.maze[m.pos.x][m.pos.y] == '#' {
-			m.pos.y++
+	steps := len(m.shortest) - 1
+	switch {
+	case m.won:
+		fmt.Fprintf(&s, "\nYou found the exit in %d moves and %s!", m.moves, m.elapsed.Round(100*time.Millisecond))
+		if m.mode == par {
+			fmt.Fprintf(&s, " Par is %d: %s.", steps, rating(m.moves, steps))
 		}
-	case "right":
-		m.pos.y++
-		if m.maze[m.pos.x][m.pos.y] == '#' {
-			m.pos.y--
+		s.WriteString("\n")
+	case m.over:
+		s.WriteString("\nOut of time! Press g for a new maze.\n")
+	default:
+		fmt.Fprintf(&s, "\n%s maze, %dx%d", m.algorithm, m.maze.Width, m.maze.Height)
+		if len(m.tower.Levels) > 1 {
+			fmt.Fprintf(&s, ", level %d of %d", m.level+1, len(m.tower.Levels))
 		}
-	case "up":
-		m.pos.x--
-		if m.maze[m.pos.x][m.pos.y] == '#' {
-			m.pos.x++
+		fmt.Fprintf(&s, ", %d moves", m.moves)
+		switch m.mode {
+		case timed:
+			fmt.Fprintf(&s, ", %s left", m.left)
+		case par:
+			fmt.Fprintf(&s, ", par %d", steps)
 		}
-	case "down":
-		m.pos.x++
-		if m.maze[m.pos.x][m.pos.y] == '#' {
-			m.pos.x--
+		s.WriteString("\n")
+	}
+
+	if m.exploring {
+		fmt.Fprintf(&s, "%s visited %d cells", m.solver, m.shown)
+		if m.shown == len(m.search.Visited) {
+			if m.search.Path == nil {
+				s.WriteString(" and found no way")
+			} else {
+				fmt.Fprintf(&s, " and found a way %d steps long", len(m.search.Path)-1)
+			}
 		}
+		s.WriteString("\n")
 	}
+
+	if m.err != nil {
+		fmt.Fprintf(&s, "Error: %v\n", m.err)
+	}
+
+	s.WriteString("\n" + m.keys.Help() + "\n")
+
+	return s.String()
+}
+
+// move moves the player in the direction dir.
+func (m model) move(dir vector) (tea.Model, tea.Cmd) {
+	cmd := m.MovePlayer(dir)
+	return m, cmd
+}
+
+// MovePlayer moves the player one cell in the direction dir, unless a wall
+// is in the way or the game is over. Crossings are passed straight through,
+// a move for every cell. Reaching the exit returns the command recording
+// the result.
+func (m *model) MovePlayer(dir vector) tea.Cmd {
+	next, moves := vector{m.pos.x + dir.x, m.pos.y + dir.y}, 1
+	for m.maze.Contains(next.x, next.y) && m.maze.IsCrossing(next.x, next.y) {
+		next = vector{next.x + dir.x, next.y + dir.y}
+		moves++
+	}
+	if m.over || !m.maze.Contains(next.x, next.y) || m.maze.IsWall(next.x, next.y) {
+		return nil
+	}
+
+	m.pos = next
+	m.moves += moves
+	m.look()
+
+	if endX, endY := m.maze.GetEndPos(); next.x == endX && next.y == endY && m.maze == m.tower.Top() {
+		return m.finish(true)
+	}
+
+	return nil
 }
 
-func Run() {
-	p := tea.NewProgram(initialModel())
+// TakeStairs takes the player up the stairs at the end of a level, or down
+// those at the start of one, which is a move.
+func (m *model) TakeStairs() {
+	if m.over {
+		return
+	}
 
-	if _, err := p.Run(); err != nil {
-		panic(err)
+	startX, startY := m.maze.GetStartPos()
+	endX, endY := m.maze.GetEndPos()
+	switch {
+	case m.pos == vector{endX, endY} && m.maze != m.tower.Top():
+		m.level++
+	case m.pos == vector{startX, startY} && m.level > 0:
+		m.level--
+	default:
+		return
 	}
+
+	m.maze = m.tower.Levels[m.level]
+	m.moves++
+	m.look()
 }
@@ -0,0 +1,263 @@
+package maze
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Kaamkiya/gg/internal/app/maze/mazesolver"
+	"github.com/Kaamkiya/gg/internal/gametest"
+	"github.com/Kaamkiya/gg/internal/registry"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var opts = registry.Options{
+	Seed:     1,
+	Settings: map[string]string{"size": "15x9", "solver": "bfs"},
+}
+
+func TestShowSolution(t *testing.T) {
+	h := gametest.New(t, "maze", opts)
+	steps := len(h.Model().(model).shortest) - 1
+
+	h.Press("p")
+	// The start and the end are drawn over the way.
+	if got := strings.Count(h.View(), "·"); got != steps-1 {
+		t.Fatalf("expected the %d cells of the way between the start and the end, got %d", steps-1, got)
+	}
+	h.Golden("solution")
+
+	h.Press("p")
+	if strings.Contains(h.View(), "·") {
+		t.Fatal("expected the way to be hidden again")
+	}
+}
+
+func TestWatchSolver(t *testing.T) {
+	h := gametest.New(t, "maze", opts)
+	m := h.Model().(model)
+
+	h.Press("e")
+	h.Advance(exploreTick)
+	if !strings.Contains(h.View(), "bfs visited 1 cells") {
+		t.Fatalf("expected the solver to visit one cell per tick:\n%s", h.View())
+	}
+
+	h.Advance(time.Duration(len(m.search.Visited)) * exploreTick)
+	if !strings.Contains(h.View(), "found a way") || strings.Count(h.View(), "·") != len(m.shortest)-2 {
+		t.Fatalf("expected the shortest way once the search is over:\n%s", h.View())
+	}
+
+	h.Press("e")
+	if strings.Contains(h.View(), "visited") || strings.Contains(h.View(), "·") {
+		t.Fatalf("expected the search to be hidden again:\n%s", h.View())
+	}
+}
+
+func TestUnknownSolver(t *testing.T) {
+	g, _ := registry.Lookup("maze")
+	o := g.DefaultOptions()
+	o.Settings["solver"] = "guess"
+
+	if _, err := g.New(o); err == nil {
+		t.Fatal("expected an error for an unknown solver")
+	}
+}
+
+// walk presses the keys that follow path, from its second cell on.
+func walk(h *gametest.Harness, path []mazesolver.Point) {
+	for i := 1; i < len(path); i++ {
+		// A crossing is passed in one move along with the cell before it.
+		if from := path[i-1]; h.Model().(model).tower.Levels[from.Z].IsCrossing(from.X, from.Y) {
+			continue
+		}
+
+		switch d := (mazesolver.Point{X: path[i].X - path[i-1].X, Y: path[i].Y - path[i-1].Y, Z: path[i].Z - path[i-1].Z}); d {
+		case mazesolver.Point{X: 1}:
+			h.Press("right")
+		case mazesolver.Point{X: -1}:
+			h.Press("left")
+		case mazesolver.Point{Y: 1}:
+			h.Press("down")
+		case mazesolver.Point{Y: -1}:
+			h.Press("up")
+		case mazesolver.Point{Z: 1}, mazesolver.Point{Z: -1}:
+			h.Press(">")
+		}
+	}
+}
+
+func TestFindTheExit(t *testing.T) {
+	h := gametest.New(t, "maze", opts)
+	m := h.Model().(model)
+
+	// Walking into a wall doesn't count as a move.
+	walk(h, m.shortest[:2])
+	at, walls := h.Model().(model).pos, 0
+	for key, d := range map[string]vector{"up": {0, -1}, "down": {0, 1}, "left": {-1, 0}, "right": {1, 0}} {
+		if m.maze.IsWall(at.x+d.x, at.y+d.y) {
+			h.Press(key)
+			walls++
+		}
+	}
+	if m := h.Model().(model); walls == 0 || m.pos != at || m.moves != 1 {
+		t.Fatalf("expected the %d walls to stop the player, got %d moves", walls, m.moves)
+	}
+
+	walk(h, m.shortest[1:])
+	want := fmt.Sprintf("You found the exit in %d moves", len(m.shortest)-1)
+	if !strings.Contains(h.View(), want) {
+		t.Fatalf("expected %q:\n%s", want, h.View())
+	}
+}
+
+func TestNewMaze(t *testing.T) {
+	h := gametest.New(t, "maze", opts)
+	first := h.Model().(model).maze
+
+	h.Press("right", "g")
+	m := h.Model().(model)
+	if slices.EqualFunc(first.Grid, m.maze.Grid, slices.Equal) || m.moves != 0 {
+		t.Fatal("expected a new maze to start over")
+	}
+
+	h.Press("a")
+	if m := h.Model().(model); m.algorithm != "backtracker" || m.maze.Width != 15 || m.maze.Height != 9 {
+		t.Fatalf("expected a 15x9 maze of the next algorithm, got a %dx%d %s maze", m.maze.Width, m.maze.Height, m.algorithm)
+	}
+}
+
+func TestFitTheTerminal(t *testing.T) {
+	h := gametest.New(t, "maze", registry.Options{Seed: 1})
+
+	h.Send(tea.WindowSizeMsg{Width: 40, Height: 30})
+	if m := h.Model().(model); m.maze.Width != 40 || m.maze.Height < 20 {
+		t.Fatalf("expected the maze to fill the terminal, got %dx%d", m.maze.Width, m.maze.Height)
+	}
+	// The help wraps on a terminal this narrow.
+	h.Press("e")
+	lines := 0
+	for _, line := range strings.Split(strings.TrimSuffix(h.View(), "\n"), "\n") {
+		lines += max(1, (lipgloss.Width(line)+39)/40)
+	}
+	if lines > 30 {
+		t.Fatalf("expected the game to fit in 30 lines, got %d:\n%s", lines, h.View())
+	}
+
+	// Once the player moved, the maze is only replaced on request.
+	walk(h, h.Model().(model).shortest[:2])
+	h.Send(tea.WindowSizeMsg{Width: 30, Height: 30})
+	if m := h.Model().(model); m.maze.Width != 40 || m.moves != 1 {
+		t.Fatalf("expected the maze being played to be kept, got a %d wide maze", m.maze.Width)
+	}
+	h.Press("g")
+	if m := h.Model().(model); m.maze.Width != 30 {
+		t.Fatalf("expected the new maze to fit the terminal, got a %d wide maze", m.maze.Width)
+	}
+}
+
+func TestTooLarge(t *testing.T) {
+	g, _ := registry.Lookup("maze")
+	o := g.DefaultOptions()
+	o.Settings["size"] = "100000x100000"
+
+	if _, err := g.New(o); err == nil {
+		t.Fatal("expected an error for a maze larger than the largest")
+	}
+
+	h := gametest.New(t, "maze", registry.Options{Seed: 1})
+	h.Send(tea.WindowSizeMsg{Width: 100000, Height: 100000})
+	if m := h.Model().(model); m.maze.Width != maxSize || m.maze.Height != maxSize {
+		t.Fatalf("expected the maze to fit the terminal up to %dx%d, got %dx%d", maxSize, maxSize, m.maze.Width, m.maze.Height)
+	}
+}
+
+func TestFixedSize(t *testing.T) {
+	h := gametest.New(t, "maze", opts)
+
+	h.Send(tea.WindowSizeMsg{Width: 40, Height: 20})
+	if m := h.Model().(model); m.maze.Width != 15 || m.maze.Height != 9 {
+		t.Fatalf("expected the size setting to win over the terminal's, got %dx%d", m.maze.Width, m.maze.Height)
+	}
+}
+
+func TestClimbTheTower(t *testing.T) {
+	h := gametest.New(t, "maze", withMode(classic, "levels", "3"))
+	m := h.Model().(model)
+	if !strings.Contains(h.View(), "level 1 of 3") || !strings.Contains(h.View(), "take the stairs") {
+		t.Fatalf("expected the first of 3 levels and the stairs to be shown:\n%s", h.View())
+	}
+
+	// The stairs only go down from the start of a level above the first.
+	h.Press(">")
+	if m := h.Model().(model); m.level != 0 || m.moves != 0 {
+		t.Fatalf("expected no stairs at the start, got to level %d", m.level)
+	}
+
+	up := slices.IndexFunc(m.shortest, func(p mazesolver.Point) bool { return p.Z == 1 })
+	walk(h, m.shortest[:up+1])
+	if !strings.Contains(h.View(), "level 2 of 3") {
+		t.Fatalf("expected to be on the second level:\n%s", h.View())
+	}
+	h.Press("<")
+	if m := h.Model().(model); m.level != 0 || m.moves != up+1 {
+		t.Fatalf("expected to go back down in a move, got to level %d in %d moves", m.level, m.moves)
+	}
+	h.Press("enter")
+
+	walk(h, m.shortest[up:])
+	want := fmt.Sprintf("You found the exit in %d moves", len(m.shortest)+1)
+	if !strings.Contains(h.View(), want) {
+		t.Fatalf("expected %q:\n%s", want, h.View())
+	}
+}
+
+func TestCrossings(t *testing.T) {
+	h := gametest.New(t, "maze", withMode(classic, "size", "31x21", "algorithm", "weave"))
+	m := h.Model().(model)
+
+	crossings := 0
+	for _, p := range m.shortest {
+		if m.maze.IsCrossing(p.X, p.Y) {
+			crossings++
+		}
+	}
+	if crossings == 0 {
+		t.Fatal("expected the way to pass a crossing")
+	}
+	if !strings.ContainsAny(h.View(), "─│") {
+		t.Fatalf("expected the crossings to be drawn:\n%s", h.View())
+	}
+
+	// Passing a crossing takes a move per cell, as on the way.
+	walk(h, m.shortest)
+	want := fmt.Sprintf("You found the exit in %d moves", len(m.shortest)-1)
+	if !strings.Contains(h.View(), want) {
+		t.Fatalf("expected %q:\n%s", want, h.View())
+	}
+}
+
+func TestInvalidLevelsAndBraid(t *testing.T) {
+	g, _ := registry.Lookup("maze")
+
+	for _, setting := range [][2]string{{"levels", "0"}, {"levels", "1000000"}, {"braid", "1.5"}} {
+		o := g.DefaultOptions()
+		o.Settings[setting[0]] = setting[1]
+		if _, err := g.New(o); err == nil {
+			t.Errorf("expected an error for %s %s", setting[0], setting[1])
+		}
+	}
+}
+
+func TestBraid(t *testing.T) {
+	braided := gametest.New(t, "maze", withMode(classic, "braid", "1")).Model().(model)
+	perfect := gametest.New(t, "maze", withMode(classic)).Model().(model)
+
+	if len(braided.shortest) > len(perfect.shortest) || slices.EqualFunc(braided.maze.Grid, perfect.maze.Grid, slices.Equal) {
+		t.Fatal("expected the braid maze to open loops into the perfect one")
+	}
+}
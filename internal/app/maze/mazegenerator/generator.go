@@ -1,21 +1,50 @@
 package mazegenerator
 
-import "math/rand/v2"
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+)
 
 type MazeGenerator interface {
 	Generate(maze *Maze)
 }
 
-func NewMazeGenerator(generator string) MazeGenerator {
+// Algorithms are the names of the generators, as NewMazeGenerator takes
+// them.
+var Algorithms = []string{"prim", "backtracker", "kruskal", "wilson", "eller", "aldous-broder", "binary-tree", "weave"}
+
+// NewMazeGenerator returns the named generator, drawing its random numbers
+// from r, or an error if there is no such generator.
+func NewMazeGenerator(generator string, r *rand.Rand) (MazeGenerator, error) {
 	switch generator {
 	case "prim":
-		return &PrimGenerator{}
+		return &PrimGenerator{rng: r}, nil
+	case "backtracker":
+		return &BacktrackerGenerator{rng: r}, nil
+	case "kruskal":
+		return &KruskalGenerator{rng: r}, nil
+	case "wilson":
+		return &WilsonGenerator{rng: r}, nil
+	case "eller":
+		return &EllerGenerator{rng: r}, nil
+	case "aldous-broder":
+		return &AldousBroderGenerator{rng: r}, nil
+	case "binary-tree":
+		return &BinaryTreeGenerator{rng: r}, nil
+	case "weave":
+		return &WeaveGenerator{rng: r}, nil
 	default:
-		return &PrimGenerator{}
+		return nil, fmt.Errorf("unknown maze algorithm %q, choose one of %s", generator, strings.Join(Algorithms, ", "))
 	}
 }
 
-type PrimGenerator struct{}
+// PrimGenerator grows the maze from the start, opening a wall at random
+// next to it each time. Its mazes branch a lot, with many short dead ends.
+type PrimGenerator struct {
+	rng *rand.Rand
+}
 
 func (p *PrimGenerator) Generate(maze *Maze) {
 	startX, startY := maze.GetStartPos()
@@ -30,7 +59,7 @@ func (p *PrimGenerator) Generate(maze *Maze) {
 
 	for len(walls) > 0 {
 		// Pop random wall
-		randIdx := rand.IntN(len(walls))
+		randIdx := p.rng.IntN(len(walls))
 		wall := walls[randIdx]
 		walls = append(walls[:randIdx], walls[randIdx+1:]...)
 
@@ -42,7 +71,7 @@ func (p *PrimGenerator) Generate(maze *Maze) {
 		if len(paths) == 0 {
 			continue
 		}
-		path := paths[rand.IntN(len(paths))]
+		path := paths[p.rng.IntN(len(paths))]
 
 		// skip special case: last wall before boundary
 		if wall.Diff(path) != 1 {
@@ -70,3 +99,308 @@ func (p *PrimGenerator) Generate(maze *Maze) {
 
 	maze.SetEnd(curr.x, curr.y)
 }
+
+// BacktrackerGenerator digs a random passage until it's stuck, then backs up
+// to the last cell it can dig from again: a depth-first search. Its mazes
+// have long winding corridors and few dead ends.
+type BacktrackerGenerator struct {
+	rng *rand.Rand
+}
+
+func (b *BacktrackerGenerator) Generate(maze *Maze) {
+	start := maze.Start
+	visited := map[Cell]bool{start: true}
+	stack := []Cell{start}
+
+	for len(stack) > 0 {
+		curr := stack[len(stack)-1]
+
+		var next []Cell
+		for _, n := range maze.neighbors(curr) {
+			if !visited[n] {
+				next = append(next, n)
+			}
+		}
+		if len(next) == 0 {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		n := next[b.rng.IntN(len(next))]
+		maze.carve(curr, n)
+		visited[n] = true
+		stack = append(stack, n)
+	}
+
+	maze.placeEnd()
+}
+
+// KruskalGenerator opens the walls between cells in random order, skipping
+// those between cells already joined. Its mazes are much like Prim's, with
+// many short dead ends spread evenly.
+type KruskalGenerator struct {
+	rng *rand.Rand
+}
+
+func (k *KruskalGenerator) Generate(maze *Maze) {
+	kruskal(maze, newDisjointSet(maze.cells()), k.rng)
+	maze.placeEnd()
+}
+
+// kruskal joins the cells of the maze not yet joined in sets, leaving out
+// the crossings, which are already open on every side.
+func kruskal(maze *Maze, sets disjointSet, r *rand.Rand) {
+	var edges [][2]Cell
+	for _, c := range maze.cells() {
+		// Right and down, so that every edge is taken once.
+		for _, dir := range DIRS[:2] {
+			n := Cell{c.x + 2*dir.x, c.y + 2*dir.y}
+			if maze.isCell(n) && !maze.IsCrossing(c.x, c.y) && !maze.IsCrossing(n.x, n.y) {
+				edges = append(edges, [2]Cell{c, n})
+			}
+		}
+	}
+	r.Shuffle(len(edges), func(i, j int) {
+		edges[i], edges[j] = edges[j], edges[i]
+	})
+
+	for _, e := range edges {
+		if sets.union(e[0], e[1]) {
+			maze.carve(e[0], e[1])
+		}
+	}
+}
+
+// WeaveGenerator lays crossings at random first, where a passage goes under
+// another, then joins the rest of the cells as Kruskal's does. Its passages
+// cross each other without meeting, so that the maze can't be read from
+// above as easily.
+type WeaveGenerator struct {
+	rng *rand.Rand
+}
+
+// weavable reports whether a crossing on c, with the passages over and under
+// it, would join cells not joined yet and make no loop.
+func weavable(sets disjointSet, over [2]Cell, c Cell, under [2]Cell) bool {
+	a, b, e := sets.find(over[0]), sets.find(c), sets.find(over[1])
+	if a == b || b == e || a == e {
+		return false
+	}
+
+	joined := func(r Cell) bool { return r == a || r == b || r == e }
+	u, v := sets.find(under[0]), sets.find(under[1])
+	return u != v && !(joined(u) && joined(v))
+}
+
+// weaveShare is the share of the cells the weave generator tries to lay a
+// crossing on.
+const weaveShare = 0.2
+
+func (w *WeaveGenerator) Generate(maze *Maze) {
+	cells := maze.cells()
+	sets := newDisjointSet(cells)
+
+	candidates := append([]Cell(nil), cells...)
+	w.rng.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+
+	for _, c := range candidates[:int(float64(len(candidates))*weaveShare)] {
+		if c == maze.Start || len(maze.neighbors(c)) < 4 {
+			continue
+		}
+
+		// The crossing can't be next to another one, as the passage under
+		// it would end in the middle of that one.
+		across := maze.neighbors(c)
+		if slices.ContainsFunc(across, func(n Cell) bool { return maze.IsCrossing(n.x, n.y) }) {
+			continue
+		}
+
+		// DIRS go right, down, left then up: the passage over the crossing
+		// is across[0] to across[2], and the one under it across[1] to
+		// across[3], or the other way around.
+		over, under := [2]Cell{across[0], across[2]}, [2]Cell{across[1], across[3]}
+		bridge := rune(HBRIDGE)
+		if w.rng.IntN(2) == 0 {
+			over, under = under, over
+			bridge = VBRIDGE
+		}
+		if !weavable(sets, over, c, under) {
+			continue
+		}
+
+		sets.union(over[0], c)
+		sets.union(c, over[1])
+		sets.union(under[0], under[1])
+		for _, n := range across {
+			maze.carve(c, n)
+		}
+		maze.Set(c.x, c.y, bridge)
+	}
+
+	kruskal(maze, sets, w.rng)
+	maze.placeEnd()
+}
+
+// WilsonGenerator walks at random from each cell not yet in the maze until
+// it meets the maze, erasing the loops of the walk, and adds the walk to the
+// maze. Every possible maze is as likely as any other, so they have no bias
+// toward long or short passages.
+type WilsonGenerator struct {
+	rng *rand.Rand
+}
+
+func (w *WilsonGenerator) Generate(maze *Maze) {
+	cells := maze.cells()
+	w.rng.Shuffle(len(cells), func(i, j int) {
+		cells[i], cells[j] = cells[j], cells[i]
+	})
+	inMaze := map[Cell]bool{maze.Start: true}
+
+	for _, c := range cells {
+		if inMaze[c] {
+			continue
+		}
+
+		// The walk only keeps the last way out of each cell, which erases
+		// the loops.
+		exit := make(map[Cell]Cell)
+		for curr := c; !inMaze[curr]; {
+			next := maze.neighbors(curr)
+			exit[curr] = next[w.rng.IntN(len(next))]
+			curr = exit[curr]
+		}
+
+		for curr := c; !inMaze[curr]; curr = exit[curr] {
+			maze.carve(curr, exit[curr])
+			inMaze[curr] = true
+		}
+	}
+
+	maze.placeEnd()
+}
+
+// EllerGenerator builds the maze one row at a time, joining cells of the
+// row at random and going down from every group of joined cells at least
+// once. Its mazes have many horizontal passages.
+type EllerGenerator struct {
+	rng *rand.Rand
+}
+
+func (e *EllerGenerator) Generate(maze *Maze) {
+	xs, ys := maze.lattice()
+	// sets[i] is the group of the cell in column i of the current row, 0 for
+	// none yet.
+	sets := make([]int, len(xs))
+	next := 1
+
+	for row, y := range ys {
+		last := row == len(ys)-1
+
+		for i := range sets {
+			if sets[i] == 0 {
+				sets[i] = next
+				next++
+			}
+		}
+
+		// The last row joins all the groups left, so that the maze is one.
+		for i := 1; i < len(xs); i++ {
+			if sets[i] == sets[i-1] || !last && e.rng.IntN(2) == 0 {
+				continue
+			}
+			maze.carve(Cell{xs[i-1], y}, Cell{xs[i], y})
+			old := sets[i]
+			for j := range sets {
+				if sets[j] == old {
+					sets[j] = sets[i-1]
+				}
+			}
+		}
+		if last {
+			break
+		}
+
+		// Groups in the order they appear, so that the same random numbers
+		// give the same maze.
+		var order []int
+		members := make(map[int][]int)
+		for i, s := range sets {
+			if len(members[s]) == 0 {
+				order = append(order, s)
+			}
+			members[s] = append(members[s], i)
+		}
+
+		below := make([]int, len(xs))
+		for _, s := range order {
+			down := false
+			for _, i := range members[s] {
+				if e.rng.IntN(2) == 0 {
+					below[i], down = s, true
+				}
+			}
+			if !down {
+				below[members[s][e.rng.IntN(len(members[s]))]] = s
+			}
+		}
+		for i, s := range below {
+			if s != 0 {
+				maze.carve(Cell{xs[i], y}, Cell{xs[i], ys[row+1]})
+			}
+		}
+		sets = below
+	}
+
+	maze.placeEnd()
+}
+
+// AldousBroderGenerator walks the grid at random, opening the way into each
+// cell the first time it gets there. Like Wilson's, it makes every possible
+// maze as likely as any other, but takes longer to do so.
+type AldousBroderGenerator struct {
+	rng *rand.Rand
+}
+
+func (a *AldousBroderGenerator) Generate(maze *Maze) {
+	left := len(maze.cells()) - 1
+	visited := map[Cell]bool{maze.Start: true}
+
+	for curr := maze.Start; left > 0; {
+		next := maze.neighbors(curr)
+		n := next[a.rng.IntN(len(next))]
+		if !visited[n] {
+			maze.carve(curr, n)
+			visited[n] = true
+			left--
+		}
+		curr = n
+	}
+
+	maze.placeEnd()
+}
+
+// BinaryTreeGenerator opens the way up or left from every cell, picked at
+// random. It's the fastest, but its mazes are biased: the top row and the
+// left column are long straight corridors, and every path leads diagonally
+// to the top left corner.
+type BinaryTreeGenerator struct {
+	rng *rand.Rand
+}
+
+func (b *BinaryTreeGenerator) Generate(maze *Maze) {
+	for _, c := range maze.cells() {
+		var ways []Cell
+		for _, n := range []Cell{{c.x, c.y - 2}, {c.x - 2, c.y}} {
+			if maze.isCell(n) {
+				ways = append(ways, n)
+			}
+		}
+		if len(ways) > 0 {
+			maze.carve(c, ways[b.rng.IntN(len(ways))])
+		}
+	}
+
+	maze.placeEnd()
+}
@@ -10,6 +10,14 @@ const (
 	PATH  = ' '
 	START = 'S'
 	END   = 'E'
+	// HBRIDGE and VBRIDGE are crossings of a weave maze, where a passage
+	// goes over the other: horizontally over a vertical one, or the other
+	// way around.
+	HBRIDGE = '-'
+	VBRIDGE = '|'
+	// UP and DOWN are the stairs between the levels of a tower.
+	UP   = '<'
+	DOWN = '>'
 )
 
 type Cell struct {
@@ -30,7 +38,18 @@ type Maze struct {
 	Grid          [][]rune
 }
 
-func NewMaze(width, height int) *Maze {
+// NewMaze returns a maze of the given size filled with walls, with the start
+// placed at random in its top left quarter.
+func NewMaze(width, height int, r *rand.Rand) *Maze {
+	startX := r.IntN(width/4) + 1
+	startY := r.IntN(height/4) + 1
+
+	return newMaze(width, height, Cell{startX, startY}, START)
+}
+
+// newMaze returns a maze of the given size filled with walls, with start
+// marked with the given rune.
+func newMaze(width, height int, start Cell, mark rune) *Maze {
 	grid := make([][]rune, height)
 
 	for i := range grid {
@@ -40,15 +59,12 @@ func NewMaze(width, height int) *Maze {
 		}
 	}
 
-	startX := rand.IntN(width/4) + 1
-	startY := rand.IntN(height/4) + 1
-
-	grid[startY][startX] = START
+	grid[start.y][start.x] = mark
 
 	return &Maze{
 		Width:  width,
 		Height: height,
-		Start:  Cell{startX, startY},
+		Start:  start,
 		Grid:   grid,
 	}
 }
@@ -85,10 +101,21 @@ func (m Maze) IsBoundary(x, y int) bool {
 	return vertical || horizontal
 }
 
+// Contains reports whether (x, y) is on the grid, border included.
+func (m Maze) Contains(x, y int) bool {
+	return x >= 0 && x < m.Width && y >= 0 && y < m.Height
+}
+
 func (m Maze) IsWall(x, y int) bool {
 	return m.Grid[y][x] == WALL
 }
 
+// IsCrossing reports whether (x, y) is a crossing, which is passed straight
+// through, over or under the other passage.
+func (m Maze) IsCrossing(x, y int) bool {
+	return m.Grid[y][x] == HBRIDGE || m.Grid[y][x] == VBRIDGE
+}
+
 func (m Maze) GetFrontiers(x, y int, findWall bool) []Cell {
 	var frontiers []Cell
 	for _, dir := range DIRS {
@@ -114,6 +141,164 @@ func (m *Maze) MakePath(cell Cell) {
 	m.Set(cell.x, cell.y, PATH)
 }
 
+// lattice returns the columns and the rows of the cells of the maze: every
+// other one inside the border, lined up with the start. The walls between
+// them are opened to join two cells.
+func (m Maze) lattice() (xs, ys []int) {
+	for x := 2 - m.Start.x%2; x < m.Width-1; x += 2 {
+		xs = append(xs, x)
+	}
+	for y := 2 - m.Start.y%2; y < m.Height-1; y += 2 {
+		ys = append(ys, y)
+	}
+
+	return xs, ys
+}
+
+// cells returns the cells of the maze, row by row.
+func (m Maze) cells() []Cell {
+	xs, ys := m.lattice()
+
+	cells := make([]Cell, 0, len(xs)*len(ys))
+	for _, y := range ys {
+		for _, x := range xs {
+			cells = append(cells, Cell{x, y})
+		}
+	}
+
+	return cells
+}
+
+// isCell reports whether c is one of the cells of the maze.
+func (m Maze) isCell(c Cell) bool {
+	return m.IsInner(c.x, c.y) && (c.x-m.Start.x)%2 == 0 && (c.y-m.Start.y)%2 == 0
+}
+
+// neighbors returns the cells next to c, walls or not between them.
+func (m Maze) neighbors(c Cell) []Cell {
+	var cells []Cell
+	for _, dir := range DIRS {
+		n := Cell{c.x + 2*dir.x, c.y + 2*dir.y}
+		if m.isCell(n) {
+			cells = append(cells, n)
+		}
+	}
+
+	return cells
+}
+
+// carve opens the cells a and b, next to each other, and the wall between
+// them.
+func (m *Maze) carve(a, b Cell) {
+	m.MakePath(a)
+	m.MakePath(Cell{(a.x + b.x) / 2, (a.y + b.y) / 2})
+	m.MakePath(b)
+}
+
+// placeEnd puts the end on the cell farthest from the start along the
+// paths, so that the way there is as long as it can be.
+func (m *Maze) placeEnd() {
+	dist := map[Cell]int{m.Start: 0}
+	queue := []Cell{m.Start}
+	end := m.Start
+
+	for len(queue) > 0 {
+		curr := queue[0]
+		queue = queue[1:]
+		if dist[curr] > dist[end] && m.isCell(curr) && m.Get(curr.x, curr.y) == PATH {
+			end = curr
+		}
+
+		for _, dir := range DIRS {
+			n := Cell{curr.x + dir.x, curr.y + dir.y}
+			if _, seen := dist[n]; seen || !m.IsInner(n.x, n.y) || m.IsWall(n.x, n.y) {
+				continue
+			}
+			dist[n] = dist[curr] + 1
+			queue = append(queue, n)
+		}
+	}
+
+	m.SetEnd(end.x, end.y)
+}
+
+// Braid opens the given share of the dead ends, from 0 to 1, into a cell
+// next to them, making loops. A maze with loops has more than one way
+// through, and no longer gets solved by keeping a hand on the wall. Dead
+// ends are opened into other dead ends first.
+func (m *Maze) Braid(share float64, r *rand.Rand) {
+	for _, c := range m.cells() {
+		if m.IsCrossing(c.x, c.y) {
+			continue
+		}
+
+		ways, closed := m.ways(c)
+		if ways != 1 || len(closed) == 0 || r.Float64() >= share {
+			continue
+		}
+
+		var deadEnds []Cell
+		for _, n := range closed {
+			if ways, _ := m.ways(n); ways == 1 {
+				deadEnds = append(deadEnds, n)
+			}
+		}
+		if len(deadEnds) > 0 {
+			closed = deadEnds
+		}
+
+		m.carve(c, closed[r.IntN(len(closed))])
+	}
+}
+
+// ways returns the number of ways out of the cell c, and the cells next to
+// it behind a wall. Crossings are never behind one.
+func (m Maze) ways(c Cell) (ways int, closed []Cell) {
+	for _, n := range m.neighbors(c) {
+		switch {
+		case !m.IsWall((c.x+n.x)/2, (c.y+n.y)/2):
+			ways++
+		case !m.IsCrossing(n.x, n.y):
+			closed = append(closed, n)
+		}
+	}
+
+	return ways, closed
+}
+
+// disjointSet keeps track of which cells are joined.
+type disjointSet map[Cell]Cell
+
+func newDisjointSet(cells []Cell) disjointSet {
+	d := make(disjointSet, len(cells))
+	for _, c := range cells {
+		d[c] = c
+	}
+
+	return d
+}
+
+// find returns the cell standing for the cells joined to c.
+func (d disjointSet) find(c Cell) Cell {
+	for d[c] != c {
+		d[c] = d[d[c]]
+		c = d[c]
+	}
+
+	return c
+}
+
+// union joins a and b, and reports whether they weren't already.
+func (d disjointSet) union(a, b Cell) bool {
+	ra, rb := d.find(a), d.find(b)
+	if ra == rb {
+		return false
+	}
+	d[ra] = rb
+
+	return true
+}
+
 func (m Maze) Print() {
 	for _, row := range m.Grid {
 		for _, cell := range row {
@@ -1,9 +1,18 @@
 package mazegenerator
 
-func GenerateMaze(width, height int, algorithm string) *Maze {
-	maze := NewMaze(width, height)
-	generator := NewMazeGenerator(algorithm)
+import "math/rand/v2"
+
+// GenerateMaze builds a maze with the named algorithm, or returns an error
+// if there is no such algorithm. The same source of random numbers always
+// gives the same maze.
+func GenerateMaze(width, height int, algorithm string, r *rand.Rand) (*Maze, error) {
+	generator, err := NewMazeGenerator(algorithm, r)
+	if err != nil {
+		return nil, err
+	}
+
+	maze := NewMaze(width, height, r)
 	generator.Generate(maze)
 
-	return maze
+	return maze, nil
 }
@@ -1,12 +1,16 @@
 package mazegenerator
 
 import (
+	"math/rand/v2"
+	"slices"
 	"testing"
+
+	"github.com/Kaamkiya/gg/internal/app/maze/mazesolver"
 )
 
 func TestPathFinder(t *testing.T) {
 	t.Run("Testing path finder on blocked maze", func(t *testing.T) {
-		maze := NewMaze(25, 25)
+		maze := NewMaze(25, 25, rand.New(rand.NewPCG(1, 1)))
 
 		startX, startY := maze.GetStartPos()
 		endX, endY := 5, 5
@@ -20,7 +24,7 @@ func TestPathFinder(t *testing.T) {
 	t.Run("Testing path finder on valid maze", func(t *testing.T) {
 		for _, grid := range mazes {
 			width, height := len(grid[0]), len(grid)
-			maze := NewMaze(width, height)
+			maze := NewMaze(width, height, rand.New(rand.NewPCG(1, 1)))
 			for i := range grid {
 				for j := range grid[i] {
 					maze.Set(j, i, grid[i][j])
@@ -50,7 +54,7 @@ func TestPathFinder(t *testing.T) {
 	t.Run("Testing path finder on invalid maze", func(t *testing.T) {
 		for _, grid := range invalidMazes {
 			width, height := len(grid[0]), len(grid)
-			maze := NewMaze(width, height)
+			maze := NewMaze(width, height, rand.New(rand.NewPCG(1, 1)))
 			for i := range grid {
 				for j := range grid[i] {
 					maze.Set(j, i, grid[i][j])
@@ -77,7 +81,10 @@ func TestPathFinder(t *testing.T) {
 func TestMazePath(t *testing.T) {
 	for i := 0; i < 1000; i++ {
 		t.Run("Testing maze", func(t *testing.T) {
-			maze := GenerateMaze(25, 15, "prim")
+			maze, err := GenerateMaze(25, 15, "prim", rand.New(rand.NewPCG(uint64(i), 0)))
+			if err != nil {
+				t.Fatal(err)
+			}
 
 			startX, startY := maze.GetStartPos()
 			endX, endY := maze.GetEndPos()
@@ -243,3 +250,222 @@ var invalidMazes = [][][]rune{
 		{'#', '#', '#', '#', '#', '#', '#', '#', '#', '#'},
 	},
 }
+
+func TestSameSeedSameMaze(t *testing.T) {
+	for _, algorithm := range Algorithms {
+		a, _ := GenerateMaze(25, 15, algorithm, rand.New(rand.NewPCG(42, 42)))
+		b, _ := GenerateMaze(25, 15, algorithm, rand.New(rand.NewPCG(42, 42)))
+
+		if !slices.EqualFunc(a.Grid, b.Grid, slices.Equal) {
+			a.Print()
+			b.Print()
+			t.Errorf("%s: Same seed should give the same maze", algorithm)
+		}
+	}
+}
+
+func TestUnknownAlgorithm(t *testing.T) {
+	if _, err := GenerateMaze(25, 15, "labyrinth", rand.New(rand.NewPCG(1, 1))); err == nil {
+		t.Errorf("Expected an error for an unknown algorithm")
+	}
+}
+
+// TestPerfectMazes checks that the mazes of every algorithm but Prim's,
+// whose walls can open onto the border, join all their cells with exactly
+// one path between any two: as many passages as cells, less one.
+func TestPerfectMazes(t *testing.T) {
+	sizes := [][2]int{{25, 15}, {8, 8}, {30, 11}, {9, 24}}
+
+	for _, algorithm := range Algorithms[1:] {
+		for i := 0; i < 100; i++ {
+			size := sizes[i%len(sizes)]
+			maze, err := GenerateMaze(size[0], size[1], algorithm, rand.New(rand.NewPCG(uint64(i), 1)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Every crossing opens one more wall, as the passage under it
+			// joins two cells through two walls.
+			open, crossings := 0, 0
+			for y := range maze.Grid {
+				for x := range maze.Grid[y] {
+					if !maze.IsWall(x, y) {
+						open++
+					}
+					if maze.IsCrossing(x, y) {
+						crossings++
+					}
+				}
+			}
+			cells := maze.cells()
+			for _, c := range cells {
+				if maze.IsWall(c.x, c.y) {
+					maze.Print()
+					t.Fatalf("%s: cell %v isn't part of the maze", algorithm, c)
+				}
+			}
+			if want := 2*len(cells) - 1 + crossings; open != want {
+				maze.Print()
+				t.Fatalf("%s: expected %d open cells, got %d", algorithm, want, open)
+			}
+
+			startX, startY := maze.GetStartPos()
+			endX, endY := maze.GetEndPos()
+			if startX == endX && startY == endY {
+				t.Fatalf("%s: Start and end positions overlap", algorithm)
+			}
+			if !isPathExists(maze, startX, startY, endX, endY) {
+				maze.Print()
+				t.Fatalf("%s: No valid path found", algorithm)
+			}
+		}
+	}
+}
+
+// TestSolvable checks that every solver finds its way through the mazes of
+// every algorithm, and that BFS and A* agree on the shortest way.
+func TestSolvable(t *testing.T) {
+	for _, algorithm := range Algorithms {
+		for i := 0; i < 50; i++ {
+			maze, err := GenerateMaze(25+i%7, 15+i%5, algorithm, rand.New(rand.NewPCG(uint64(i), 2)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			shortest := len(mazesolver.BFS(maze).Path)
+			for _, solver := range mazesolver.Algorithms {
+				s, _ := mazesolver.Solve(solver, maze)
+				switch {
+				case s.Path == nil:
+					maze.Print()
+					t.Fatalf("%s: %s found no way through", algorithm, solver)
+				case len(s.Path) < shortest:
+					t.Fatalf("%s: %s found a way shorter than BFS, %d cells long instead of %d", algorithm, solver, len(s.Path), shortest)
+				case (solver == "astar" || solver == "dead-end-filling") && len(s.Path) != shortest:
+					t.Fatalf("%s: %s found a way %d cells long, the shortest is %d", algorithm, solver, len(s.Path), shortest)
+				}
+			}
+		}
+	}
+}
+
+func TestWeave(t *testing.T) {
+	crossings := 0
+	for i := 0; i < 20; i++ {
+		maze, err := GenerateMaze(25, 15, "weave", rand.New(rand.NewPCG(uint64(i), 3)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, c := range maze.cells() {
+			if !maze.IsCrossing(c.x, c.y) {
+				continue
+			}
+			crossings++
+
+			if ways, _ := maze.ways(c); ways != 4 {
+				maze.Print()
+				t.Fatalf("expected the crossing %v to be open on every side, got %d ways", c, ways)
+			}
+			for _, n := range maze.neighbors(c) {
+				if maze.IsCrossing(n.x, n.y) {
+					maze.Print()
+					t.Fatalf("expected no crossing next to the crossing %v", c)
+				}
+			}
+		}
+	}
+
+	if crossings == 0 {
+		t.Fatal("expected the weave mazes to have crossings")
+	}
+}
+
+func TestBraid(t *testing.T) {
+	for _, algorithm := range Algorithms {
+		maze, err := GenerateMaze(25, 15, algorithm, rand.New(rand.NewPCG(1, 4)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		steps := len(mazesolver.BFS(maze).Path)
+
+		maze.Braid(1, rand.New(rand.NewPCG(1, 5)))
+		for _, c := range maze.cells() {
+			if ways, closed := maze.ways(c); ways == 1 && len(closed) > 0 {
+				maze.Print()
+				t.Fatalf("%s: expected no dead end left, got one at %v", algorithm, c)
+			}
+		}
+
+		// Loops only ever make the way shorter.
+		s := mazesolver.AStar(maze)
+		if s.Path == nil || len(s.Path) > steps {
+			maze.Print()
+			t.Fatalf("%s: expected a way of at most %d cells through the braid maze, got %d", algorithm, steps, len(s.Path))
+		}
+	}
+}
+
+func TestBraidNothing(t *testing.T) {
+	a, _ := GenerateMaze(25, 15, "prim", rand.New(rand.NewPCG(1, 1)))
+	b, _ := GenerateMaze(25, 15, "prim", rand.New(rand.NewPCG(1, 1)))
+
+	r := rand.New(rand.NewPCG(2, 2))
+	want := r.Uint64()
+	r = rand.New(rand.NewPCG(2, 2))
+
+	tower := &Tower{Levels: []*Maze{b}}
+	tower.Braid(0, r)
+	if !slices.EqualFunc(a.Grid, b.Grid, slices.Equal) {
+		t.Fatal("expected braiding none of the dead ends to leave the maze as it is")
+	}
+	if r.Uint64() != want {
+		t.Fatal("expected braiding none of the dead ends to draw no random numbers")
+	}
+}
+
+func TestTower(t *testing.T) {
+	for _, algorithm := range Algorithms {
+		tower, err := GenerateTower(25, 15, 3, algorithm, rand.New(rand.NewPCG(1, 6)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tower.Levels) != 3 {
+			t.Fatalf("%s: expected 3 levels, got %d", algorithm, len(tower.Levels))
+		}
+
+		// The bottom level is the maze GenerateMaze builds, with stairs up
+		// at its end.
+		maze, _ := GenerateMaze(25, 15, algorithm, rand.New(rand.NewPCG(1, 6)))
+		maze.Set(maze.End.x, maze.End.y, UP)
+		if !slices.EqualFunc(maze.Grid, tower.Levels[0].Grid, slices.Equal) {
+			t.Fatalf("%s: expected the bottom level to be the maze GenerateMaze builds", algorithm)
+		}
+
+		for i, level := range tower.Levels {
+			if i > 0 && (level.Start != tower.Levels[i-1].End || level.Get(level.Start.x, level.Start.y) != DOWN) {
+				t.Fatalf("%s: expected stairs down on level %d where the stairs up of the level below are", algorithm, i)
+			}
+			if i < 2 && level.Get(level.End.x, level.End.y) != UP {
+				t.Fatalf("%s: expected stairs up at the end of level %d", algorithm, i)
+			}
+		}
+		if top := tower.Top(); top.Get(top.End.x, top.End.y) != END {
+			t.Fatalf("%s: expected the exit at the end of the top level", algorithm)
+		}
+
+		above := []mazesolver.Maze{tower.Levels[1], tower.Levels[2]}
+		for _, solver := range []string{"bfs", "astar", "dead-end-filling"} {
+			s, _ := mazesolver.Solve(solver, tower.Levels[0], above...)
+			if len(s.Path) == 0 || s.Path[len(s.Path)-1].Z != 2 {
+				t.Fatalf("%s: expected %s to find the way up to the top level", algorithm, solver)
+			}
+		}
+	}
+}
+
+func TestUnknownTowerAlgorithm(t *testing.T) {
+	if _, err := GenerateTower(25, 15, 2, "nope", rand.New(rand.NewPCG(1, 1))); err == nil {
+		t.Fatal("expected an error for an unknown algorithm")
+	}
+}
@@ -0,0 +1,52 @@
+package mazegenerator
+
+import "math/rand/v2"
+
+// Tower is a maze of levels stacked on each other. The end of every level
+// but the top one is a staircase up to the start of the level above, so the
+// way goes through every level from the bottom up.
+type Tower struct {
+	Levels []*Maze
+}
+
+// GenerateTower builds a tower of the given number of levels with the named
+// algorithm, or returns an error if there is no such algorithm. Its bottom
+// level is the maze GenerateMaze builds from the same source of random
+// numbers.
+func GenerateTower(width, height, levels int, algorithm string, r *rand.Rand) (*Tower, error) {
+	generator, err := NewMazeGenerator(algorithm, r)
+	if err != nil {
+		return nil, err
+	}
+
+	maze := NewMaze(width, height, r)
+	generator.Generate(maze)
+	t := &Tower{Levels: []*Maze{maze}}
+
+	for len(t.Levels) < levels {
+		below := t.Levels[len(t.Levels)-1]
+		below.Set(below.End.x, below.End.y, UP)
+
+		maze = newMaze(width, height, below.End, DOWN)
+		generator.Generate(maze)
+		t.Levels = append(t.Levels, maze)
+	}
+
+	return t, nil
+}
+
+// Top returns the top level of the tower, which has the exit.
+func (t *Tower) Top() *Maze {
+	return t.Levels[len(t.Levels)-1]
+}
+
+// Braid opens the given share of the dead ends of every level into loops.
+func (t *Tower) Braid(share float64, r *rand.Rand) {
+	if share <= 0 {
+		return
+	}
+
+	for _, level := range t.Levels {
+		level.Braid(share, r)
+	}
+}
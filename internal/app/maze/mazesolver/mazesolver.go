@@ -0,0 +1,472 @@
+// Package mazesolver finds the way through a maze. Every solver returns the
+// path it found and the cells it visited on the way, in order, so that the
+// search can be watched step by step.
+//
+// A maze can have several levels, stacked from the bottom up and joined by
+// stairs: the end of every level but the top is a staircase up to the start
+// of the level above. Its passages can cross each other too: a crossing is
+// passed straight through, over or under the other passage.
+package mazesolver
+
+import (
+	"container/heap"
+	"fmt"
+	"strings"
+)
+
+// Maze is a grid of walls and open cells, with a start and an end.
+// *mazegenerator.Maze is one.
+type Maze interface {
+	GetStartPos() (x, y int)
+	GetEndPos() (x, y int)
+	Contains(x, y int) bool
+	IsWall(x, y int) bool
+}
+
+// Weave is a maze whose passages cross each other. It can't be turned in
+// at a crossing.
+type Weave interface {
+	IsCrossing(x, y int) bool
+}
+
+// Point is a cell of the grid, on level Z of a maze of several levels.
+type Point struct {
+	X, Y, Z int
+}
+
+// dirs are up, right, down and left, clockwise.
+var dirs = []Point{{0, -1, 0}, {1, 0, 0}, {0, 1, 0}, {-1, 0, 0}}
+
+func (p Point) add(d Point) Point {
+	return Point{p.X + d.X, p.Y + d.Y, p.Z + d.Z}
+}
+
+// Solution is what a solver found.
+type Solution struct {
+	// Path goes from the start to the end, both included, one step at a
+	// time, or is nil if the end can't be reached.
+	Path []Point
+	// Visited are the cells the solver looked at, in order. A cell can be
+	// visited more than once.
+	Visited []Point
+}
+
+// Algorithms are the names of the solvers, as Solve takes them.
+var Algorithms = []string{"bfs", "astar", "wall-follower", "dead-end-filling"}
+
+// Solve solves the maze m, with the levels above it if any, with the named
+// solver, or returns an error if there is no such solver.
+func Solve(algorithm string, m Maze, above ...Maze) (Solution, error) {
+	switch algorithm {
+	case "bfs":
+		return BFS(m, above...), nil
+	case "astar":
+		return AStar(m, above...), nil
+	case "wall-follower":
+		return WallFollower(m, above...), nil
+	case "dead-end-filling":
+		return DeadEndFilling(m, above...), nil
+	default:
+		return Solution{}, fmt.Errorf("unknown maze solver %q, choose one of %s", algorithm, strings.Join(Algorithms, ", "))
+	}
+}
+
+// graph is a maze seen as the cells a player can stand on, each joined to
+// the cells they can get to in one move.
+type graph struct {
+	levels   []Maze
+	from, to Point
+}
+
+func newGraph(m Maze, above []Maze) graph {
+	g := graph{levels: append([]Maze{m}, above...)}
+
+	x, y := m.GetStartPos()
+	g.from = Point{x, y, 0}
+	top := g.levels[len(g.levels)-1]
+	x, y = top.GetEndPos()
+	g.to = Point{x, y, len(g.levels) - 1}
+
+	return g
+}
+
+// open reports whether p is on the grid and not a wall.
+func (g graph) open(p Point) bool {
+	if p.Z < 0 || p.Z >= len(g.levels) {
+		return false
+	}
+
+	m := g.levels[p.Z]
+	return m.Contains(p.X, p.Y) && !m.IsWall(p.X, p.Y)
+}
+
+func (g graph) crossing(p Point) bool {
+	w, ok := g.levels[p.Z].(Weave)
+	return ok && w.IsCrossing(p.X, p.Y)
+}
+
+// step returns the cell reached going from p in the direction d, passing
+// crossings straight through, if it can be gone to.
+func (g graph) step(p, d Point) (Point, bool) {
+	n := p.add(d)
+	for g.open(n) && g.crossing(n) {
+		n = n.add(d)
+	}
+
+	return n, g.open(n)
+}
+
+// stairs returns the other end of the stairs at p, if there are any.
+func (g graph) stairs(p Point) (Point, bool) {
+	if p.Z+1 < len(g.levels) {
+		if x, y := g.levels[p.Z].GetEndPos(); x == p.X && y == p.Y {
+			x, y := g.levels[p.Z+1].GetStartPos()
+			return Point{x, y, p.Z + 1}, true
+		}
+	}
+	if p.Z > 0 {
+		if x, y := g.levels[p.Z].GetStartPos(); x == p.X && y == p.Y {
+			x, y := g.levels[p.Z-1].GetEndPos()
+			return Point{x, y, p.Z - 1}, true
+		}
+	}
+
+	return Point{}, false
+}
+
+// next returns the cells p leads to in one move: the stairs first, then up,
+// right, down and left.
+func (g graph) next(p Point, walkable func(Point) bool) []Point {
+	var cells []Point
+	if s, ok := g.stairs(p); ok && walkable(s) {
+		cells = append(cells, s)
+	}
+	for _, d := range dirs {
+		if n, ok := g.step(p, d); ok && walkable(n) {
+			cells = append(cells, n)
+		}
+	}
+
+	return cells
+}
+
+// cells returns the cells a player can stand on, level by level and row by
+// row.
+func (g graph) cells() []Point {
+	var cells []Point
+	for z, m := range g.levels {
+		for y := 0; m.Contains(0, y); y++ {
+			for x := 0; m.Contains(x, y); x++ {
+				if p := (Point{x, y, z}); g.open(p) && !g.crossing(p) {
+					cells = append(cells, p)
+				}
+			}
+		}
+	}
+
+	return cells
+}
+
+// moves returns the number of moves from a to b, a cell a leads to: one
+// for stairs, and one for every cell on the way otherwise, crossings
+// included.
+func moves(a, b Point) int {
+	if a.Z != b.Z {
+		return 1
+	}
+
+	return abs(a.X-b.X) + abs(a.Y-b.Y)
+}
+
+// BFS searches the maze breadth first, visiting the cells in order of their
+// distance to the start. Its path is one of the shortest.
+func BFS(m Maze, above ...Maze) Solution {
+	g := newGraph(m, above)
+	return bfs(g, g.open)
+}
+
+// bfs searches the cells of g for which walkable is true breadth first.
+// Crossings make some moves longer than others, so it searches by the
+// number of moves, not of cells.
+func bfs(g graph, walkable func(Point) bool) Solution {
+	parent := map[Point]Point{g.from: g.from}
+	dist := map[Point]int{g.from: 0}
+	// buckets[d] are the cells d moves away from the start, waiting.
+	buckets := [][]Point{{g.from}}
+
+	var s Solution
+	for d := 0; d < len(buckets); d++ {
+		for i := 0; i < len(buckets[d]); i++ {
+			curr := buckets[d][i]
+			if dist[curr] != d {
+				continue
+			}
+			s.Visited = append(s.Visited, curr)
+			if curr == g.to {
+				s.Path = trace(parent, g.to)
+				return s
+			}
+
+			for _, n := range g.next(curr, walkable) {
+				nd := d + moves(curr, n)
+				if old, seen := dist[n]; seen && old <= nd {
+					continue
+				}
+				dist[n], parent[n] = nd, curr
+				for len(buckets) <= nd {
+					buckets = append(buckets, nil)
+				}
+				buckets[nd] = append(buckets[nd], n)
+			}
+		}
+	}
+
+	return s
+}
+
+// AStar searches the cells closest to the end first, by their distance to
+// the start plus their Manhattan distance to the end. Its path is one of
+// the shortest, like that of BFS, but it usually visits fewer cells. On
+// another level than the end, it only counts the stairs left to take.
+func AStar(m Maze, above ...Maze) Solution {
+	g := newGraph(m, above)
+	estimate := func(p Point) int {
+		if p.Z != g.to.Z {
+			return abs(p.Z - g.to.Z)
+		}
+		return abs(p.X-g.to.X) + abs(p.Y-g.to.Y)
+	}
+
+	parent := map[Point]Point{g.from: g.from}
+	dist := map[Point]int{g.from: 0}
+	done := make(map[Point]bool)
+	queue := &frontier{{g.from, estimate(g.from), estimate(g.from), 0}}
+	pushed := 1
+
+	var s Solution
+	for queue.Len() > 0 {
+		curr := heap.Pop(queue).(node).p
+		if done[curr] {
+			continue
+		}
+		done[curr] = true
+		s.Visited = append(s.Visited, curr)
+		if curr == g.to {
+			s.Path = trace(parent, g.to)
+			return s
+		}
+
+		for _, n := range g.next(curr, g.open) {
+			if done[n] {
+				continue
+			}
+			nd := dist[curr] + moves(curr, n)
+			if old, ok := dist[n]; ok && old <= nd {
+				continue
+			}
+			dist[n] = nd
+			parent[n] = curr
+			heap.Push(queue, node{n, nd + estimate(n), estimate(n), pushed})
+			pushed++
+		}
+	}
+
+	return s
+}
+
+// WallFollower walks the maze keeping its left hand on the wall, as a
+// person lost in it would, and takes the stairs it finds unless it just
+// came down or up them. It finds the end of any maze without loops, but
+// its path is rarely the shortest. In a maze with loops it can walk around
+// an island forever, so it gives up once it's back where it was, facing the
+// same way.
+func WallFollower(m Maze, above ...Maze) Solution {
+	g := newGraph(m, above)
+	type state struct {
+		p       Point
+		facing  int
+		climbed bool
+	}
+
+	curr := state{p: g.from}
+	walked := make(map[state]bool)
+	// at is where each cell of the path is in it, so that the loops the
+	// walk makes are erased from the path.
+	at := map[Point]int{curr.p: 0}
+
+	s := Solution{Path: []Point{curr.p}, Visited: []Point{curr.p}}
+	for curr.p != g.to {
+		if walked[curr] {
+			return Solution{Visited: s.Visited}
+		}
+		walked[curr] = true
+
+		next, moved := curr, false
+		if up, ok := g.stairs(curr.p); ok && !curr.climbed {
+			next, moved = state{up, curr.facing, true}, true
+		}
+		// Left first, then straight, right and back.
+		for _, turn := range []int{3, 0, 1, 2} {
+			if moved {
+				break
+			}
+			d := (curr.facing + turn) % len(dirs)
+			if n, ok := g.step(curr.p, dirs[d]); ok {
+				next, moved = state{n, d, false}, true
+			}
+		}
+		if !moved {
+			return Solution{Visited: s.Visited}
+		}
+		curr = next
+
+		s.Visited = append(s.Visited, curr.p)
+		if i, ok := at[curr.p]; ok {
+			for _, p := range s.Path[i+1:] {
+				delete(at, p)
+			}
+			s.Path = s.Path[:i+1]
+			continue
+		}
+		at[curr.p] = len(s.Path)
+		s.Path = append(s.Path, curr.p)
+	}
+
+	s.Path = expand(s.Path)
+	return s
+}
+
+// DeadEndFilling fills every dead end of the maze but the start and the
+// end, and the dead ends that makes, until none are left. What is left
+// open is the way through; in a maze with loops, the shortest way is taken
+// among what is left. It visits the cells it fills.
+func DeadEndFilling(m Maze, above ...Maze) Solution {
+	g := newGraph(m, above)
+	filled := make(map[Point]bool)
+	walkable := func(p Point) bool {
+		return g.open(p) && !filled[p]
+	}
+	deadEnd := func(p Point) bool {
+		if p == g.from || p == g.to || !walkable(p) || g.crossing(p) {
+			return false
+		}
+		return len(g.next(p, walkable)) <= 1
+	}
+
+	var queue []Point
+	for _, p := range g.cells() {
+		if deadEnd(p) {
+			queue = append(queue, p)
+		}
+	}
+
+	var visited []Point
+	for len(queue) > 0 {
+		curr := queue[0]
+		queue = queue[1:]
+		if !deadEnd(curr) {
+			continue
+		}
+		filled[curr] = true
+		visited = append(visited, curr)
+
+		for _, n := range g.next(curr, g.open) {
+			if deadEnd(n) {
+				queue = append(queue, n)
+			}
+		}
+	}
+
+	s := bfs(g, walkable)
+	s.Visited = visited
+	return s
+}
+
+// trace follows the parents back from p to the start, whose parent is
+// itself, and returns the way from the start to p, one step at a time.
+func trace(parent map[Point]Point, p Point) []Point {
+	path := []Point{p}
+	for parent[p] != p {
+		p = parent[p]
+		path = append(path, p)
+	}
+
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+
+	return expand(path)
+}
+
+// expand adds the crossings passed between the cells of path.
+func expand(path []Point) []Point {
+	var steps []Point
+	for i, p := range path {
+		if i > 0 && p.Z == path[i-1].Z {
+			prev := path[i-1]
+			d := Point{sign(p.X - prev.X), sign(p.Y - prev.Y), 0}
+			for c := prev.add(d); c != p; c = c.add(d) {
+				steps = append(steps, c)
+			}
+		}
+		steps = append(steps, p)
+	}
+
+	return steps
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+
+	return 0
+}
+
+// node is a cell waiting in the frontier of A*, with its estimated cost,
+// its estimated distance to the end and the order it was added in.
+type node struct {
+	p     Point
+	cost  int
+	left  int
+	order int
+}
+
+// frontier is the priority queue of A*, the cheapest node first. Of nodes
+// as cheap, the one closest to the end goes first, then the oldest.
+type frontier []node
+
+func (f frontier) Len() int { return len(f) }
+
+func (f frontier) Less(i, j int) bool {
+	if f[i].cost != f[j].cost {
+		return f[i].cost < f[j].cost
+	}
+	if f[i].left != f[j].left {
+		return f[i].left < f[j].left
+	}
+
+	return f[i].order < f[j].order
+}
+
+func (f frontier) Swap(i, j int) { f[i], f[j] = f[j], f[i] }
+func (f *frontier) Push(x any)   { *f = append(*f, x.(node)) }
+
+func (f *frontier) Pop() any {
+	old := *f
+	n := old[len(old)-1]
+	*f = old[:len(old)-1]
+
+	return n
+}
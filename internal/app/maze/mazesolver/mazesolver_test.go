@@ -0,0 +1,145 @@
+package mazesolver
+
+import (
+	"strings"
+	"testing"
+)
+
+// grid is a maze drawn as text: '#' walls, 'S' the start and 'E' the end.
+type grid []string
+
+func (g grid) find(c byte) (x, y int) {
+	for y, row := range g {
+		if x := strings.IndexByte(row, c); x >= 0 {
+			return x, y
+		}
+	}
+
+	return -1, -1
+}
+
+func (g grid) GetStartPos() (x, y int) { return g.find('S') }
+func (g grid) GetEndPos() (x, y int)   { return g.find('E') }
+func (g grid) IsWall(x, y int) bool    { return g[y][x] == '#' }
+
+func (g grid) Contains(x, y int) bool {
+	return y >= 0 && y < len(g) && x >= 0 && x < len(g[y])
+}
+
+var (
+	// corridors has a single way through, 19 cells long, and a dead end.
+	corridors = grid{
+		"#########",
+		"#S      #",
+		"####### #",
+		"#E    # #",
+		"##### # #",
+		"#       #",
+		"#########",
+	}
+	// loops has two ways around a block, the shorter 7 cells long. Keeping
+	// its left hand on the block, the wall follower never finds the way
+	// out.
+	loops = grid{
+		"#########",
+		"#       #",
+		"# #####S#",
+		"# ##### #",
+		"#       #",
+		"####E####",
+		"#########",
+	}
+	// walledIn has no way to the end.
+	walledIn = grid{
+		"#######",
+		"#S  # #",
+		"#   #E#",
+		"#######",
+	}
+)
+
+// checkPath fails t unless path goes from the start to the end of m, with
+// the levels above it, one open cell or one staircase at a time.
+func checkPath(t *testing.T, path []Point, m Maze, above ...Maze) {
+	t.Helper()
+
+	g := newGraph(m, above)
+	if len(path) == 0 || path[0] != g.from || path[len(path)-1] != g.to {
+		t.Fatalf("expected a path from %v to %v, got %v", g.from, g.to, path)
+	}
+	for i, p := range path {
+		if !g.open(p) {
+			t.Fatalf("the path goes through the wall at %v", p)
+		}
+		if i == 0 {
+			continue
+		}
+		if up, ok := g.stairs(path[i-1]); ok && up == p {
+			continue
+		}
+		if p.Z != path[i-1].Z || abs(p.X-path[i-1].X)+abs(p.Y-path[i-1].Y) != 1 {
+			t.Fatalf("the path jumps from %v to %v", path[i-1], p)
+		}
+	}
+}
+
+func TestSolve(t *testing.T) {
+	for _, algorithm := range Algorithms {
+		s, err := Solve(algorithm, corridors)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkPath(t, s.Path, corridors)
+		if len(s.Path) != 19 {
+			t.Errorf("%s: expected the only path, 19 cells long, got %d", algorithm, len(s.Path))
+		}
+		if len(s.Visited) == 0 {
+			t.Errorf("%s: expected the cells visited", algorithm)
+		}
+	}
+}
+
+func TestShortestPath(t *testing.T) {
+	for name, solve := range map[string]func(Maze, ...Maze) Solution{"bfs": BFS, "astar": AStar, "dead-end-filling": DeadEndFilling} {
+		s := solve(loops)
+		checkPath(t, s.Path, loops)
+		if len(s.Path) != 7 {
+			t.Errorf("%s: expected the shortest path, 7 cells long, got %d: %v", name, len(s.Path), s.Path)
+		}
+	}
+}
+
+func TestAStarVisitsLess(t *testing.T) {
+	open := grid{
+		"###########",
+		"#S        #",
+		"#         #",
+		"#         #",
+		"#        E#",
+		"###########",
+	}
+
+	if a, b := AStar(open), BFS(open); len(a.Visited) >= len(b.Visited) {
+		t.Errorf("expected A* to visit fewer cells than BFS, got %d and %d", len(a.Visited), len(b.Visited))
+	}
+}
+
+func TestWallFollowerGivesUp(t *testing.T) {
+	if s := WallFollower(loops); s.Path != nil {
+		t.Errorf("expected the wall follower to go around the island, got %v", s.Path)
+	}
+}
+
+func TestNoWay(t *testing.T) {
+	for _, algorithm := range Algorithms {
+		if s, _ := Solve(algorithm, walledIn); s.Path != nil {
+			t.Errorf("%s: expected no path, got %v", algorithm, s.Path)
+		}
+	}
+}
+
+func TestUnknownSolver(t *testing.T) {
+	if _, err := Solve("guess", corridors); err == nil {
+		t.Error("expected an error for an unknown solver")
+	}
+}
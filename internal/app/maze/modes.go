@@ -0,0 +1,149 @@
+package maze
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Kaamkiya/gg/internal/app/maze/mazesolver"
+	"github.com/Kaamkiya/gg/internal/clock"
+	"github.com/Kaamkiya/gg/internal/scores"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The modes of the game. All but the classic one are challenges, in which
+// the way can't be shown.
+const (
+	// classic shows the whole maze.
+	classic = "classic"
+	// fog only shows the cells within sight of the player.
+	fog = "fog"
+	// memory shows the cells within sight of the player, and those seen
+	// before.
+	memory = "memory"
+	// timed gives the player a time limit.
+	timed = "timed"
+	// par rates the moves of the player against the shortest way.
+	par = "par"
+)
+
+var modes = []string{classic, fog, memory, timed, par}
+
+// scoreName returns the name the results of mode are recorded under, so
+// that every mode has its own leaderboard.
+func scoreName(mode string) string {
+	if mode == classic {
+		return "maze"
+	}
+
+	return "maze/" + mode
+}
+
+// countdownMsg takes a second off the time limit. round tells which maze it
+// is for, so that the ticks of an earlier one are dropped.
+type countdownMsg struct{ round int }
+
+func countdown(c clock.Clock, round int) tea.Cmd {
+	return c.Tick(time.Second, func(time.Time) tea.Msg {
+		return countdownMsg{round}
+	})
+}
+
+// stepTime is the time limit per step of the shortest way when the limit
+// isn't set, and minTime the shortest such limit.
+const (
+	stepTime = 250 * time.Millisecond
+	minTime  = 10 * time.Second
+)
+
+// timeLimit returns the time limit of a maze whose shortest way takes steps.
+func (m model) timeLimit(steps int) time.Duration {
+	if m.limit > 0 {
+		return m.limit
+	}
+
+	return max(minTime, (time.Duration(steps) * stepTime).Round(time.Second))
+}
+
+// inSight reports whether the cell (x, y) is close enough to the player to
+// be seen.
+func (m model) inSight(x, y int) bool {
+	dx, dy := x-m.pos.x, y-m.pos.y
+	return dx*dx+dy*dy <= m.sight*m.sight
+}
+
+// look marks the cells in sight of the player as seen.
+func (m *model) look() {
+	for y := m.pos.y - m.sight; y <= m.pos.y+m.sight; y++ {
+		for x := m.pos.x - m.sight; x <= m.pos.x+m.sight; x++ {
+			if m.maze.Contains(x, y) && m.inSight(x, y) {
+				m.seen[mazesolver.Point{X: x, Y: y, Z: m.level}] = true
+			}
+		}
+	}
+}
+
+// hidden reports whether the cell (x, y) is hidden in the fog. The exit
+// never is, so that the player knows where to go.
+func (m model) hidden(x, y int) bool {
+	switch m.mode {
+	case fog:
+		return !m.inSight(x, y)
+	case memory:
+		return !m.seen[mazesolver.Point{X: x, Y: y, Z: m.level}]
+	}
+
+	return false
+}
+
+// rating rates moves against the steps of the shortest way.
+func rating(moves, steps int) string {
+	switch {
+	case moves <= steps:
+		return "perfect"
+	case moves*4 <= steps*5:
+		return "great"
+	case moves*2 <= steps*3:
+		return "good"
+	case moves <= steps*2:
+		return "fair"
+	default:
+		return "lost"
+	}
+}
+
+// finish ends the game, won or lost, and returns the command recording its
+// result under its mode. A won game scores how close the player came to the
+// shortest way, 100 being the shortest, or in the timed mode the share of
+// the time limit left, so that a longer limit scores no better.
+func (m *model) finish(won bool) tea.Cmd {
+	m.over = true
+	m.won = won
+
+	steps := len(m.shortest) - 1
+	r := scores.Result{
+		Duration: time.Since(m.started),
+		Seed:     m.seed,
+		Detail:   fmt.Sprintf("%s %dx%d, %d moves, par %d", m.algorithm, m.maze.Width, m.maze.Height, m.moves, steps),
+	}
+
+	switch {
+	case m.mode == timed:
+		limit := m.timeLimit(steps)
+		r.Duration = limit - m.left
+		if won {
+			r.Score = int(100 * m.left / limit)
+			r.Detail += fmt.Sprintf(", %s limit", limit)
+		} else {
+			r.Detail = fmt.Sprintf("%s %dx%d, out of time", m.algorithm, m.maze.Width, m.maze.Height)
+		}
+	case won:
+		r.Score = 100 * steps / max(m.moves, 1)
+		if m.mode == par {
+			r.Detail += ", " + rating(m.moves, steps)
+		}
+	}
+	m.elapsed = r.Duration
+
+	return scores.RecordCmd(scoreName(m.mode), r)
+}
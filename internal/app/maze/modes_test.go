@@ -0,0 +1,216 @@
+package maze
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Kaamkiya/gg/internal/app/maze/mazesolver"
+	"github.com/Kaamkiya/gg/internal/gametest"
+	"github.com/Kaamkiya/gg/internal/registry"
+	"github.com/Kaamkiya/gg/internal/scores"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// withMode returns opts for a game in mode, with settings on top.
+func withMode(mode string, settings ...string) registry.Options {
+	o := registry.Options{Seed: 1, Settings: map[string]string{"size": "15x9", "mode": mode}}
+	for i := 0; i+1 < len(settings); i += 2 {
+		o.Settings[settings[i]] = settings[i+1]
+	}
+
+	return o
+}
+
+// recorded returns the stats of the maze in mode.
+func recorded(t *testing.T, mode string) scores.Stats {
+	t.Helper()
+
+	store, err := scores.Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, err := store.Stats(scoreName(mode))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return stats
+}
+
+func TestFog(t *testing.T) {
+	h := gametest.New(t, "maze", withMode(fog, "sight", "2"))
+	m := h.Model().(model)
+	h.Golden("fog")
+
+	far := vector{m.pos.x + 3, m.pos.y}
+	if !m.hidden(far.x, far.y) || m.hidden(m.pos.x+2, m.pos.y) {
+		t.Fatal("expected the cells two away to be seen, and no farther")
+	}
+
+	// Three cells ahead of the start, two ahead once the player moved.
+	step := m.shortest[1]
+	ahead := vector{m.pos.x + 3*(step.X-m.pos.x), m.pos.y + 3*(step.Y-m.pos.y)}
+	walk(h, m.shortest[:2])
+	if !m.hidden(ahead.x, ahead.y) || h.Model().(model).hidden(ahead.x, ahead.y) {
+		t.Fatal("expected the fog to follow the player")
+	}
+}
+
+func TestMemory(t *testing.T) {
+	h := gametest.New(t, "maze", withMode(memory, "sight", "1"))
+	m := h.Model().(model)
+
+	walk(h, m.shortest[:4])
+	if h.Model().(model).hidden(m.pos.x, m.pos.y) {
+		t.Fatal("expected the start to stay seen once left")
+	}
+	if !h.Model().(model).hidden(m.pos.x+5, m.pos.y+5) {
+		t.Fatal("expected the cells never seen to be hidden")
+	}
+}
+
+func TestChallengesHideTheWay(t *testing.T) {
+	for _, mode := range modes[1:] {
+		h := gametest.New(t, "maze", withMode(mode))
+
+		h.Press("p", "e")
+		if view := h.View(); strings.Contains(view, "show the way") || strings.Contains(view, "·") {
+			t.Fatalf("%s: expected the way to stay hidden:\n%s", mode, view)
+		}
+	}
+}
+
+func TestTimedRunsOut(t *testing.T) {
+	h := gametest.New(t, "maze", withMode(timed, "time", "5s"))
+
+	h.Advance(4 * time.Second)
+	if view := h.View(); !strings.Contains(view, "1s left") {
+		t.Fatalf("expected a second left:\n%s", view)
+	}
+
+	h.Advance(time.Second)
+	if view := h.View(); !strings.Contains(view, "Out of time!") {
+		t.Fatalf("expected the time to be up:\n%s", view)
+	}
+	pos := h.Model().(model).pos
+	h.Press("up", "down", "left", "right")
+	if h.Model().(model).pos != pos {
+		t.Fatal("expected the player to stop once the time is up")
+	}
+
+	if s := recorded(t, timed); s.Played != 1 || s.Best[0].Score != 0 || s.Best[0].Duration != 5*time.Second {
+		t.Fatalf("expected the loss to be recorded, got %+v", s)
+	}
+}
+
+func TestOutOfTimeKeptOnResize(t *testing.T) {
+	h := gametest.New(t, "maze", withMode(timed, "time", "5s", "size", "fit"))
+
+	h.Advance(5 * time.Second)
+	h.Send(tea.WindowSizeMsg{Width: 40, Height: 30})
+	if view := h.View(); !strings.Contains(view, "Out of time!") {
+		t.Fatalf("expected resizing the terminal to keep the lost maze:\n%s", view)
+	}
+}
+
+func TestTimedWin(t *testing.T) {
+	h := gametest.New(t, "maze", withMode(timed, "time", "30s"))
+
+	h.Advance(3 * time.Second)
+	walk(h, h.Model().(model).shortest)
+
+	if s := recorded(t, timed); s.Played != 1 || s.Best[0].Score != 90 {
+		t.Fatalf("expected a score of 90 for the 27 of 30 seconds left, got %+v", s)
+	}
+
+	// A new maze starts a new countdown, which the old one doesn't speed up.
+	h.Press("g")
+	h.Advance(time.Second)
+	if m := h.Model().(model); m.left != 29*time.Second {
+		t.Fatalf("expected 29s left, got %s", m.left)
+	}
+}
+
+func TestTimeLimitFromTheWay(t *testing.T) {
+	m := model{}
+	if got := m.timeLimit(100); got != 25*time.Second {
+		t.Errorf("expected a quarter second per step, got %s", got)
+	}
+	if got := m.timeLimit(4); got != minTime {
+		t.Errorf("expected at least %s, got %s", minTime, got)
+	}
+}
+
+func TestPar(t *testing.T) {
+	h := gametest.New(t, "maze", withMode(par))
+	m := h.Model().(model)
+
+	// A step back and forth, two more than the shortest way.
+	walk(h, m.shortest[:2])
+	walk(h, []mazesolver.Point{m.shortest[1], m.shortest[0], m.shortest[1]})
+	walk(h, m.shortest[1:])
+
+	steps := len(m.shortest) - 1
+	if view := h.View(); !strings.Contains(view, "Par is 14: great.") {
+		t.Fatalf("expected a rating of %d moves for a par of %d:\n%s", steps+2, steps, view)
+	}
+	if s := recorded(t, par); s.Played != 1 || s.Best[0].Score != 100*steps/(steps+2) || !strings.HasSuffix(s.Best[0].Detail, "great") {
+		t.Fatalf("expected the result to be recorded under the par mode, got %+v", s)
+	}
+	if s := recorded(t, classic); s.Played != 0 {
+		t.Fatalf("expected nothing recorded under the classic mode, got %+v", s)
+	}
+}
+
+func TestRating(t *testing.T) {
+	for _, tt := range []struct {
+		moves int
+		want  string
+	}{
+		{20, "perfect"}, {25, "great"}, {26, "good"}, {30, "good"}, {40, "fair"}, {41, "lost"},
+	} {
+		if got := rating(tt.moves, 20); got != tt.want {
+			t.Errorf("%d moves for a par of 20: expected %s, got %s", tt.moves, tt.want, got)
+		}
+	}
+}
+
+func TestUnknownMode(t *testing.T) {
+	g, _ := registry.Lookup("maze")
+	for setting, value := range map[string]string{"mode": "blind", "sight": "0", "time": "soon"} {
+		o := g.DefaultOptions()
+		o.Settings[setting] = value
+
+		if _, err := g.New(o); err == nil {
+			t.Errorf("expected an error for the %s %q", setting, value)
+		}
+	}
+}
+
+// TestSeedPerMaze checks that the seed kept with a result builds the maze
+// that was played, whether it is the first one or not.
+func TestSeedPerMaze(t *testing.T) {
+	h := gametest.New(t, "maze", withMode(classic))
+	first := h.Model().(model)
+
+	h.Press("g")
+	m := h.Model().(model)
+	if m.seed == first.seed {
+		t.Fatal("expected the new maze to have a seed of its own")
+	}
+
+	o := withMode(classic)
+	o.Seed = m.seed
+	again := gametest.New(t, "maze", o).Model().(model)
+	if !slices.EqualFunc(again.maze.Grid, m.maze.Grid, slices.Equal) {
+		t.Fatal("expected the seed of the maze to build it again")
+	}
+
+	walk(h, m.shortest)
+	if s := recorded(t, classic); s.Played != 1 || s.Best[0].Seed != m.seed {
+		t.Fatalf("expected the seed of the maze played to be recorded, got %+v", s.Best)
+	}
+}
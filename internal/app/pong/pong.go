@@ -4,10 +4,32 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/Kaamkiya/gg/internal/clock"
+	"github.com/Kaamkiya/gg/internal/keymap"
+	"github.com/Kaamkiya/gg/internal/netplay"
+	"github.com/Kaamkiya/gg/internal/registry"
+	"github.com/Kaamkiya/gg/internal/scores"
+	"github.com/Kaamkiya/gg/internal/theme"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
+func init() {
+	registry.Register(registry.Game{
+		Name:        "pong",
+		Title:       "pong",
+		Description: "keep the ball in play; a/d and the arrow keys move the paddles",
+		Players:     2,
+		Networked:   true,
+		New:         newModel,
+	})
+}
+
+func newModel(opts registry.Options) (tea.Model, error) {
+	return initialModel(opts.Ticker()), nil
+}
+
 type vector struct {
 	x int
 	y int
@@ -20,6 +42,23 @@ type ballBody struct {
 
 type moveBallMsg struct{}
 
+func moveBall(c clock.Clock) tea.Cmd {
+	return c.Tick(300*time.Millisecond, func(time.Time) tea.Msg {
+		return moveBallMsg{}
+	})
+}
+
+// actions move the paddles; player 1 is on the left of the keyboard.
+var actions = []keymap.Action{
+	{Name: "left1", Keys: []string{"a"}, Help: "player 1 left"},
+	{Name: "right1", Keys: []string{"d"}, Help: "player 1 right"},
+	{Name: "left2", Keys: []string{"left"}, Help: "player 2 left"},
+	{Name: "right2", Keys: []string{"right"}, Help: "player 2 right"},
+	keymap.Quit,
+}
+
+var _ netplay.Model = model{}
+
 type model struct {
 	hitCount int
 
@@ -31,13 +70,22 @@ type model struct {
 	ball ballBody
 
 	colors []lipgloss.Style
+
+	clock clock.Clock
+	keys  *keymap.Map
+
+	started time.Time
+	err     error
 }
 
-func initialModel() tea.Model {
+func initialModel(c clock.Clock) tea.Model {
 	size := vector{30, 15}
 
 	return model{
 		hitCount: 0,
+		clock:    c,
+		keys:     keymap.New("pong", actions...),
+		started:  time.Now(),
 		size:     size,
 		paddle1:  vector{1, 8},
 		paddle2:  vector{size.x - 1, 7},
@@ -46,29 +94,29 @@ func initialModel() tea.Model {
 			vel: vector{1, 1},
 		},
 		colors: []lipgloss.Style{
-			lipgloss.NewStyle().Foreground(lipgloss.Color("#aaaaff")),
-			lipgloss.NewStyle().Foreground(lipgloss.Color("#ffaaaa")),
+			lipgloss.NewStyle().Foreground(theme.Current().Player1),
+			lipgloss.NewStyle().Foreground(theme.Current().Player2),
 		},
 	}
 }
 
 func (m model) Init() tea.Cmd {
-	return nil
+	return moveBall(m.clock)
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
+		switch {
+		case m.keys.Matches(msg, "quit"):
 			return m, tea.Quit
-		case "a":
+		case m.keys.Matches(msg, "left1"):
 			m.MovePaddle(1, -1)
-		case "d":
+		case m.keys.Matches(msg, "right1"):
 			m.MovePaddle(1, 1)
-		case "left":
+		case m.keys.Matches(msg, "left2"):
 			m.MovePaddle(2, -1)
-		case "right":
+		case m.keys.Matches(msg, "right2"):
 			m.MovePaddle(2, 1)
 		}
 	case moveBallMsg:
@@ -85,12 +133,22 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.hitCount++
 		}
 
+		// The game ends once the score is saved.
 		if m.ball.pos.x == 0 || m.ball.pos.x >= m.size.x {
-			return m, tea.Quit
+			return m, scores.RecordCmd("pong", scores.Result{
+				Score:    m.hitCount,
+				Duration: time.Since(m.started),
+			})
 		}
 
 		m.ball.pos.x += m.ball.vel.x
 		m.ball.pos.y += m.ball.vel.y
+
+		return m, moveBall(m.clock)
+
+	case scores.RecordedMsg:
+		m.err = msg.Err
+		return m, tea.Quit
 	}
 	return m, nil
 }
@@ -119,10 +177,51 @@ func (m model) View() string {
 	}
 
 	s += fmt.Sprintf("\nHit count: %d\n", m.hitCount)
+	s += m.keys.Help() + "\n"
+	if m.err != nil {
+		s += fmt.Sprintf("Could not save the score: %v\n", m.err)
+	}
 
 	return s
 }
 
+// Action returns the way the player on side moves their paddle with key.
+// Over the network each player has a paddle of their own, which both
+// pairs of keys move.
+func (m model) Action(_ netplay.Side, key tea.KeyMsg) (netplay.Action, bool) {
+	switch {
+	case m.keys.Matches(key, "left1"), m.keys.Matches(key, "left2"):
+		return netplay.Action{Input: "left"}, true
+	case m.keys.Matches(key, "right1"), m.keys.Matches(key, "right2"):
+		return netplay.Action{Input: "right"}, true
+	}
+
+	return netplay.Action{}, false
+}
+
+// Play moves the paddle of the player on side, the host's being player 1.
+func (m model) Play(side netplay.Side, action netplay.Action) (tea.Model, tea.Cmd, error) {
+	switch action.Input {
+	case "left":
+		m.MovePaddle(int(side), -1)
+	case "right":
+		m.MovePaddle(int(side), 1)
+	default:
+		return m, nil, fmt.Errorf("invalid input %+v", action)
+	}
+
+	return m, nil, nil
+}
+
+// Player names the paddle of the player on side.
+func (m model) Player(side netplay.Side) string {
+	if side == netplay.Host {
+		return "the top paddle"
+	}
+
+	return "the bottom paddle"
+}
+
 func (m *model) MovePaddle(num, amount int) {
 	if num == 1 {
 		m.paddle1.y += amount
@@ -136,18 +235,3 @@ func (m *model) MovePaddle(num, amount int) {
 		}
 	}
 }
-
-func Run() {
-	p := tea.NewProgram(initialModel())
-
-	go func() {
-		for {
-			time.Sleep(300 * time.Millisecond)
-			p.Send(moveBallMsg{})
-		}
-	}()
-
-	if _, err := p.Run(); err != nil {
-		panic(err)
-	}
-}
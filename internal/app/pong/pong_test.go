@@ -0,0 +1,85 @@
+package pong
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Kaamkiya/gg/internal/gametest"
+	"github.com/Kaamkiya/gg/internal/netplay"
+	"github.com/Kaamkiya/gg/internal/registry"
+)
+
+func TestBallMovesOnEveryTick(t *testing.T) {
+	h := gametest.New(t, "pong", registry.Options{})
+	h.Golden("start")
+
+	h.Advance(300 * time.Millisecond)
+	if pos := h.Model().(model).ball.pos; pos != (vector{16, 9}) {
+		t.Fatalf("expected the ball to move diagonally, got %v", pos)
+	}
+
+	h.Advance(600 * time.Millisecond)
+	if pos := h.Model().(model).ball.pos; pos != (vector{18, 11}) {
+		t.Fatalf("expected the ball to keep moving, got %v", pos)
+	}
+}
+
+func TestPaddlesMove(t *testing.T) {
+	h := gametest.New(t, "pong", registry.Options{})
+
+	h.Press("a", "a", "right")
+	m := h.Model().(model)
+	if m.paddle1 != (vector{1, 6}) || m.paddle2 != (vector{29, 8}) {
+		t.Fatalf("unexpected paddles %v and %v", m.paddle1, m.paddle2)
+	}
+
+	h.Golden("paddles")
+}
+
+func TestMissingTheBallEndsTheGame(t *testing.T) {
+	h := gametest.New(t, "pong", registry.Options{})
+
+	h.Advance(time.Minute)
+	if !h.Quit() {
+		t.Fatal("expected the game to be over")
+	}
+
+	if err := h.Model().(model).err; err != nil {
+		t.Fatalf("expected the score to be saved, got %v", err)
+	}
+}
+
+func TestNetwork(t *testing.T) {
+	host, guest := gametest.Connect(t, "pong", registry.Options{})
+	same := func(when string) {
+		t.Helper()
+		h := host.Model().(*netplay.Session).Game().(model)
+		g := guest.Model().(*netplay.Session).Game().(model)
+		if h.ball != g.ball || h.paddle1 != g.paddle1 || h.paddle2 != g.paddle2 || h.hitCount != g.hitCount {
+			t.Fatalf("the games differ %s:\n%+v\n%+v", when, h, g)
+		}
+	}
+
+	// The ball only moves on the guest's side when the host's clock ticks.
+	host.Advance(300 * time.Millisecond)
+	guest.Receive()
+	same("after a tick")
+
+	// Each player moves their own paddle, with either pair of keys.
+	guest.Press("a")
+	host.Receive()
+	guest.Receive()
+	host.Press("right")
+	guest.Receive()
+	same("after the paddles moved")
+	if m := guest.Model().(*netplay.Session).Game().(model); m.paddle1 != (vector{1, 9}) || m.paddle2 != (vector{29, 6}) {
+		t.Fatalf("unexpected paddles %v and %v", m.paddle1, m.paddle2)
+	}
+
+	// The game ends on both sides when the ball is missed.
+	host.Advance(time.Minute)
+	for !guest.Quit() {
+		guest.Receive()
+	}
+	same("at the end")
+}
@@ -2,15 +2,41 @@ package snake
 
 import (
 	"fmt"
-	"math/rand/v2"
+	"strings"
 	"time"
 
+	"github.com/Kaamkiya/gg/internal/clock"
+	"github.com/Kaamkiya/gg/internal/keymap"
+	"github.com/Kaamkiya/gg/internal/registry"
+	"github.com/Kaamkiya/gg/internal/rng"
+	"github.com/Kaamkiya/gg/internal/scores"
+	"github.com/Kaamkiya/gg/internal/theme"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
+func init() {
+	registry.Register(registry.Game{
+		Name:        "snake",
+		Title:       "snake",
+		Description: "eat the food, grow longer and don't bite yourself",
+		Players:     1,
+		Settings: []registry.Setting{
+			{Name: "size", Usage: "size of the board as WIDTHxHEIGHT", Default: "20x20", Check: registry.CheckWith(parseSize)},
+		},
+		New: initialModel,
+	})
+}
+
 type moveMsg struct{}
 
+func move(c clock.Clock) tea.Cmd {
+	return c.Tick(200*time.Millisecond, func(time.Time) tea.Msg {
+		return moveMsg{}
+	})
+}
+
 type vector struct {
 	x int
 	y int
@@ -62,6 +88,13 @@ func (p player) headChar() rune {
 }
 
 type model struct {
+	size      vector
+	seed      uint64
+	rng       *rng.Rand
+	clock     clock.Clock
+	keys      *keymap.Map
+	started   time.Time
+	err       error
 	foodPos   vector
 	foodStyle lipgloss.Style
 	player    player
@@ -69,34 +102,34 @@ type model struct {
 
 func (m *model) setRandomFoodPos() {
 	m.foodPos = vector{
-		x: rand.IntN(20),
-		y: rand.IntN(20),
+		x: m.rng.IntN(m.size.x),
+		y: m.rng.IntN(m.size.y),
 	}
 }
 
 func (m model) Init() tea.Cmd {
-	return nil
+	return move(m.clock)
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
+		switch {
+		case m.keys.Matches(msg, "quit"):
 			return m, tea.Quit
-		case "k", "up":
+		case m.keys.Matches(msg, "up"):
 			if m.player.dir != dirDown {
 				m.player.dir = dirUp
 			}
-		case "j", "down":
+		case m.keys.Matches(msg, "down"):
 			if m.player.dir != dirUp {
 				m.player.dir = dirDown
 			}
-		case "h", "left":
+		case m.keys.Matches(msg, "left"):
 			if m.player.dir != dirRight {
 				m.player.dir = dirLeft
 			}
-		case "l", "right":
+		case m.keys.Matches(msg, "right"):
 			if m.player.dir != dirLeft {
 				m.player.dir = dirRight
 			}
@@ -106,8 +139,8 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		head := m.player.body[0]
 
-		if head.x >= 20 || head.x < 0 || head.y < 0 || head.y >= 20 {
-			return m, tea.Quit
+		if head.x >= m.size.x || head.x < 0 || head.y < 0 || head.y >= m.size.y {
+			return m.gameOver()
 		}
 
 		for i, b := range m.player.body {
@@ -115,24 +148,41 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				continue
 			}
 			if b.equals(head) {
-				return m, tea.Quit
+				return m.gameOver()
 			}
 		}
 
 		if head.x == m.foodPos.x && head.y == m.foodPos.y {
 			m.setRandomFoodPos()
 		}
+
+		return m, move(m.clock)
+
+	case scores.RecordedMsg:
+		m.err = msg.Err
+		return m, tea.Quit
 	}
 
 	return m, nil
 }
 
+// gameOver records the length of the snake as its score. The game quits
+// when the score is saved.
+func (m model) gameOver() (tea.Model, tea.Cmd) {
+	return m, scores.RecordCmd("snake", scores.Result{
+		Score:    len(m.player.body),
+		Duration: time.Since(m.started),
+		Seed:     m.seed,
+	})
+}
+
 func (m model) View() string {
-	s := "----------------------\n"
+	border := strings.Repeat("-", m.size.x+2) + "\n"
+	s := border
 
-	for y := 0; y < 20; y++ {
+	for y := 0; y < m.size.y; y++ {
 		s += "|"
-		for x := 0; x < 20; x++ {
+		for x := 0; x < m.size.x; x++ {
 			drew := false
 			for i, b := range m.player.body {
 				if b.x == x && b.y == y {
@@ -157,37 +207,50 @@ func (m model) View() string {
 		s += "|\n"
 	}
 
-	s += "----------------------\n"
+	s += border
 	s += fmt.Sprintf("Score: %d\n", len(m.player.body))
+	s += m.keys.Help() + "\n"
+	if m.err != nil {
+		s += fmt.Sprintf("Could not save the score: %v\n", m.err)
+	}
 	return s
 }
 
-func initialModel() tea.Model {
-	return model{
-		foodPos: vector{
-			x: rand.IntN(20),
-			y: rand.IntN(20),
-		},
-		foodStyle: lipgloss.NewStyle().Foreground(lipgloss.Color("#ff0000")),
+func initialModel(opts registry.Options) (tea.Model, error) {
+	size, err := parseSize(opts.Get("size"))
+	if err != nil {
+		return nil, err
+	}
+
+	m := model{
+		size:      size,
+		seed:      opts.Seed,
+		rng:       opts.Rand(),
+		clock:     opts.Ticker(),
+		keys:      keymap.New("snake", keymap.Up, keymap.Down, keymap.Left, keymap.Right, keymap.Quit),
+		started:   time.Now(),
+		foodStyle: lipgloss.NewStyle().Foreground(theme.Current().Bad),
 		player: player{
 			body:  []vector{{6, 6}},
 			dir:   dirRight,
-			style: lipgloss.NewStyle().Foreground(lipgloss.Color("32")),
+			style: lipgloss.NewStyle().Foreground(theme.Current().Player1),
 		},
 	}
-}
+	m.setRandomFoodPos()
 
-func Run() {
-	p := tea.NewProgram(initialModel())
+	return m, nil
+}
 
-	go func() {
-		for {
-			p.Send(moveMsg{})
-			time.Sleep(200 * time.Millisecond)
-		}
-	}()
+// parseSize parses the size of the board, at least 10x10.
+func parseSize(s string) (vector, error) {
+	width, height, err := registry.ParseSize(s)
+	if err != nil {
+		return vector{}, err
+	}
 
-	if _, err := p.Run(); err != nil {
-		panic(err)
+	if width < 10 || height < 10 {
+		return vector{}, fmt.Errorf("snake needs a board of at least 10x10, got %dx%d", width, height)
 	}
+
+	return vector{width, height}, nil
 }
@@ -0,0 +1,71 @@
+package snake
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Kaamkiya/gg/internal/gametest"
+	"github.com/Kaamkiya/gg/internal/registry"
+	"github.com/Kaamkiya/gg/internal/scores"
+)
+
+var opts = registry.Options{
+	Seed:     1,
+	Settings: map[string]string{"size": "12x12"},
+}
+
+func TestSnakeMovesOnEveryTick(t *testing.T) {
+	h := gametest.New(t, "snake", opts)
+	h.Golden("start")
+
+	h.Advance(199 * time.Millisecond)
+	if head := h.Model().(model).player.body[0]; head != (vector{6, 6}) {
+		t.Fatalf("expected the snake to wait for the first tick, got its head at %v", head)
+	}
+
+	h.Advance(time.Millisecond)
+	if head := h.Model().(model).player.body[0]; head != (vector{7, 6}) {
+		t.Fatalf("expected the snake to move right, got its head at %v", head)
+	}
+
+	h.Press("down")
+	h.Advance(400 * time.Millisecond)
+	if head := h.Model().(model).player.body[0]; head != (vector{7, 8}) {
+		t.Fatalf("expected the snake to move down, got its head at %v", head)
+	}
+
+	h.Golden("moved")
+}
+
+func TestSnakeCantTurnAround(t *testing.T) {
+	h := gametest.New(t, "snake", opts)
+
+	h.Press("left")
+	h.Advance(200 * time.Millisecond)
+	if head := h.Model().(model).player.body[0]; head != (vector{7, 6}) {
+		t.Fatalf("expected the snake to keep moving right, got its head at %v", head)
+	}
+}
+
+func TestHittingTheWallEndsTheGame(t *testing.T) {
+	h := gametest.New(t, "snake", opts)
+
+	h.Advance(10 * time.Second)
+	if !h.Quit() {
+		t.Fatal("expected the game to be over")
+	}
+
+	stats, err := scores.Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := stats.Stats("snake")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Played != 1 || s.Best[0].Seed != 1 {
+		t.Fatalf("expected the game to be recorded with its seed, got %+v", s)
+	}
+}
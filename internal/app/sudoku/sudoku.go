@@ -2,20 +2,82 @@ package sudoku
 
 import (
 	"fmt"
-	"strconv"
+	"math/rand/v2"
 
 	"github.com/Kaamkiya/gg/internal/app/sudoku/sudokugenerator"
+	"github.com/Kaamkiya/gg/internal/keymap"
+	"github.com/Kaamkiya/gg/internal/registry"
+	"github.com/Kaamkiya/gg/internal/savegame"
+	"github.com/Kaamkiya/gg/internal/theme"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
+func init() {
+	registry.Register(registry.Game{
+		Name:        "sudoku",
+		Title:       "sudoku",
+		Description: "fill the grid so every row, column and box holds 1 to 9",
+		Players:     1,
+		New:         newModel,
+		Resume:      resume,
+	})
+}
+
+// saveVersion is the version of saveState; a save of any other version is
+// refused rather than read into the wrong grids.
+const saveVersion = 1
+
+// saveState is what is kept of a suspended game.
+type saveState struct {
+	OrigGrid [][]int `json:"origGrid"`
+	Grid     [][]int `json:"grid"`
+	CursorX  int     `json:"cursorX"`
+	CursorY  int     `json:"cursorY"`
+}
+
+func resume(s savegame.Save) (tea.Model, error) {
+	var state saveState
+	if err := s.Decode(saveVersion, &state); err != nil {
+		return nil, err
+	}
+
+	return model{
+		origGrid: state.OrigGrid,
+		grid:     state.Grid,
+		cursorx:  state.CursorX,
+		cursory:  state.CursorY,
+		keys:     keymap.New("sudoku", actions...),
+	}, nil
+}
+
+func newModel(opts registry.Options) (tea.Model, error) {
+	return initialModel(opts.Rand().Rand), nil
+}
+
+// actions are the moves of the cursor and the numbers to fill in. The n-th
+// key of "number" fills in n.
+var actions = []keymap.Action{
+	keymap.Up,
+	keymap.Down,
+	keymap.Left,
+	keymap.Right,
+	{Name: "number", Keys: []string{"1", "2", "3", "4", "5", "6", "7", "8", "9"}, Help: "fill in"},
+	{Name: "clear", Keys: []string{"0", "backspace"}, Help: "clear"},
+	keymap.Save,
+	keymap.Quit,
+}
+
 type model struct {
 	origGrid [][]int
 	grid     [][]int
 
 	cursorx int
 	cursory int
+
+	keys *keymap.Map
+	err  error
 }
 
 func (m model) Init() tea.Cmd {
@@ -25,27 +87,40 @@ func (m model) Init() tea.Cmd {
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "q", "ctrl+c":
+		switch {
+		case m.keys.Matches(msg, "quit"):
+			return m, tea.Quit
+		case m.keys.Matches(msg, "save"):
+			m.err = savegame.Write("sudoku", saveVersion, saveState{
+				OrigGrid: m.origGrid,
+				Grid:     m.grid,
+				CursorX:  m.cursorx,
+				CursorY:  m.cursory,
+			})
+			if m.err != nil {
+				return m, nil
+			}
 			return m, tea.Quit
-		case "up", "k":
+		case m.keys.Matches(msg, "up"):
 			if m.cursory > 0 {
 				m.cursory--
 			}
-		case "down", "j":
+		case m.keys.Matches(msg, "down"):
 			if m.cursory < 8 {
 				m.cursory++
 			}
-		case "left", "h":
+		case m.keys.Matches(msg, "left"):
 			if m.cursorx > 0 {
 				m.cursorx--
 			}
-		case "right", "l":
+		case m.keys.Matches(msg, "right"):
 			if m.cursorx < 8 {
 				m.cursorx++
 			}
-		case "1", "2", "3", "4", "5", "6", "7", "8", "9", "0":
-			m.setSquare(msg.String())
+		case m.keys.Matches(msg, "number"):
+			m.setSquare(m.keys.Index(msg, "number") + 1)
+		case m.keys.Matches(msg, "clear"):
+			m.setSquare(0)
 		}
 	}
 
@@ -62,7 +137,7 @@ func (m model) View() string {
 			}
 
 			if j == m.cursorx && i == m.cursory {
-				col := lipgloss.NewStyle().Background(lipgloss.Color("#0000ff")).Render
+				col := lipgloss.NewStyle().Background(theme.Current().Cursor).Render
 				if c == 0 {
 					s += col(" . ")
 				} else {
@@ -85,19 +160,23 @@ func (m model) View() string {
 	}
 
 	s += fmt.Sprintf("\n\norig: %v\n\ncurr: %v", m.origGrid, m.grid)
+	s += "\n\n" + m.keys.Help()
+	if m.err != nil {
+		s += fmt.Sprintf("\nCould not save: %v", m.err)
+	}
 
 	return s
 }
 
-func (m *model) setSquare(button string) {
+func (m *model) setSquare(n int) {
 	if m.origGrid[m.cursory][m.cursorx] == 0 {
-		m.grid[m.cursory][m.cursorx], _ = strconv.Atoi(button)
+		m.grid[m.cursory][m.cursorx] = n
 	}
 }
 
-func initialModel() tea.Model {
+func initialModel(r *rand.Rand) tea.Model {
 	g := sudokugenerator.Model{}
-	g.Init()
+	g.Init(r)
 
 	grid := make([][]int, 9)
 	orig := make([][]int, 9)
@@ -115,13 +194,6 @@ func initialModel() tea.Model {
 	return model{
 		grid:     grid,
 		origGrid: orig,
-	}
-}
-
-func Run() {
-	p := tea.NewProgram(initialModel())
-
-	if _, err := p.Run(); err != nil {
-		panic(err)
+		keys:     keymap.New("sudoku", actions...),
 	}
 }
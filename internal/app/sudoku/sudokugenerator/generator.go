@@ -7,6 +7,8 @@ import (
 
 type Model struct {
 	Grid [][]int
+
+	rng *rand.Rand
 }
 
 func (m *Model) unusedInBox(row, col, n int) bool {
@@ -26,7 +28,7 @@ func (m *Model) fillBox(row, col int) {
 	for i := range 3 {
 		for j := range 3 {
 			for !m.unusedInBox(row, col, n) {
-				n = rand.IntN(9) + 1
+				n = m.rng.IntN(9) + 1
 			}
 			m.Grid[row+i][col+j] = n
 		}
@@ -79,7 +81,7 @@ func (m *Model) fillRemaining(row, col int) bool {
 
 func (m *Model) emptyCells(amount int) {
 	for amount > 0 {
-		id := rand.IntN(81)
+		id := m.rng.IntN(81)
 		i := id / 9
 		j := id % 9
 
@@ -98,7 +100,9 @@ func (m *Model) generate() {
 	m.fillRemaining(0, 0)
 }
 
-func (m *Model) Init() {
+// Init generates a new puzzle, drawing its random numbers from r.
+func (m *Model) Init(r *rand.Rand) {
+	m.rng = r
 	m.Grid = make([][]int, 9)
 	for i := range m.Grid {
 		m.Grid[i] = make([]int, 9)
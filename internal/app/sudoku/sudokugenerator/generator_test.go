@@ -1,28 +1,34 @@
 package sudokugenerator
 
-import "testing"
+import (
+	"math/rand/v2"
+	"testing"
+)
 
 func TestGen(t *testing.T) {
 	m := Model{}
-	m.Init()
+	m.Init(rand.New(rand.NewPCG(1, 1)))
 
-	m.grid = make([][]int, 9)
-	for i := range m.grid {
-		m.grid[i] = make([]int, 9)
+	m.Grid = make([][]int, 9)
+	for i := range m.Grid {
+		m.Grid[i] = make([]int, 9)
 	}
 	m.generate()
 
-	for r, row := range m.grid {
+	for r, row := range m.Grid {
 		for c, cell := range row {
-			if !m.unusedInBox(r-r%3, c-c%3, cell) || !m.unusedInCol(c, cell) || !m.unusedInRow(r, cell) {
+			// Clear the cell so it isn't counted as its own duplicate.
+			m.Grid[r][c] = 0
+			if !m.isSafe(r, c, cell) {
 				t.Fatalf("Invalid Sudoku generated: %d overlaps", cell)
 			}
+			m.Grid[r][c] = cell
 		}
 	}
 
 	m.emptyCells(20)
 	c := 0
-	for _, r := range m.grid {
+	for _, r := range m.Grid {
 		for _, n := range r {
 			if n == 0 {
 				c++
@@ -34,3 +40,17 @@ func TestGen(t *testing.T) {
 		t.Fatalf("Not enough empty cells: wanted=20 got=%d", c)
 	}
 }
+
+func TestSameSeedSamePuzzle(t *testing.T) {
+	a, b := Model{}, Model{}
+	a.Init(rand.New(rand.NewPCG(42, 42)))
+	b.Init(rand.New(rand.NewPCG(42, 42)))
+
+	for r := range a.Grid {
+		for c := range a.Grid[r] {
+			if a.Grid[r][c] != b.Grid[r][c] {
+				t.Fatalf("Same seed gave different puzzles at %d,%d", r, c)
+			}
+		}
+	}
+}
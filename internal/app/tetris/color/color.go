@@ -1,6 +1,10 @@
 package color
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"github.com/Kaamkiya/gg/internal/theme"
+
+	"github.com/charmbracelet/lipgloss"
+)
 
 type Color int
 
@@ -16,16 +20,17 @@ const (
 	Beige
 )
 
-var defaultStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#f9f6f2"))
+// Styles returns the style of every color in theme t. The colors are named
+// after the default theme; other themes put their own pieces in their place.
+func Styles(t theme.Theme) map[Color]lipgloss.Style {
+	defaultStyle := lipgloss.NewStyle().Foreground(t.Text)
+
+	styles := map[Color]lipgloss.Style{
+		None: defaultStyle.Background(lipgloss.NoColor{}),
+	}
+	for i, piece := range t.Pieces {
+		styles[Blue+Color(i)] = defaultStyle.Background(piece)
+	}
 
-var Colors = map[Color]lipgloss.Style{
-	None:    defaultStyle.Background(lipgloss.NoColor{}),
-	Blue:    defaultStyle.Background(lipgloss.Color("#063970")),
-	Green:   defaultStyle.Background(lipgloss.Color("#4CA74F")),
-	Orange:  defaultStyle.Background(lipgloss.Color("#CF6209")),
-	Pink:    defaultStyle.Background(lipgloss.Color("#D85B85")),
-	Teal:    defaultStyle.Background(lipgloss.Color("#2692E8")),
-	Purple:  defaultStyle.Background(lipgloss.Color("#9047A3")),
-	Magenta: defaultStyle.Background(lipgloss.Color("#CA1F7B")),
-	Beige:   defaultStyle.Background(lipgloss.Color("#FFFDD0")),
+	return styles
 }
@@ -1,24 +1,42 @@
 package tetris
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Kaamkiya/gg/internal/app/tetris/color"
 	"github.com/Kaamkiya/gg/internal/app/tetris/shape"
+	"github.com/Kaamkiya/gg/internal/clock"
+	"github.com/Kaamkiya/gg/internal/keymap"
+	"github.com/Kaamkiya/gg/internal/rng"
+	"github.com/Kaamkiya/gg/internal/scores"
+	"github.com/Kaamkiya/gg/internal/theme"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
 type gameProgressTick struct{}
 
-func initialModel() gameState {
+// actions are listed in the sidebar in this order.
+var actions = []keymap.Action{
+	{Name: "left", Keys: []string{"h", "H", "left"}, Help: "move left"},
+	{Name: "right", Keys: []string{"l", "L", "right"}, Help: "move right"},
+	{Name: "drop", Keys: []string{"j", "J", "down"}, Help: "drop"},
+	{Name: "rotate-left", Keys: []string{"z", "Z"}, Help: "rotate left"},
+	{Name: "rotate-right", Keys: []string{"x", "X"}, Help: "rotate right"},
+	{Name: "pause", Keys: []string{"p", "P"}, Help: "pause"},
+	{Name: "save", Keys: []string{"s", "S"}, Help: "save and quit"},
+	{Name: "quit", Keys: []string{"q", "Q", "ctrl+c"}, Help: "quit"},
+}
+
+func initialModel(r *rng.Rand) gameState {
 	return gameState{
 		nil,
 		nil,
-		newGameboard(color.Colors),
-		shape.NewRandomizer(),
+		newGameboard(color.Styles(theme.Current())),
+		shape.NewRandomizer(r),
 		0,
 		&difficulty{
 			initialDifficulyCountDown,
@@ -26,10 +44,16 @@ func initialModel() gameState {
 			initialGameProgressTickDelay,
 		},
 		false,
+		false,
 		pieceDrop{
 			dropFinished,
 			false,
 		},
+		0,
+		clock.Real,
+		keymap.New("tetris", actions...),
+		time.Now(),
+		nil,
 	}
 }
 
@@ -47,32 +71,40 @@ func (gs *gameState) Init() tea.Cmd {
 func (gs *gameState) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		if msg.String() == "ctrl+c" || msg.String() == "q" || msg.String() == "Q" {
+		if gs.keys.Matches(msg, "quit") {
+			return gs, tea.Quit
+		} else if gs.isOver {
+			return gs, nil
+		} else if gs.keys.Matches(msg, "save") {
+			gs.err = gs.save()
+			if gs.err != nil {
+				return gs, nil
+			}
 			return gs, tea.Quit
 		} else if !gs.isPaused {
-			switch msg.String() {
-			case "h", "H", "left":
+			switch {
+			case gs.keys.Matches(msg, "left"):
 				gs.handleLeft()
-			case "l", "L", "right":
+			case gs.keys.Matches(msg, "right"):
 				gs.handleRight()
-			case "j", "J", "down":
+			case gs.keys.Matches(msg, "drop"):
 				return gs, gs.handleDrop()
-			case "z", "Z":
+			case gs.keys.Matches(msg, "rotate-left"):
 				gs.handleLeftRotate()
-			case "x", "X":
+			case gs.keys.Matches(msg, "rotate-right"):
 				gs.handleRightRotate()
-			case "p", "P":
+			case gs.keys.Matches(msg, "pause"):
 				gs.isPaused = true
 				return gs, nil
 			}
 		} else {
-			if msg.String() == "p" || msg.String() == "P" {
+			if gs.keys.Matches(msg, "pause") {
 				gs.isPaused = false
-				return gs, tea.Tick(gs.currentDifficulty.gameProgressTickDelay, func(time.Time) tea.Msg { return gameProgressTick{} })
+				return gs, gs.clock.Tick(gs.currentDifficulty.gameProgressTickDelay, func(time.Time) tea.Msg { return gameProgressTick{} })
 			}
 		}
 	case gameProgressTick:
-		if gs.isPaused {
+		if gs.isPaused || gs.isOver {
 			return gs, nil
 		}
 
@@ -84,6 +116,9 @@ func (gs *gameState) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return gs, gs.handleGameProgressTick()
 	case lineAnimationTick:
 		return gs, gs.handleLineAnimationTick(msg)
+	case scores.RecordedMsg:
+		gs.err = msg.Err
+		return gs, tea.Quit
 	}
 
 	return gs, nil
@@ -99,7 +134,7 @@ func (gs *gameState) View() string {
 
 	borderStyle := lipgloss.NewStyle().
 		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.AdaptiveColor{Light: "#200C0C", Dark: "#BEC1C6"})
+		BorderForeground(theme.Current().Muted)
 
 	gameGridLines := buildGameGrid(gs)
 	sideBarLines := buildSidebar(gs)
@@ -155,8 +190,8 @@ func buildGameGrid(gs *gameState) [height * 2]string {
 	return gridLines
 }
 
-func buildSidebar(gs *gameState) [14]string {
-	sidebarLines := [14]string{}
+func buildSidebar(gs *gameState) []string {
+	sidebarLines := make([]string, 10)
 	sidebarLines[0] = "      Next Shape      "
 	sidebarLines[1] = "                      "
 
@@ -190,10 +225,17 @@ func buildSidebar(gs *gameState) [14]string {
 	sidebarLines[7] = "   Your score is      "
 	sidebarLines[8] = strings.Repeat(" ", 22-len(scoreStr)) + scoreStr
 	sidebarLines[9] = "                      "
-	sidebarLines[10] = "  hjl/←↓→ to move    "
-	sidebarLines[11] = "  z,x to rotate      "
-	sidebarLines[12] = "  q/ctl+c to quit    "
-	sidebarLines[13] = "  p to pause         "
+
+	for _, b := range gs.keys.ShortHelp() {
+		if b.Enabled() {
+			line := fmt.Sprintf("  %s %s", b.Help().Key, b.Help().Desc)
+			sidebarLines = append(sidebarLines, line+strings.Repeat(" ", max(0, 22-lipgloss.Width(line))))
+		}
+	}
+
+	if gs.err != nil {
+		sidebarLines = append(sidebarLines, "  could not save     ")
+	}
 
 	return sidebarLines
 }
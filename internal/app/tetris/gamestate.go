@@ -6,6 +6,9 @@ import (
 
 	"github.com/Kaamkiya/gg/internal/app/tetris/color"
 	"github.com/Kaamkiya/gg/internal/app/tetris/shape"
+	"github.com/Kaamkiya/gg/internal/clock"
+	"github.com/Kaamkiya/gg/internal/keymap"
+	"github.com/Kaamkiya/gg/internal/scores"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -35,6 +38,10 @@ type gameboard struct {
 //   - gameboard is the playing area
 //   - shapeRandomizer is used to find which shape is going to be dropped next.
 //   - isPaused is a flag which is true when the game is paused.
+//   - isOver is a flag which is true once the game is over, while its score is saved.
+//   - seed is the seed the game was started with, kept with its score.
+//   - clock schedules the ticks that drop the shapes and animate the lines.
+//   - started is when the game started and err is set when the score couldn't be saved.
 type gameState struct {
 	nextShape         *shape.Shape
 	currentShape      *shape.Shape
@@ -43,7 +50,13 @@ type gameState struct {
 	score             uint
 	currentDifficulty *difficulty
 	isPaused          bool
+	isOver            bool
 	pieceDrop         pieceDrop
+	seed              uint64
+	clock             clock.Clock
+	keys              *keymap.Map
+	started           time.Time
+	err               error
 }
 
 const (
@@ -79,7 +92,7 @@ func (gs *gameState) handleGameProgressTick() tea.Cmd {
 		gs.nextShape = &newShape
 	}
 
-	nextCmd := tea.Tick(gs.currentDifficulty.gameProgressTickDelay, func(time.Time) tea.Msg {
+	nextCmd := gs.clock.Tick(gs.currentDifficulty.gameProgressTickDelay, func(time.Time) tea.Msg {
 		return gameProgressTick{}
 	})
 
@@ -109,7 +122,14 @@ func (gs *gameState) handleGameProgressTick() tea.Cmd {
 			lineAnimationMsg := gs.constructLineAnimationMsg(completedLines)
 			return gs.handleLineAnimationTick(lineAnimationMsg)
 		} else if posY == 0 {
-			return tea.Quit
+			// The game ends once the score is saved, and nothing moves
+			// until then.
+			gs.isOver = true
+			return scores.RecordCmd("tetris", scores.Result{
+				Score:    int(gs.score),
+				Duration: time.Since(gs.started),
+				Seed:     gs.seed,
+			})
 		}
 	}
 
@@ -2,17 +2,21 @@ package tetris
 
 import (
 	"testing"
+	"time"
 
 	"github.com/Kaamkiya/gg/internal/app/tetris/color"
 	"github.com/Kaamkiya/gg/internal/app/tetris/shape"
+	"github.com/Kaamkiya/gg/internal/clock"
+	"github.com/Kaamkiya/gg/internal/rng"
+	"github.com/Kaamkiya/gg/internal/theme"
 )
 
 func TestASingleLineIsRemoved(t *testing.T) {
 	gamestate := gameState{
 		nil,
 		nil,
-		newGameboard(color.Colors),
-		shape.NewRandomizer(),
+		newGameboard(color.Styles(theme.Current())),
+		shape.NewRandomizer(rng.New(1)),
 		0,
 		&difficulty{
 			20,
@@ -20,10 +24,16 @@ func TestASingleLineIsRemoved(t *testing.T) {
 			300,
 		},
 		false,
+		false,
 		pieceDrop{
 			dropFinished,
 			false,
 		},
+		0,
+		clock.Real,
+		nil,
+		time.Time{},
+		nil,
 	}
 
 	for i := range width {
@@ -43,8 +53,8 @@ func TestMultipleLinesAreRemoved(t *testing.T) {
 	gamestate := gameState{
 		nil,
 		nil,
-		newGameboard(color.Colors),
-		shape.NewRandomizer(),
+		newGameboard(color.Styles(theme.Current())),
+		shape.NewRandomizer(rng.New(1)),
 		0,
 		&difficulty{
 			20,
@@ -52,10 +62,16 @@ func TestMultipleLinesAreRemoved(t *testing.T) {
 			300,
 		},
 		false,
+		false,
 		pieceDrop{
 			dropFinished,
 			false,
 		},
+		0,
+		clock.Real,
+		nil,
+		time.Time{},
+		nil,
 	}
 
 	for i := range width {
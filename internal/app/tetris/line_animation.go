@@ -67,7 +67,7 @@ func (gs *gameState) handleLineAnimationTick(animationTick lineAnimationTick) te
 		gs.gameBoard.Grid[k] = v
 	}
 
-	return tea.Tick(lineAnimationInterval, func(time.Time) tea.Msg {
+	return gs.clock.Tick(lineAnimationInterval, func(time.Time) tea.Msg {
 		return lineAnimationTick{
 			newLinesToUpdateMap,
 			animationTick.animationCountDown,
@@ -1,22 +1,25 @@
 package shape
 
 import (
-	"math/rand"
+	"encoding/json"
 	"slices"
+
+	"github.com/Kaamkiya/gg/internal/rng"
 )
 
 // Randomizer makes the randrom pick of shapes to fill less 'unfair'. Inspired by info found
 // here: https://tetris.fandom.com/wiki/TGM_randomizer
 type Randomizer struct {
 	lastValues []int
+	rng        *rng.Rand
 }
 
 func (r *Randomizer) nextInt(maxValue int) int {
-	nextShape := rand.Intn(maxValue)
+	nextShape := r.rng.IntN(maxValue)
 
 	retries := 0
 	for retries < 6 && slices.Contains(r.lastValues, nextShape) {
-		nextShape = rand.Intn(maxValue)
+		nextShape = r.rng.IntN(maxValue)
 		retries++
 	}
 
@@ -26,7 +29,7 @@ func (r *Randomizer) nextInt(maxValue int) int {
 	return nextShape
 }
 
-func NewRandomizer() *Randomizer {
+func NewRandomizer(r *rng.Rand) *Randomizer {
 	lastValues := make([]int, 4)
 
 	lastValues[0] = Z
@@ -36,5 +39,28 @@ func NewRandomizer() *Randomizer {
 
 	return &Randomizer{
 		lastValues,
+		r,
 	}
 }
+
+type randomizerJSON struct {
+	LastValues []int     `json:"lastValues"`
+	Rand       *rng.Rand `json:"rand"`
+}
+
+// MarshalJSON encodes the full state of the randomizer, so a restored
+// randomizer picks the same shapes the original would have.
+func (r *Randomizer) MarshalJSON() ([]byte, error) {
+	return json.Marshal(randomizerJSON{r.lastValues, r.rng})
+}
+
+func (r *Randomizer) UnmarshalJSON(data []byte) error {
+	var v randomizerJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	r.lastValues = v.LastValues
+	r.rng = v.Rand
+	return nil
+}
@@ -3,10 +3,12 @@ package shape
 import (
 	"strconv"
 	"testing"
+
+	"github.com/Kaamkiya/gg/internal/rng"
 )
 
 func TestNewRandomizerHasSZ(t *testing.T) {
-	randomizer := NewRandomizer()
+	randomizer := NewRandomizer(rng.New(1))
 
 	if randomizer.lastValues[0] != Z ||
 		randomizer.lastValues[1] != S ||
@@ -18,7 +20,7 @@ func TestNewRandomizerHasSZ(t *testing.T) {
 }
 
 func TestNewRandomizerUpdatesStateCorrectlyOnNewInt(t *testing.T) {
-	randomizer := NewRandomizer()
+	randomizer := NewRandomizer(rng.New(1))
 
 	firstShape := randomizer.nextInt(7)
 	secondShape := randomizer.nextInt(7)
@@ -4,6 +4,8 @@
 package shape
 
 import (
+	"encoding/json"
+
 	"github.com/Kaamkiya/gg/internal/app/tetris/color"
 )
 
@@ -216,6 +218,28 @@ func (s Shape) GetHeight() int {
 	return len(s.grid)
 }
 
+type shapeJSON struct {
+	PosX  int         `json:"posX"`
+	PosY  int         `json:"posY"`
+	Grid  [][]bool    `json:"grid"`
+	Color color.Color `json:"color"`
+}
+
+// MarshalJSON encodes the shape, so it can be kept in a saved game.
+func (s Shape) MarshalJSON() ([]byte, error) {
+	return json.Marshal(shapeJSON{s.posX, s.posY, s.grid, s.color})
+}
+
+func (s *Shape) UnmarshalJSON(data []byte) error {
+	var v shapeJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*s = Shape{v.PosX, v.PosY, v.Grid, v.Color}
+	return nil
+}
+
 func copyGrid(grid [][]bool) [][]bool {
 	duplicate := make([][]bool, len(grid))
 	for i := range grid {
@@ -5,10 +5,11 @@ import (
 	"testing"
 
 	"github.com/Kaamkiya/gg/internal/app/tetris/color"
+	"github.com/Kaamkiya/gg/internal/rng"
 )
 
 func TestShapeMoveDown(t *testing.T) {
-	shape := CreateNew(0, 0, NewRandomizer())
+	shape := CreateNew(0, 0, NewRandomizer(rng.New(1)))
 	movedDownShape := shape.MoveDown()
 
 	if shape.color != movedDownShape.color {
@@ -1,20 +1,100 @@
 package tetris
 
 import (
-	"fmt"
-	"os"
+	"time"
+
+	"github.com/Kaamkiya/gg/internal/app/tetris/color"
+	"github.com/Kaamkiya/gg/internal/app/tetris/shape"
+	"github.com/Kaamkiya/gg/internal/registry"
+	"github.com/Kaamkiya/gg/internal/savegame"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-func Run() {
-	initialModel := initialModel()
-	p := tea.NewProgram(&initialModel)
+func init() {
+	registry.Register(registry.Game{
+		Name:        "tetris",
+		Title:       "tetris",
+		Description: "rotate the falling shapes and clear as many lines as you can",
+		Players:     1,
+		New:         newModel,
+		Resume:      resume,
+	})
+}
+
+// saveVersion is the version of saveState. It changes with the fields, and
+// with how shapes and the randomizer encode themselves.
+const saveVersion = 1
+
+// saveState is what is kept of a suspended game. The randomizer is saved
+// with its random number generator, so the game continues with the same
+// shapes it would have had.
+type saveState struct {
+	Grid            [height][width]color.Color `json:"grid"`
+	NextShape       *shape.Shape               `json:"nextShape"`
+	CurrentShape    *shape.Shape               `json:"currentShape"`
+	ShapeRandomizer *shape.Randomizer          `json:"shapeRandomizer"`
+	Score           uint                       `json:"score"`
+	Countdown       int                        `json:"countdown"`
+	Level           float32                    `json:"level"`
+	TickDelay       time.Duration              `json:"tickDelay"`
+	DropStatus      dropStatus                 `json:"dropStatus"`
+	Seed            uint64                     `json:"seed"`
+	Played          time.Duration              `json:"played"`
+}
+
+func newModel(opts registry.Options) (tea.Model, error) {
+	gs := initialModel(opts.Rand())
+	gs.seed = opts.Seed
+	gs.clock = opts.Ticker()
+	return &gs, nil
+}
+
+func (gs *gameState) save() error {
+	return savegame.Write("tetris", saveVersion, saveState{
+		Grid:            gs.gameBoard.Grid,
+		NextShape:       gs.nextShape,
+		CurrentShape:    gs.currentShape,
+		ShapeRandomizer: gs.shapeRandomizer,
+		Score:           gs.score,
+		Countdown:       gs.currentDifficulty.countdown,
+		Level:           gs.currentDifficulty.level,
+		TickDelay:       gs.currentDifficulty.gameProgressTickDelay,
+		DropStatus:      gs.pieceDrop.dropStatus,
+		Seed:            gs.seed,
+		Played:          time.Since(gs.started),
+	})
+}
+
+// resume restores a saved game. It starts paused, so the player has time to
+// get ready.
+func resume(s savegame.Save) (tea.Model, error) {
+	var state saveState
+	if err := s.Decode(saveVersion, &state); err != nil {
+		return nil, err
+	}
+
+	gs := initialModel(nil)
+	gs.gameBoard.Grid = state.Grid
+	gs.nextShape = state.NextShape
+	gs.currentShape = state.CurrentShape
+	gs.shapeRandomizer = state.ShapeRandomizer
+	gs.score = state.Score
+	gs.currentDifficulty.countdown = state.Countdown
+	gs.currentDifficulty.level = state.Level
+	gs.currentDifficulty.gameProgressTickDelay = state.TickDelay
+	gs.pieceDrop.dropStatus = state.DropStatus
+	gs.seed = state.Seed
+	gs.started = time.Now().Add(-state.Played)
+	gs.isPaused = true
 
-	if _, err := p.Run(); err != nil {
-		fmt.Printf("An error: %v", err)
-		os.Exit(1)
+	// A game saved during the line clearing animation, when there is no
+	// current shape, still has the completed lines on the board.
+	if gs.currentShape == nil {
+		if lines := gs.checkForCompleteLines(0, height-1); len(lines) != 0 {
+			gs.removeCompletedLines(lines)
+		}
 	}
 
-	fmt.Println("")
+	return &gs, nil
 }
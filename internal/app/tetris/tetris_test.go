@@ -0,0 +1,118 @@
+package tetris
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Kaamkiya/gg/internal/app/tetris/color"
+	"github.com/Kaamkiya/gg/internal/app/tetris/shape"
+	"github.com/Kaamkiya/gg/internal/gametest"
+	"github.com/Kaamkiya/gg/internal/registry"
+	"github.com/Kaamkiya/gg/internal/rng"
+	"github.com/Kaamkiya/gg/internal/savegame"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSaveAndResume(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	gs := initialModel(rng.New(3))
+	gs.handleGameProgressTick()
+	gs.handleGameProgressTick()
+	gs.score = 120
+	gs.gameBoard.Grid[height-1][0] = color.Blue
+
+	if err := gs.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := savegame.Read("tetris")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := resume(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := m.(*gameState)
+
+	if restored.gameBoard.Grid != gs.gameBoard.Grid {
+		t.Fatal("the restored board differs from the saved one")
+	}
+
+	if restored.score != gs.score {
+		t.Fatalf("expected score %d, got %d", gs.score, restored.score)
+	}
+
+	if !restored.isPaused {
+		t.Fatal("expected a resumed game to start paused")
+	}
+
+	// Both randomizers must keep picking the same shapes.
+	for range 20 {
+		want := shape.CreateNew(0, 0, gs.shapeRandomizer)
+		got := shape.CreateNew(0, 0, restored.shapeRandomizer)
+		if want.GetColor() != got.GetColor() {
+			t.Fatal("the restored randomizer picks different shapes")
+		}
+	}
+}
+
+func TestShapesDropOnEveryTick(t *testing.T) {
+	h := gametest.New(t, "tetris", registry.Options{Seed: 3})
+	h.Golden("start")
+
+	position := func() (int, int) {
+		return h.Model().(*gameState).currentShape.GetPosition()
+	}
+
+	x, y := position()
+	h.Advance(900 * time.Millisecond)
+	if _, dropped := position(); dropped <= y {
+		t.Fatalf("expected the shape to drop below %d, got %d", y, dropped)
+	}
+
+	h.Press("h")
+	if moved, _ := position(); moved != x-1 {
+		t.Fatalf("expected the shape to move left to %d, got %d", x-1, moved)
+	}
+
+	h.Golden("dropped")
+
+	h.Press("p")
+	_, paused := position()
+	h.Advance(3 * time.Second)
+	if _, y := position(); y != paused {
+		t.Fatalf("expected the shape to stay put while paused, got it at %d instead of %d", y, paused)
+	}
+}
+
+func TestNothingMovesOnceOver(t *testing.T) {
+	gs := initialModel(rng.New(3))
+	for range 10000 {
+		if gs.isOver {
+			break
+		}
+		gs.Update(gameProgressTick{})
+	}
+	if !gs.isOver {
+		t.Fatal("expected the pieces to pile up to the top")
+	}
+	grid := gs.gameBoard.Grid
+
+	// Pausing twice used to schedule a tick that restarted the game.
+	pause := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")}
+	for range 2 {
+		if _, cmd := gs.Update(pause); cmd != nil {
+			t.Fatal("expected no command from a key once the game is over")
+		}
+	}
+	if _, cmd := gs.Update(gameProgressTick{}); cmd != nil {
+		t.Fatal("expected no command from a tick once the game is over")
+	}
+	if gs.gameBoard.Grid != grid || gs.currentShape != nil {
+		t.Fatal("expected the board to stay as it was once the game is over")
+	}
+}
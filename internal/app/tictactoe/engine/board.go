@@ -1,6 +1,10 @@
 package engine
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
 
 const (
 	P1    = 1
@@ -10,21 +14,53 @@ const (
 
 type Player = int
 
+// Board is a grid of cells, stored row by row from the top left.
 type Board struct {
-	Size  int
-	Cells []int
+	Width  int
+	Height int
+	Cells  []int
 }
 
+// NewBoard returns an empty size×size board.
 func NewBoard(size int) *Board {
-	cells := make([]int, size*size)
+	return NewGrid(size, size)
+}
+
+// NewGrid returns an empty board of width columns and height rows. A
+// negative size gives a board without cells.
+func NewGrid(width, height int) *Board {
+	width, height = max(width, 0), max(height, 0)
+	cells := make([]int, width*height)
 	for i := range cells {
 		cells[i] = EMPTY
 	}
 
 	return &Board{
-		Size:  size,
-		Cells: cells,
+		Width:  width,
+		Height: height,
+		Cells:  cells,
+	}
+}
+
+// ErrNoMoves is returned by an AI asked to move when no move is left.
+var ErrNoMoves = errors.New("no legal moves")
+
+// Validate returns an error if b isn't a board the engine can play on: a
+// grid of cells holding P1, P2 or EMPTY.
+func (b *Board) Validate() error {
+	if b.Width <= 0 || b.Height <= 0 {
+		return fmt.Errorf("invalid board size: %dx%d", b.Width, b.Height)
+	}
+	if len(b.Cells) != b.Width*b.Height {
+		return fmt.Errorf("invalid cells length: %d for a %dx%d board", len(b.Cells), b.Width, b.Height)
 	}
+	for i, cell := range b.Cells {
+		if cell != P1 && cell != P2 && cell != EMPTY {
+			return fmt.Errorf("invalid value %d in cell %d", cell, i)
+		}
+	}
+
+	return nil
 }
 
 func (b *Board) GetCell(index int) (int, error) {
@@ -54,11 +90,60 @@ func (b *Board) Load(cells []int) error {
 }
 
 func (b *Board) GetRowCol(index int) (int, int, error) {
-	if index < 0 || index >= len(b.Cells) {
+	if index < 0 || index >= len(b.Cells) || b.Width <= 0 {
 		return 0, 0, fmt.Errorf("invalid cell index: %d", index)
 	}
 
-	return index / b.Size, index % b.Size, nil
+	return index / b.Width, index % b.Width, nil
+}
+
+// InARow reports whether the mark on the cell at index is one of k or
+// more marks of its player in a row, in any direction through it. An empty
+// cell, or one off the board, never is.
+func (b *Board) InARow(index, k int) bool {
+	player, err := b.GetCell(index)
+	if err != nil || player == EMPTY {
+		return false
+	}
+
+	row, col, err := b.GetRowCol(index)
+	if err != nil {
+		return false
+	}
+
+	// Right, down, down-right (\) and down-left (/).
+	directions := [4][2]int{{0, 1}, {1, 0}, {1, 1}, {1, -1}}
+	for _, d := range directions {
+		count := 1 + b.countLine(row, col, d[0], d[1], player) +
+			b.countLine(row, col, -d[0], -d[1], player)
+
+		if count >= k {
+			return true
+		}
+	}
+
+	return false
+}
+
+// countLine counts the marks of player next to (row, col), going in the
+// direction (dr, dc) until another cell or the edge of the board.
+func (b *Board) countLine(row, col, dr, dc, player int) int {
+	count := 0
+
+	for {
+		row += dr
+		col += dc
+		if row < 0 || row >= b.Height || col < 0 || col >= b.Width {
+			return count
+		}
+
+		cell, err := b.GetCell(row*b.Width + col)
+		if err != nil || cell != player {
+			return count
+		}
+
+		count++
+	}
 }
 
 func (b *Board) ChangePerspective() {
@@ -67,16 +152,15 @@ func (b *Board) ChangePerspective() {
 	}
 }
 
+// Copy returns a copy of b, cell for cell, even if b isn't valid.
 func (b *Board) Copy() *Board {
-	newBoard := NewBoard(b.Size)
-	copy(newBoard.Cells, b.Cells)
-	return newBoard
+	return &Board{Width: b.Width, Height: b.Height, Cells: slices.Clone(b.Cells)}
 }
 
 func (b *Board) Print() {
-	for i := 0; i < b.Size; i++ {
-		for j := 0; j < b.Size; j++ {
-			cell, _ := b.GetCell(i*b.Size + j)
+	for i := 0; i < b.Height; i++ {
+		for j := 0; j < b.Width; j++ {
+			cell, _ := b.GetCell(i*b.Width + j)
 			if cell == P1 {
 				fmt.Print("O")
 			} else if cell == P2 {
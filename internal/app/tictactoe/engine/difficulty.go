@@ -0,0 +1,111 @@
+package engine
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+// Difficulty sets how well the AI plays.
+type Difficulty struct {
+	// Name identifies the level, e.g. "easy".
+	Name string
+	// Iterations is the number of MCTS iterations run per move.
+	Iterations int
+	// Think is the time the AI searches for per move instead, if set.
+	Think time.Duration
+	// Exploration is the exploration constant of the UCB formula. Higher
+	// values spread the search over more moves instead of the best ones.
+	Exploration float64
+	// Rollout picks the moves of the games played out by the search, random
+	// ones if it is nil.
+	Rollout RolloutPolicy
+	// Blunder is the chance of playing a random move instead of searching.
+	Blunder float64
+	// Exact makes the AI solve boards of up to maxExactCells cells with the
+	// Solver, and never lose on them. Larger boards are still searched.
+	Exact bool
+}
+
+// maxExactCells is the size of the largest board solved exactly; a 4×4
+// board takes the solver a fraction of a second, a 5×5 one far too long.
+const maxExactCells = 16
+
+// The difficulty levels, from the weakest to the strongest.
+var (
+	Easy    = Difficulty{Name: "easy", Iterations: 30, Exploration: 3, Blunder: 0.3}
+	Medium  = Difficulty{Name: "medium", Iterations: 100, Exploration: 1.41}
+	Hard    = Difficulty{Name: "hard", Iterations: 1000, Exploration: 1.41}
+	Perfect = Difficulty{Name: "perfect", Iterations: 10000, Exploration: 1, Exact: true}
+)
+
+// Difficulties are the levels to choose from.
+var Difficulties = []Difficulty{Easy, Medium, Hard, Perfect}
+
+// LookupDifficulty returns the level called name.
+func LookupDifficulty(name string) (Difficulty, error) {
+	names := make([]string, 0, len(Difficulties))
+	for _, d := range Difficulties {
+		if d.Name == name {
+			return d, nil
+		}
+		names = append(names, d.Name)
+	}
+
+	return Difficulty{}, fmt.Errorf("unknown difficulty %q, choose one of %s", name, strings.Join(names, ", "))
+}
+
+// ParseThink parses the time the AI thinks per move, written as a
+// duration such as 500ms; 0 keeps the difficulty's fixed search.
+func ParseThink(s string) (time.Duration, error) {
+	think, err := time.ParseDuration(s)
+	if err != nil || think < 0 {
+		return 0, fmt.Errorf("invalid think time %q: expected a duration such as 500ms", s)
+	}
+
+	return think, nil
+}
+
+// withBlunders makes ai blunder as often as d says.
+func withBlunders(ai AI, engine GameEngine, d Difficulty, r *rand.Rand) AI {
+	if d.Blunder > 0 {
+		return &blunderer{ai, engine, d.Blunder, r}
+	}
+
+	return ai
+}
+
+// blunderer is an AI that now and then plays a random move instead of the
+// move of the AI it wraps.
+type blunderer struct {
+	ai     AI
+	engine GameEngine
+	chance float64
+	rng    *rand.Rand
+}
+
+func (b *blunderer) Solve(board *Board) (int, error) {
+	if b.rng.Float64() < b.chance && board.Validate() == nil {
+		if moves := b.engine.GetLegalMoves(board); len(moves) > 0 {
+			return moves[b.rng.IntN(len(moves))], nil
+		}
+	}
+
+	return b.ai.Solve(board)
+}
+
+// exactAI solves small boards with the solver and leaves larger ones to
+// another AI.
+type exactAI struct {
+	solver *Solver
+	ai     AI
+}
+
+func (e *exactAI) Solve(board *Board) (int, error) {
+	if len(board.Cells) <= maxExactCells {
+		return e.solver.Solve(board)
+	}
+
+	return e.ai.Solve(board)
+}
@@ -1,17 +1,37 @@
 package engine
 
+import (
+	"fmt"
+	"math/rand/v2"
+)
+
 type Engine struct {
-	ai AI
+	ai        AI
+	winLength int
 }
 
-func NewEngine(depth int) *Engine {
-	engine := &Engine{}
-	mcts := NewMCTS(engine, depth)
-	engine.ai = mcts
+// NewEngine returns an engine for games won with winLength marks in a row,
+// whose AI plays at the given difficulty. A winLength of 0 means a whole row
+// of the board.
+func NewEngine(winLength int, d Difficulty, r *rand.Rand) *Engine {
+	engine := &Engine{winLength: winLength}
+	engine.ai = NewAI(engine, d, r)
 
 	return engine
 }
 
+// NewAI returns an AI for games played with game, searching with MCTS at
+// difficulty d. Exact play only applies to tictactoe, the one game the
+// solver knows.
+func NewAI(game GameEngine, d Difficulty, r *rand.Rand) AI {
+	ai := NewMCTS(game, d, r)
+	if e, ok := game.(*Engine); ok && d.Exact {
+		ai = &exactAI{NewSolver(e), ai}
+	}
+
+	return withBlunders(ai, game, d, r)
+}
+
 func (e *Engine) GetLegalMoves(board *Board) []int {
 	var moves []int
 	for i, cell := range board.Cells {
@@ -22,7 +42,20 @@ func (e *Engine) GetLegalMoves(board *Board) []int {
 	return moves
 }
 
+// PlayMove puts a mark of player on the free cell move.
 func (e *Engine) PlayMove(board *Board, player int, move int) error {
+	if player != P1 && player != P2 {
+		return fmt.Errorf("invalid player: %d", player)
+	}
+
+	cell, err := board.GetCell(move)
+	if err != nil {
+		return err
+	}
+	if cell != EMPTY {
+		return fmt.Errorf("cell %d is taken", move)
+	}
+
 	return board.SetCell(move, player)
 }
 
@@ -47,99 +80,18 @@ func (e *Engine) CheckGameOver(board *Board, lastMove int) (bool, int) {
 	return false, 0
 }
 
+// CheckWin reports whether lastMove completed a line of the engine's win
+// length, in any direction through it. A move off the board never does.
 func (e *Engine) CheckWin(board *Board, lastMove int) bool {
-	player, err := board.GetCell(lastMove)
-	if err != nil {
-		panic(err)
-	}
-	if player == EMPTY {
-		return false
-	}
-
-	row, col, err := board.GetRowCol(lastMove)
-	if err != nil {
-		panic(err)
-	}
-
-	if e.checkRow(board, row, player) {
-		return true
-	}
-
-	if e.checkCol(board, col, player) {
-		return true
-	}
-
-	if e.checkDiagonal(board, player) {
-		return true
-	}
-
-	return false
-}
-
-func (e *Engine) checkRow(board *Board, row, player int) bool {
-	for i := 0; i < board.Size; i++ {
-		cell, err := board.GetCell(row*board.Size + i)
-		if err != nil {
-			panic(err)
-		}
-
-		if cell != player {
-			return false
-		}
-	}
-
-	return true
-}
-
-func (e *Engine) checkCol(board *Board, col, player int) bool {
-	for i := 0; i < board.Size; i++ {
-		cell, err := board.GetCell(i*board.Size + col)
-		if err != nil {
-			panic(err)
-		}
-
-		if cell != player {
-			return false
-		}
-	}
-
-	return true
+	return board.InARow(lastMove, e.WinLength(board))
 }
 
-func (e *Engine) checkDiagonal(board *Board, player int) bool {
-	sum := 0
-	// Left to right
-	for i := 0; i < board.Size; i++ {
-		cell, err := board.GetCell(i*board.Size + i)
-		if err != nil {
-			panic(err)
-		}
-
-		if cell == player {
-			sum += player
-		}
-	}
-
-	if sum == board.Size*player {
-		return true
-	}
-
-	sum = 0
-	// Right to left
-	for i := 0; i < board.Size; i++ {
-		cell, err := board.GetCell(i*board.Size + board.Size - i - 1)
-		if err != nil {
-			panic(err)
-		}
-
-		if cell == player {
-			sum += player
-		}
-	}
-
-	if sum == board.Size*player {
-		return true
+// WinLength returns the number of marks in a row needed to win on board.
+func (e *Engine) WinLength(board *Board) int {
+	size := min(board.Width, board.Height)
+	if e.winLength <= 0 || e.winLength > size {
+		return size
 	}
 
-	return false
+	return e.winLength
 }
@@ -1,7 +1,13 @@
 package engine
 
 import (
+	"errors"
+	"math/rand/v2"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
 )
 
 var testCases = []struct {
@@ -55,19 +61,46 @@ var testCases = []struct {
 	},
 }
 
+// mustSolve returns the move of ai on board, and fails the test if it
+// can't move.
+func mustSolve(t *testing.T, ai AI, board *Board) int {
+	t.Helper()
+
+	move, err := ai.Solve(board)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return move
+}
+
+// mustOutcomes returns the outcomes of the moves on board.
+func mustOutcomes(t *testing.T, solver *Solver, board *Board) map[int]int {
+	t.Helper()
+
+	outcomes, err := solver.MoveOutcomes(board)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return outcomes
+}
+
 func TestEngine_Solve(t *testing.T) {
 	BOARD_SIZE := 3
-	engine := NewEngine(DEPTH)
+	engine := NewEngine(0, Medium, rand.New(rand.NewPCG(1, 1)))
 
 	for _, tc := range testCases {
 		t.Run("Testing solve", func(t *testing.T) {
 			board := NewBoard(BOARD_SIZE)
 			board.Load(tc.input)
 
-			move := engine.ai.Solve(board)
-
-			if move != tc.expected {
-				t.Errorf("expected move %d, got %d", tc.expected, move)
+			move, err := engine.ai.Solve(board)
+			if tc.expected < 0 && !errors.Is(err, ErrNoMoves) {
+				t.Errorf("expected no move to be left, got %d, %v", move, err)
+			}
+			if tc.expected >= 0 && (err != nil || move != tc.expected) {
+				t.Errorf("expected move %d, got %d, %v", tc.expected, move, err)
 			}
 		})
 	}
@@ -76,7 +109,7 @@ func TestEngine_Solve(t *testing.T) {
 func TestEngine_CheckWin(t *testing.T) {
 	BOARD_SIZE := 3
 	board := NewBoard(BOARD_SIZE)
-	engine := NewEngine(DEPTH)
+	engine := NewEngine(0, Medium, rand.New(rand.NewPCG(1, 1)))
 
 	t.Run("Empty board", func(t *testing.T) {
 		if engine.CheckWin(board, 0) {
@@ -127,7 +160,7 @@ func TestEngine_CheckWin(t *testing.T) {
 func TestEngine_GetLegalMoves(t *testing.T) {
 	BOARD_SIZE := 4
 	board := NewBoard(BOARD_SIZE)
-	engine := NewEngine(DEPTH)
+	engine := NewEngine(0, Medium, rand.New(rand.NewPCG(1, 1)))
 	moves := []int{}
 
 	t.Run("Empty board", func(t *testing.T) {
@@ -155,3 +188,448 @@ func TestEngine_GetLegalMoves(t *testing.T) {
 		}
 	})
 }
+
+func TestEngine_CheckWinLength(t *testing.T) {
+	tests := []struct {
+		name      string
+		size      int
+		winLength int
+		cells     []int
+		lastMove  int
+		want      bool
+	}{
+		{"4x4 row of 4", 4, 4, []int{4, 5, 6, 7}, 6, true},
+		{"4x4 row of 3", 4, 4, []int{4, 5, 6}, 6, false},
+		{"4x4 anti-diagonal", 4, 4, []int{3, 6, 9, 12}, 9, true},
+		{"5x5 three in a column", 5, 3, []int{7, 12, 17}, 7, true},
+		{"5x5 short diagonal", 5, 3, []int{2, 8, 14}, 8, true},
+		{"15x15 five off the main diagonal", 15, 5, []int{3*15 + 7, 4*15 + 8, 5*15 + 9, 6*15 + 10, 7*15 + 11}, 5*15 + 9, true},
+		{"15x15 five down-left", 15, 5, []int{0*15 + 14, 1*15 + 13, 2*15 + 12, 3*15 + 11, 4*15 + 10}, 0*15 + 14, true},
+		{"15x15 four", 15, 5, []int{10*15 + 0, 10*15 + 1, 10*15 + 2, 10*15 + 3}, 10*15 + 3, false},
+		{"15x15 broken five", 15, 5, []int{10*15 + 0, 10*15 + 1, 10*15 + 2, 10*15 + 4, 10*15 + 5}, 10*15 + 2, false},
+		{"15x15 does not wrap around", 15, 5, []int{0*15 + 12, 0*15 + 13, 0*15 + 14, 1*15 + 0, 1*15 + 1}, 0*15 + 14, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := NewEngine(tt.winLength, Medium, rand.New(rand.NewPCG(1, 1)))
+			board := NewBoard(tt.size)
+			for _, c := range tt.cells {
+				board.SetCell(c, P1)
+			}
+
+			if got := engine.CheckWin(board, tt.lastMove); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestBoard_InARow(t *testing.T) {
+	// A grid as wide as connect 4's, with three marks in the bottom row
+	// and a fourth of the other player.
+	board := NewGrid(7, 6)
+	for _, c := range []int{35, 36, 37} {
+		board.SetCell(c, P1)
+	}
+	board.SetCell(38, P2)
+
+	switch {
+	case !board.InARow(36, 3):
+		t.Error("expected three in a row")
+	case board.InARow(36, 4):
+		t.Error("expected the other player's mark to end the row")
+	case board.InARow(0, 1):
+		t.Error("expected an empty cell never to be in a row")
+	case board.InARow(42, 1):
+		t.Error("expected a cell off the board never to be in a row")
+	}
+}
+
+func TestEngine_SolveBlocksLongerLines(t *testing.T) {
+	// On a 4x4 board won with 4 in a row, the opponent (-1) has three in
+	// the second row, and the AI (1) has to block the last square.
+	board := NewBoard(4)
+	board.Load([]int{
+		1, 0, 0, 1,
+		-1, -1, -1, 0,
+		0, 0, 0, 0,
+		0, 0, 1, 0,
+	})
+
+	engine := NewEngine(4, Hard, rand.New(rand.NewPCG(1, 1)))
+	if move := mustSolve(t, engine.ai, board); move != 7 {
+		t.Errorf("expected the AI to block at 7, got %d", move)
+	}
+}
+
+func TestLookupDifficulty(t *testing.T) {
+	for _, d := range Difficulties {
+		got, err := LookupDifficulty(d.Name)
+		if err != nil || got != d {
+			t.Errorf("expected %v, got %v (%v)", d, got, err)
+		}
+	}
+
+	if _, err := LookupDifficulty("impossible"); err == nil {
+		t.Error("expected an error for an unknown difficulty")
+	}
+}
+
+func TestDifficulty_Blunder(t *testing.T) {
+	// An AI that always blunders misses the winning move at least once in
+	// a few games, one that never does finds it every time.
+	board := NewBoard(3)
+	board.Load(testCases[0].input)
+
+	careless := NewEngine(0, Difficulty{Name: "careless", Iterations: 100, Exploration: 1.41, Blunder: 1}, rand.New(rand.NewPCG(1, 1)))
+	missed := false
+	for range 10 {
+		if mustSolve(t, careless.ai, board.Copy()) != testCases[0].expected {
+			missed = true
+		}
+	}
+	if !missed {
+		t.Error("expected the blundering AI to miss the winning move")
+	}
+
+	for _, d := range []Difficulty{Medium, Hard, Perfect} {
+		engine := NewEngine(0, d, rand.New(rand.NewPCG(1, 1)))
+		if move := mustSolve(t, engine.ai, board.Copy()); move != testCases[0].expected {
+			t.Errorf("%s: expected move %d, got %d", d.Name, testCases[0].expected, move)
+		}
+	}
+}
+
+func TestSolver_Solve(t *testing.T) {
+	solver := NewSolver(NewEngine(0, Medium, rand.New(rand.NewPCG(1, 1))))
+
+	for _, tc := range testCases {
+		board := NewBoard(3)
+		board.Load(tc.input)
+
+		move, err := solver.Solve(board)
+		if tc.expected < 0 && !errors.Is(err, ErrNoMoves) || tc.expected >= 0 && err != nil {
+			t.Errorf("%v: %v", tc.input, err)
+		}
+		if move != tc.expected {
+			t.Errorf("%v: expected move %d, got %d", tc.input, tc.expected, move)
+		}
+	}
+}
+
+func TestSolver_Outcome(t *testing.T) {
+	tests := []struct {
+		name      string
+		size      int
+		winLength int
+		cells     []int
+		want      int
+	}{
+		{"empty 3x3", 3, 0, make([]int, 9), Draw},
+		{"empty 4x4, three in a row", 4, 3, make([]int, 16), Win},
+		{"fork", 3, 0, []int{1, 0, 0, 0, -1, 0, 0, 0, 1}, Win},
+		// P1 can only block one of the two threats of P2.
+		{"double threat", 3, 0, []int{-1, -1, 0, -1, 1, 0, 0, 0, 1}, Loss},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			solver := NewSolver(NewEngine(tt.winLength, Medium, rand.New(rand.NewPCG(1, 1))))
+			board := NewBoard(tt.size)
+			board.Load(tt.cells)
+
+			got, err := solver.Outcome(board)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSolver_Symmetry(t *testing.T) {
+	solver := NewSolver(NewEngine(0, Medium, rand.New(rand.NewPCG(1, 1))))
+
+	// A position and the same position turned a quarter to the right.
+	board := NewBoard(3)
+	board.Load([]int{1, 0, 0, 0, -1, 0, 0, 0, 0})
+	turned := NewBoard(3)
+	turned.Load([]int{0, 0, 1, 0, -1, 0, 0, 0, 0})
+
+	solver.prepare(3)
+	if solver.key(board) != solver.key(turned) {
+		t.Fatal("expected turned boards to share a key")
+	}
+
+	outcomes := mustOutcomes(t, solver, board)
+	for move, outcome := range mustOutcomes(t, solver, turned) {
+		// Turning back: the cell at (r, c) came from (2-c, r).
+		r, c := move/3, move%3
+		if from := (2-c)*3 + r; outcomes[from] != outcome {
+			t.Errorf("move %d: expected %d like move %d, got %d", move, outcomes[from], from, outcome)
+		}
+	}
+}
+
+// TestMCTS_NeverLoses checks the moves of MCTS, as searched at the perfect
+// level on boards too large to solve, against the solver in every position
+// of a 3×3 game: whenever a move that doesn't lose exists, MCTS has to find
+// one.
+func TestMCTS_NeverLoses(t *testing.T) {
+	engine := NewEngine(0, Medium, rand.New(rand.NewPCG(1, 1)))
+	mcts := NewMCTS(engine, Perfect, rand.New(rand.NewPCG(1, 1)))
+	solver := NewSolver(engine)
+	solver.prepare(3)
+
+	seen := map[uint64]bool{}
+	var visit func(board *Board)
+	visit = func(board *Board) {
+		key := solver.key(board)
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+
+		outcomes := mustOutcomes(t, solver, board)
+		best := Loss
+		for _, o := range outcomes {
+			best = max(best, o)
+		}
+
+		if len(outcomes) == 0 {
+			return
+		}
+
+		move := mustSolve(t, mcts, board.Copy())
+		if best > Loss && outcomes[move] == Loss {
+			t.Errorf("MCTS plays the losing move %d on %v", move, board.Cells)
+		}
+
+		for move := range outcomes {
+			next := board.Copy()
+			engine.PlayMove(next, P1, move)
+			if over, _ := engine.CheckGameOver(next, move); !over {
+				next.ChangePerspective()
+				visit(next)
+			}
+		}
+	}
+
+	visit(NewBoard(3))
+	if len(seen) < 500 {
+		t.Fatalf("expected to check every position, checked %d", len(seen))
+	}
+}
+
+func TestGame_AIPlaysItsOwnSide(t *testing.T) {
+	// The player (P1) threatens the top row, the AI (P2) can win in the
+	// middle one, and has to see the board from its own side to notice.
+	g := GetModel(1, 3, 0, Perfect).(Game)
+	g.board.Load([]int{
+		P1, P1, EMPTY,
+		P2, P2, EMPTY,
+		P1, EMPTY, EMPTY,
+	})
+	g.turn = P2
+
+	msg := g.aiMove()()
+	if cells := g.board.Cells; cells[5] != EMPTY {
+		t.Fatalf("expected the AI to leave the board to Update, got %v", cells)
+	}
+
+	_, cmd := g.Update(msg)
+	if over, ok := cmd().(gameOverMsg); !ok || over.winner != P2 {
+		t.Fatalf("expected the AI to win, got %#v on %v", msg, g.board.Cells)
+	}
+}
+
+func TestGame_NextOnlyOnceOver(t *testing.T) {
+	g := GetModel(1, 3, 0, Perfect).(Game)
+	next := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")}
+
+	m, _ := g.Update(next)
+	if m.(Game).round != g.round {
+		t.Fatal("expected the match being played to go on")
+	}
+
+	g.gameover = true
+	m, _ = g.Update(next)
+	if m.(Game).round != g.round+1 {
+		t.Fatal("expected the next match once this one is over")
+	}
+}
+
+func TestMCTS_Think(t *testing.T) {
+	engine := NewEngine(0, Medium, rand.New(rand.NewPCG(1, 1)))
+	ai := NewMCTS(engine, Difficulty{Name: "test", Think: 50 * time.Millisecond, Exploration: 1.41}, rand.New(rand.NewPCG(1, 1)))
+
+	board := NewBoard(3)
+	board.Load(testCases[0].input)
+
+	start := time.Now()
+	if move := mustSolve(t, ai, board); move != testCases[0].expected {
+		t.Errorf("expected move %d, got %d", testCases[0].expected, move)
+	}
+	if d := time.Since(start); d < 50*time.Millisecond {
+		t.Errorf("expected the AI to think for 50ms, it took %v", d)
+	}
+}
+
+func TestMCTS_ReusesTree(t *testing.T) {
+	engine := NewEngine(4, Medium, rand.New(rand.NewPCG(1, 1)))
+	m := NewMCTS(engine, Medium, rand.New(rand.NewPCG(1, 1))).(*mcts)
+
+	mustSolve(t, m, NewBoard(4))
+	kept := m.trees[0].root
+	if kept == nil || len(kept.children) == 0 {
+		t.Fatal("expected the subtree of the move played to be kept")
+	}
+
+	// The opponent answers with a move the search looked at.
+	reply := kept.children[0]
+	board := reply.board.Copy()
+	visits := reply.visitCount
+
+	mustSolve(t, m, board)
+	if reply.parent != nil {
+		t.Error("expected the reused node to become the root")
+	}
+	if reply.visitCount != visits+Medium.Iterations {
+		t.Errorf("expected %d visits on the reused root, got %d", visits+Medium.Iterations, reply.visitCount)
+	}
+
+	// A position the search never saw starts a new tree.
+	if root := m.reuse(m.trees[0], NewBoard(4)); root.visitCount != 0 {
+		t.Error("expected a new root for an unknown position")
+	}
+}
+
+func TestMCTS_Analyze(t *testing.T) {
+	engine := NewEngine(0, Medium, rand.New(rand.NewPCG(1, 1)))
+	a := NewAnalyzer(engine, Hard, rand.New(rand.NewPCG(1, 1)))
+
+	// P1 wins at once on square 2.
+	board := NewBoard(3)
+	board.Load([]int{
+		P1, P1, EMPTY,
+		P2, P2, EMPTY,
+		EMPTY, EMPTY, EMPTY,
+	})
+
+	stats, err := a.Analyze(board)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 5 {
+		t.Fatalf("expected every legal move, got %v", stats)
+	}
+	for i := 1; i < len(stats); i++ {
+		if stats[i-1].Move >= stats[i].Move {
+			t.Fatalf("expected the moves in order, got %v", stats)
+		}
+	}
+
+	if best := Best(stats); best != 2 {
+		t.Fatalf("expected the winning move 2 to be the best, got %d", best)
+	}
+	for _, s := range stats {
+		if s.Move == 2 && s.WinRate != 1 {
+			t.Errorf("expected the winning move to always win, got %.2f", s.WinRate)
+		}
+		// Moves that neither win nor block square 5 let P2 win.
+		if s.Move > 5 && s.WinRate >= 0.5 {
+			t.Errorf("expected move %d to let P2 win, got a win rate of %.2f", s.Move, s.WinRate)
+		}
+	}
+
+	// Analyzing doesn't play the move.
+	if mustSolve(t, a, board) != 2 {
+		t.Error("expected the analyzer to still play the winning move")
+	}
+}
+
+func TestRollout_TakeWinsAndBlocksLosses(t *testing.T) {
+	engine := NewEngine(0, Medium, rand.New(rand.NewPCG(1, 1)))
+	r := rand.New(rand.NewPCG(1, 1))
+
+	// P1 can win on 2, P2 threatens to win on 5.
+	board := NewBoard(3)
+	board.Load([]int{
+		P1, P1, EMPTY,
+		P2, P2, EMPTY,
+		EMPTY, EMPTY, EMPTY,
+	})
+	moves := engine.GetLegalMoves(board)
+
+	if move, ok := TakeWins.Move(engine, board, P1, moves, r); !ok || move != 2 {
+		t.Errorf("expected P1 to take the win on 2, got %d (%v)", move, ok)
+	}
+	if move, ok := BlockLosses.Move(engine, board, P1, moves, r); !ok || move != 5 {
+		t.Errorf("expected P1 to block on 5, got %d (%v)", move, ok)
+	}
+	if move, ok := HeuristicRollout.Move(engine, board, P1, moves, r); !ok || move != 2 {
+		t.Errorf("expected the heuristic policy to prefer winning to blocking, got %d", move)
+	}
+
+	// Without a win or a threat, they leave the choice to the next policy.
+	empty := NewBoard(3)
+	if _, ok := TakeWins.Move(engine, empty, P1, engine.GetLegalMoves(empty), r); ok {
+		t.Error("expected no winning move on an empty board")
+	}
+	if _, ok := BlockLosses.Move(engine, empty, P1, engine.GetLegalMoves(empty), r); ok {
+		t.Error("expected nothing to block on an empty board")
+	}
+}
+
+func TestRollout_PreferCenter(t *testing.T) {
+	engine := NewEngine(0, Medium, rand.New(rand.NewPCG(1, 1)))
+	r := rand.New(rand.NewPCG(1, 1))
+	board := NewBoard(7)
+	moves := engine.GetLegalMoves(board)
+
+	counts := map[int]int{}
+	for range 10000 {
+		move, _ := PreferCenter.Move(engine, board, P1, moves, r)
+		counts[move]++
+	}
+
+	// The center is 7 times as likely as a corner, 6 steps away.
+	if center, corner := counts[24], counts[0]; center < 4*corner {
+		t.Errorf("expected the center to be played far more often than a corner, got %d and %d", center, corner)
+	}
+}
+
+func TestLookupRollout(t *testing.T) {
+	for name := range Rollouts {
+		if _, err := LookupRollout(name); err != nil {
+			t.Error(err)
+		}
+	}
+	if _, err := LookupRollout("greedy"); err == nil {
+		t.Error("expected an error for an unknown policy")
+	}
+}
+
+// countingPolicy counts its moves and plays the first legal one.
+type countingPolicy struct{ moves *atomic.Int64 }
+
+func (p countingPolicy) Move(_ GameEngine, _ *Board, _ Player, moves []int, _ *rand.Rand) (int, bool) {
+	p.moves.Add(1)
+	return moves[0], true
+}
+
+func TestMCTS_Rollout(t *testing.T) {
+	engine := NewEngine(0, Medium, rand.New(rand.NewPCG(1, 1)))
+	policy := countingPolicy{&atomic.Int64{}}
+
+	d := Medium
+	d.Rollout = policy
+	mustSolve(t, NewMCTS(engine, d, rand.New(rand.NewPCG(1, 1))), NewBoard(3))
+
+	if policy.moves.Load() == 0 {
+		t.Fatal("expected the search to play its games out with the policy")
+	}
+}
@@ -0,0 +1,159 @@
+package engine
+
+import (
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fuzzLevel searches little, so that the fuzzer tries many boards.
+var fuzzLevel = Difficulty{Name: "fuzz", Iterations: 20, Exploration: 1.41, Rollout: HeuristicRollout, Blunder: 0.2}
+
+// fuzzBoard builds a board of any size and any cells, valid or not: each
+// byte of cells is EMPTY, P1, P2 or a value that is none of them.
+func fuzzBoard(width, height int8, cells []byte) *Board {
+	board := &Board{Width: int(width), Height: int(height)}
+	for _, c := range cells[:min(len(cells), 64)] {
+		board.Cells = append(board.Cells, [4]int{EMPTY, P1, P2, 2}[c%4])
+	}
+
+	return board
+}
+
+// FuzzBoard checks that no board, however malformed, panics the engine,
+// that the AIs refuse invalid boards, and that they only ever play legal
+// moves on valid ones.
+func FuzzBoard(f *testing.F) {
+	f.Add(int8(3), int8(3), []byte{0, 0, 0, 0, 0, 0, 0, 0, 0}, 4, 0)
+	f.Add(int8(3), int8(3), []byte{1, 2, 1, 2, 2, 1, 1, 1, 2}, 8, 3)
+	f.Add(int8(4), int8(2), []byte{1, 1, 1, 0, 2, 2, 0, 0}, 2, 4)
+	f.Add(int8(3), int8(3), []byte{1, 3, 0}, 1, 0)
+	f.Add(int8(-1), int8(5), []byte{}, -1, -2)
+	f.Add(int8(0), int8(0), []byte{0, 1}, 0, 1)
+
+	f.Fuzz(func(t *testing.T, width, height int8, cells []byte, move, winLength int) {
+		board := fuzzBoard(width, height, cells)
+		valid := board.Validate() == nil
+		e := NewEngine(winLength, fuzzLevel, rand.New(rand.NewPCG(1, 2)))
+
+		moves := e.GetLegalMoves(board)
+		for _, m := range moves {
+			if cell, err := board.GetCell(m); err != nil || cell != EMPTY {
+				t.Fatalf("legal move %d isn't a free cell of %v", m, board.Cells)
+			}
+		}
+
+		e.CheckGameOver(board, move)
+		e.WinLength(board)
+		board.GetRowCol(move)
+		if err := e.PlayMove(board.Copy(), P1, move); err == nil && !slices.Contains(moves, move) {
+			t.Fatalf("played %d, which isn't a legal move of %v", move, board.Cells)
+		}
+
+		ais := map[string]AI{
+			"ai":     e.ai,
+			"mcts":   NewMCTS(e, fuzzLevel, rand.New(rand.NewPCG(3, 4))),
+			"solver": NewSolver(e),
+		}
+		for name, ai := range ais {
+			// Solving a large board takes too long for a fuzzer.
+			if name == "solver" && valid && len(board.Cells) > 9 {
+				continue
+			}
+
+			got, err := ai.Solve(board.Copy())
+			switch {
+			case !valid || name == "solver" && board.Width != board.Height:
+				if err == nil {
+					t.Fatalf("%s: expected an error for the board %dx%d %v", name, board.Width, board.Height, board.Cells)
+				}
+			case len(moves) == 0:
+				if !errors.Is(err, ErrNoMoves) {
+					t.Fatalf("%s: expected ErrNoMoves for the full board %v, got %d, %v", name, board.Cells, got, err)
+				}
+			case err != nil:
+				t.Fatalf("%s: %v", name, err)
+			case !slices.Contains(moves, got):
+				t.Fatalf("%s: played %d, which isn't a legal move of %v", name, got, board.Cells)
+			}
+		}
+
+		stats, err := NewAnalyzer(e, fuzzLevel, rand.New(rand.NewPCG(5, 6))).Analyze(board.Copy())
+		if !valid && err == nil {
+			t.Fatalf("analyze: expected an error for the invalid board %v", board.Cells)
+		}
+		for _, s := range stats {
+			if !slices.Contains(moves, s.Move) {
+				t.Fatalf("analyze: rated %d, which isn't a legal move of %v", s.Move, board.Cells)
+			}
+		}
+	})
+}
+
+// FuzzMoves plays games from any sequence of moves, legal or not, against
+// the AI, and checks that illegal moves leave the board as it was, that
+// the AI always answers with a legal move, and that the board stays valid.
+func FuzzMoves(f *testing.F) {
+	f.Add(uint8(0), 0, []byte{4, 0, 8})
+	f.Add(uint8(1), 3, []byte{0, 0, 255, 16, 5, 6, 7})
+	f.Add(uint8(2), 4, []byte{12, 12, 12, 100, 24, 0})
+
+	f.Fuzz(func(t *testing.T, size uint8, winLength int, moves []byte) {
+		board := NewBoard(3 + int(size%3))
+		e := NewEngine(winLength, fuzzLevel, rand.New(rand.NewPCG(uint64(size), 7)))
+
+		for _, m := range moves {
+			move := int(int8(m))
+			before := slices.Clone(board.Cells)
+			if err := e.PlayMove(board, P1, move); err != nil {
+				if !slices.Equal(before, board.Cells) {
+					t.Fatalf("the failed move %d changed the board", move)
+				}
+				continue
+			}
+			if over, _ := e.CheckGameOver(board, move); over {
+				break
+			}
+
+			view := board.Copy()
+			view.ChangePerspective()
+			reply, err := e.ai.Solve(view)
+			if err != nil {
+				t.Fatalf("the AI failed on %v: %v", board.Cells, err)
+			}
+			if err := e.PlayMove(board, P2, reply); err != nil {
+				t.Fatalf("the AI played an illegal move %d on %v: %v", reply, board.Cells, err)
+			}
+			if over, _ := e.CheckGameOver(board, reply); over {
+				break
+			}
+		}
+
+		if err := board.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// failingAI never finds a move.
+type failingAI struct{}
+
+func (failingAI) Solve(*Board) (int, error) {
+	return -1, errors.New("out of ideas")
+}
+
+func TestGame_AIFailure(t *testing.T) {
+	g := GetModel(1, 3, 3, Easy).(Game)
+	g.engine.ai = failingAI{}
+
+	model, cmd := g.play(4)
+	for cmd != nil {
+		model, cmd = model.Update(cmd())
+	}
+
+	if view := model.View(); !strings.Contains(view, "Error: the AI failed: out of ideas") {
+		t.Fatalf("expected the failure to be shown, got:\n%s", view)
+	}
+}
@@ -1,19 +1,19 @@
 package engine
 
 import (
-	"fmt"
+	"maps"
 	"math"
 	"math/rand/v2"
-)
-
-const (
-	C_VALUE = 1.41
-	DEPTH   = 100
+	"runtime"
+	"slices"
+	"sync"
+	"time"
 )
 
 type AI interface {
-	// Returns the best move for the current player
-	Solve(board *Board) int
+	// Solve returns the best move for P1, the player to move. It fails on
+	// a board that isn't valid, and with ErrNoMoves if no move is left.
+	Solve(board *Board) (int, error)
 }
 
 type GameEngine interface {
@@ -28,96 +28,252 @@ type GameEngine interface {
 }
 
 type mcts struct {
-	engine GameEngine
-	depth  int
+	engine      GameEngine
+	iterations  int
+	think       time.Duration
+	exploration float64
+	rollout     RolloutPolicy
+	rng         *rand.Rand
+	// trees are searched side by side, each on its own goroutine. They
+	// keep the subtree of the move played, to reuse it on the next turn.
+	trees []tree
 }
 
-func NewMCTS(engine GameEngine, depth int) AI {
-	return &mcts{engine, depth}
+type tree struct {
+	root *node
+	rng  *rand.Rand
 }
 
-func (m *mcts) Solve(board *Board) int {
-	root := newNode(m.engine, board, -1, nil)
+// NewMCTS returns a Monte Carlo tree search playing at difficulty d. It
+// runs d.Iterations iterations per move or, if d.Think is set, thinks for
+// that long with a tree per CPU. The exploration constant of the UCB
+// formula is d.Exploration, the games are played out with d.Rollout, and
+// their random moves are drawn from r.
+func NewMCTS(engine GameEngine, d Difficulty, r *rand.Rand) AI {
+	rollout := d.Rollout
+	if rollout == nil {
+		rollout = RandomRollout
+	}
 
-	for i := 0; i < m.depth; i++ {
-		node := root
-		for node.isExpanded() {
-			child, err := node.selectChild()
-			if err != nil {
-				panic(err)
-			}
-			node = child
+	return &mcts{
+		engine:      engine,
+		iterations:  d.Iterations,
+		think:       d.Think,
+		exploration: d.Exploration,
+		rollout:     rollout,
+		rng:         r,
+	}
+}
+
+// MoveStat is what a search found out about a move.
+type MoveStat struct {
+	Move int
+	// Visits is the number of times the search tried the move; the most
+	// tried move is the one played.
+	Visits int
+	// WinRate is the share of the games played out after the move that the
+	// player to move won, a draw counting as half a win.
+	WinRate float64
+}
+
+// Analyzer is an AI that can tell what it thinks of every move.
+type Analyzer interface {
+	AI
+	// Analyze searches board like Solve, and returns the moves it tried in
+	// order, without playing any of them.
+	Analyze(board *Board) ([]MoveStat, error)
+}
+
+// NewAnalyzer returns a Monte Carlo tree search for analyzing positions,
+// configured like NewMCTS.
+func NewAnalyzer(engine GameEngine, d Difficulty, r *rand.Rand) Analyzer {
+	return NewMCTS(engine, d, r).(*mcts)
+}
+
+// Best returns the most tried move of stats, or -1 if there is none.
+func Best(stats []MoveStat) int {
+	best, bestVisits := -1, 0
+	for _, s := range stats {
+		if s.Visits > bestVisits {
+			best, bestVisits = s.Move, s.Visits
 		}
+	}
 
-		isOver, value := m.engine.CheckGameOver(node.board, node.move)
-		value = m.engine.GetOpponent(value)
+	return best
+}
 
-		if !isOver {
-			child, err := node.expand()
-			if err != nil {
-			} else {
-				value = child.simulate()
-				node = child
-			}
+func (m *mcts) Solve(board *Board) (int, error) {
+	trees, err := m.search(board)
+	if err != nil {
+		return -1, err
+	}
+	bestMove := Best(collect(trees))
+
+	// Keep what was found about the move played.
+	for i := range trees {
+		trees[i].root = trees[i].root.child(bestMove)
+	}
+
+	return bestMove, nil
+}
+
+func (m *mcts) Analyze(board *Board) ([]MoveStat, error) {
+	trees, err := m.search(board)
+	if err != nil {
+		return nil, err
+	}
+
+	return collect(trees), nil
+}
+
+// search searches board and returns the trees it grew.
+func (m *mcts) search(board *Board) ([]tree, error) {
+	if err := board.Validate(); err != nil {
+		return nil, err
+	}
+	if len(m.engine.GetLegalMoves(board)) == 0 {
+		return nil, ErrNoMoves
+	}
+
+	// Counting iterations only gives the same moves for the same seed on a
+	// single goroutine; a time limit never does, so it may as well use
+	// every CPU.
+	workers := 1
+	if m.think > 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	for len(m.trees) < workers {
+		r := m.rng
+		if len(m.trees) > 0 {
+			r = rand.New(rand.NewPCG(m.rng.Uint64(), m.rng.Uint64()))
 		}
+		m.trees = append(m.trees, tree{rng: r})
+	}
+	trees := m.trees[:workers]
 
-		node.backpropagate(value)
+	for i := range trees {
+		trees[i].root = m.reuse(trees[i], board)
 	}
 
-	visits := make([]float64, board.Size*board.Size)
-	dist := make([]float64, board.Size*board.Size)
-	sum := 0.0
+	deadline := time.Now().Add(m.think)
+	more := func(i int) bool {
+		if m.think > 0 {
+			return i == 0 || time.Now().Before(deadline)
+		}
+		return i < m.iterations
+	}
 
-	for _, child := range root.children {
-		visits[child.move] = float64(child.visitCount)
-		sum += visits[child.move]
+	var wg sync.WaitGroup
+	for _, t := range trees {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; more(i); i++ {
+				m.iterate(t.root)
+			}
+		}()
 	}
+	wg.Wait()
 
-	for i, visit := range visits {
-		dist[i] = visit / sum
+	return trees, nil
+}
+
+// collect adds up what trees found about the moves of their roots.
+func collect(trees []tree) []MoveStat {
+	visits := map[int]int{}
+	values := map[int]int{}
+	for _, t := range trees {
+		for _, child := range t.root.children {
+			visits[child.move] += child.visitCount
+			values[child.move] += child.valueSum
+		}
 	}
 
-	bestMove := -1
-	bestValue := 0.0
+	stats := make([]MoveStat, 0, len(visits))
+	for _, move := range slices.Sorted(maps.Keys(visits)) {
+		s := MoveStat{Move: move, Visits: visits[move]}
+		if s.Visits > 0 {
+			// Children count their results for the opponent, from -1 to 1.
+			s.WinRate = (1 - float64(values[move])/float64(s.Visits)) / 2
+		}
+		stats = append(stats, s)
+	}
 
-	for i, value := range dist {
-		if value > bestValue {
-			bestMove = i
-			bestValue = value
+	return stats
+}
+
+// reuse returns the node of t for board, which the opponent reached by
+// answering the move kept in t, or a new root if the search never got
+// there.
+func (m *mcts) reuse(t tree, board *Board) *node {
+	if t.root != nil {
+		for _, child := range t.root.children {
+			if slices.Equal(child.board.Cells, board.Cells) {
+				child.parent = nil
+				return child
+			}
 		}
 	}
 
-	return bestMove
+	return newNode(m.engine, m.exploration, m.rollout, t.rng, board, -1, nil)
+}
+
+// iterate runs one iteration of the search: it selects a node, expands it,
+// plays a random game from there and counts its result on the way back.
+func (m *mcts) iterate(root *node) {
+	node := root
+	for node.isExpanded() {
+		node = node.selectChild()
+	}
+
+	isOver, value := m.engine.CheckGameOver(node.board, node.move)
+	value = m.engine.GetOpponent(value)
+
+	// A position without moves that the game doesn't call over can't be
+	// expanded, and counts as a draw.
+	if !isOver && len(node.legalMoves) > 0 {
+		node = node.expand()
+		value = node.simulate()
+	}
+
+	node.backpropagate(value)
 }
 
 type node struct {
-	engine     GameEngine
-	board      *Board
-	move       int
-	parent     *node
-	children   []*node
-	legalMoves []int
-	valueSum   int
-	visitCount int
+	engine      GameEngine
+	exploration float64
+	rollout     RolloutPolicy
+	rng         *rand.Rand
+	board       *Board
+	move        int
+	parent      *node
+	children    []*node
+	legalMoves  []int
+	valueSum    int
+	visitCount  int
 }
 
-func newNode(engine GameEngine, board *Board, move int, parent *node) *node {
+func newNode(engine GameEngine, exploration float64, rollout RolloutPolicy, r *rand.Rand, board *Board, move int, parent *node) *node {
 	legalMoves := engine.GetLegalMoves(board)
 
 	return &node{
-		engine:     engine,
-		board:      board,
-		move:       move,
-		parent:     parent,
-		children:   []*node{},
-		legalMoves: legalMoves,
-		valueSum:   0,
-		visitCount: 0,
+		engine:      engine,
+		exploration: exploration,
+		rollout:     rollout,
+		rng:         r,
+		board:       board,
+		move:        move,
+		parent:      parent,
+		children:    []*node{},
+		legalMoves:  legalMoves,
+		valueSum:    0,
+		visitCount:  0,
 	}
 }
 
-// Simulate all moves until game is over;
-// Returns winner
+// simulate plays the moves of the rollout policy until the game is over,
+// and returns its result for P1, the player to move: 1 for a win, -1 for a
+// loss and 0 for a draw.
 func (n *node) simulate() int {
 	isOver, winner := n.engine.CheckGameOver(n.board, n.move)
 	if isOver {
@@ -129,15 +285,15 @@ func (n *node) simulate() int {
 	result := 0
 
 	for {
-		move, _, err := popRandomMove(n.engine.GetLegalMoves(board))
-		if err != nil {
+		move, ok := n.rollout.Move(n.engine, board, player, n.engine.GetLegalMoves(board), n.rng)
+		if !ok || n.engine.PlayMove(board, player, move) != nil {
 			break
 		}
 
-		n.engine.PlayMove(board, player, move)
 		isOver, winner = n.engine.CheckGameOver(board, move)
 		if isOver {
-			result = winner
+			// A win is always the win of the player who just moved.
+			result = winner * player
 			break
 		}
 
@@ -147,23 +303,22 @@ func (n *node) simulate() int {
 	return result
 }
 
-func (n *node) expand() (*node, error) {
-	move, rest, err := popRandomMove(n.legalMoves)
-	if err != nil {
-		return nil, err
-	}
-
-	n.legalMoves = rest
+// expand adds the child of a random move that wasn't tried yet, of which
+// there must be one.
+func (n *node) expand() *node {
+	index := n.rng.IntN(len(n.legalMoves))
+	move := n.legalMoves[index]
+	n.legalMoves = slices.Delete(n.legalMoves, index, index+1)
 
 	board := n.board.Copy()
 	n.engine.PlayMove(board, P1, move)
 
 	// Every node considers itself as p1
 	board.ChangePerspective()
-	child := newNode(n.engine, board, move, n)
+	child := newNode(n.engine, n.exploration, n.rollout, n.rng, board, move, n)
 	n.children = append(n.children, child)
 
-	return child, nil
+	return child
 }
 
 func (n *node) backpropagate(value int) {
@@ -175,12 +330,9 @@ func (n *node) backpropagate(value int) {
 	}
 }
 
-// Get next child with highest UCB
-func (n *node) selectChild() (*node, error) {
-	if len(n.children) == 0 {
-		return nil, fmt.Errorf("No child nodes")
-	}
-
+// selectChild returns the child with the highest UCB. The node must have
+// children.
+func (n *node) selectChild() *node {
 	var selected *node
 	var bestValue float64 = math.Inf(-1)
 
@@ -192,19 +344,18 @@ func (n *node) selectChild() (*node, error) {
 		}
 	}
 
-	return selected, nil
+	return selected
 }
 
-func popRandomMove(legalMoves []int) (int, []int, error) {
-	if len(legalMoves) == 0 {
-		return -1, legalMoves, fmt.Errorf("No legal moves")
+// child returns the child reached by move, or nil.
+func (n *node) child(move int) *node {
+	for _, c := range n.children {
+		if c.move == move {
+			return c
+		}
 	}
 
-	index := rand.IntN(len(legalMoves))
-	move := legalMoves[index]
-	legalMoves = append(legalMoves[:index], legalMoves[index+1:]...)
-
-	return move, legalMoves, nil
+	return nil
 }
 
 func (n *node) isExpanded() bool {
@@ -213,5 +364,5 @@ func (n *node) isExpanded() bool {
 
 func (n *node) getUCB(child *node) float64 {
 	q := 1 - ((float64(child.valueSum)/float64(child.visitCount))+1)/2
-	return q + C_VALUE*math.Sqrt(math.Log(float64(n.visitCount))/float64(child.visitCount))
+	return q + n.exploration*math.Sqrt(math.Log(float64(n.visitCount))/float64(child.visitCount))
 }
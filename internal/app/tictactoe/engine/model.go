@@ -2,64 +2,107 @@ package engine
 
 import (
 	"fmt"
-	"log"
 	"math/rand/v2"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/Kaamkiya/gg/internal/keymap"
+	"github.com/Kaamkiya/gg/internal/rng"
+	"github.com/Kaamkiya/gg/internal/scores"
+	"github.com/Kaamkiya/gg/internal/theme"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
 type Game struct {
-	board    *Board
-	engine   *Engine
-	turn     Player
-	winner   Player
-	gameover bool
-	round    int
-	scoreP1  int
-	scoreP2  int
-	colors   map[string]lipgloss.Style
+	board      *Board
+	engine     *Engine
+	size       int
+	winLength  int
+	difficulty Difficulty
+	cursor     int
+	turn       Player
+	winner     Player
+	gameover   bool
+	round      int
+	scoreP1    int
+	scoreP2    int
+	colors     map[string]lipgloss.Style
+	keys       *keymap.Map
+	seed       uint64
+	rng        *rng.Rand
+	started    time.Time
+	// hints are what a search found about the player's moves, shown on
+	// the board until a move is played.
+	hints []MoveStat
+	// quitting is set once the player quit, while the score is saved.
+	quitting bool
+	err      error
 }
 
-const (
-	size   = 3
-	yellow = "#FF9E3B"
-	dark   = "#3C3A32"
-	gray   = "#717C7C"
-	light  = "#DCD7BA"
-	red    = "#E63D3D"
-	green  = "#98BB6C"
-	blue   = "#7E9CD8"
-)
+// place puts a mark on a square of a 3×3 board, the n-th key on the n-th
+// square. Larger boards have too many squares for it, and are played by
+// moving the cursor.
+var place = keymap.Action{Name: "place", Keys: []string{"1", "2", "3", "4", "5", "6", "7", "8", "9"}, Help: "place a mark"}
+
+var actions = []keymap.Action{
+	keymap.Up,
+	keymap.Down,
+	keymap.Left,
+	keymap.Right,
+	{Name: "mark", Keys: []string{"enter", " "}, Help: "place a mark"},
+	{Name: "next", Keys: []string{"n", "N"}, Help: "next match"},
+	{Name: "hint", Keys: []string{"?"}, Help: "hint"},
+	keymap.Quit,
+}
 
-func GetModel() tea.Model {
+// GetModel returns a game against the AI on a size×size board, won with
+// winLength marks in a row. The AI plays at difficulty d, and the same seed
+// always makes it play the same way.
+func GetModel(seed uint64, size, winLength int, d Difficulty) tea.Model {
+	r := rng.New(seed)
 	board := NewBoard(size)
-	engine := NewEngine(100)
+	engine := NewEngine(winLength, d, r.Rand)
 
-	defaultStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#f9f6f2"))
-	c := func(s string) lipgloss.Color {
-		return lipgloss.Color(s)
+	bindings := actions
+	if size == 3 {
+		bindings = append([]keymap.Action{place}, actions...)
 	}
 
+	t := theme.Current()
+	defaultStyle := lipgloss.NewStyle().Foreground(t.Text)
+
 	return Game{
-		board:    board,
-		engine:   engine,
-		turn:     P1,
-		winner:   0,
-		round:    1,
-		scoreP1:  0,
-		scoreP2:  0,
-		gameover: false,
+		board:      board,
+		engine:     engine,
+		size:       size,
+		winLength:  winLength,
+		difficulty: d,
+		cursor:     size * size / 2,
+		turn:       P1,
+		winner:     0,
+		round:      1,
+		scoreP1:    0,
+		scoreP2:    0,
+		gameover:   false,
+		keys:       keymap.New("tictactoe-ai", bindings...),
+		seed:       seed,
+		rng:        r,
+		started:    time.Now(),
 		colors: map[string]lipgloss.Style{
-			"board":  defaultStyle.Background(c(dark)),
-			"text":   defaultStyle.Background(c(dark)).Foreground(c(light)),
-			"line":   defaultStyle.Background(c(dark)).Foreground(c(gray)),
-			"p1":     defaultStyle.Background(c(dark)).Foreground(c(yellow)),
-			"p2":     defaultStyle.Background(c(dark)).Foreground(c(red)),
-			"hi":     defaultStyle.Foreground(c(green)),
-			"status": defaultStyle.Foreground(c(blue)),
+			"board":  defaultStyle.Background(t.Surface),
+			"text":   defaultStyle.Background(t.Surface),
+			"line":   defaultStyle.Background(t.Surface).Foreground(t.Muted),
+			"p1":     defaultStyle.Background(t.Surface).Foreground(t.Player1),
+			"p2":     defaultStyle.Background(t.Surface).Foreground(t.Player2),
+			"cursor": defaultStyle.Background(t.Cursor),
+			"hi":     defaultStyle.Foreground(t.Good),
+			"hint":   defaultStyle.Background(t.Surface).Foreground(t.Info),
+			"best":   defaultStyle.Background(t.Surface).Foreground(t.Good).Bold(true),
+			"status": defaultStyle.Foreground(t.Info),
 		},
 	}
 }
@@ -72,13 +115,56 @@ type gameOverMsg struct{ winner Player }
 type nextTurnMsg struct{}
 type aiTurnMsg struct{}
 
+// aiMoveMsg carries the square the AI plays on, or why it couldn't move.
+type aiMoveMsg struct {
+	move int
+	err  error
+}
+
+// hintMsg carries the hints for the board whose cells were cells, or why
+// there are none.
+type hintMsg struct {
+	cells []int
+	hints []MoveStat
+	err   error
+}
+
 func (g Game) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case aiTurnMsg:
 		time.Sleep(time.Millisecond * 200)
-		return g, aiMoveCmd(&g)
+		return g, g.aiMove()
+
+	case aiMoveMsg:
+		if msg.err == nil {
+			msg.err = g.engine.PlayMove(g.board, P2, msg.move)
+		}
+		if msg.err != nil {
+			g.err = fmt.Errorf("the AI failed: %w", msg.err)
+			return g, nil
+		}
+
+		isover, win := g.engine.CheckGameOver(g.board, msg.move)
+		switch {
+		case isover && win > 0:
+			return g, func() tea.Msg { return gameOverMsg{winner: P2} }
+		case isover:
+			return g, func() tea.Msg { return gameOverMsg{winner: 0} }
+		}
+		return g, func() tea.Msg { return nextTurnMsg{} }
+
+	case hintMsg:
+		if msg.err != nil {
+			g.err = fmt.Errorf("could not find a hint: %w", msg.err)
+			return g, nil
+		}
+		if !g.gameover && g.turn == P1 && slices.Equal(msg.cells, g.board.Cells) {
+			g.hints = msg.hints
+		}
+		return g, nil
 
 	case nextTurnMsg:
+		g.hints = nil
 		g.turn = g.engine.GetOpponent(g.turn)
 		if g.turn == P2 {
 			return g, func() tea.Msg {
@@ -88,6 +174,7 @@ func (g Game) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return g, nil
 
 	case gameOverMsg:
+		g.hints = nil
 		g.winner = msg.winner
 		g.turn = g.engine.GetOpponent(g.turn)
 		g.gameover = true
@@ -98,104 +185,169 @@ func (g Game) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return g, nil
 
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
-			return g, tea.Quit
+	case scores.RecordedMsg:
+		if msg.Err != nil {
+			g.err = fmt.Errorf("could not save the score: %w", msg.Err)
+		}
+		return g, tea.Quit
 
-		case "n", "N":
+	case tea.KeyMsg:
+		switch {
+		case g.keys.Matches(msg, "quit"):
+			// The game ends once the score is saved, if there is one.
+			cmd := g.recordScore()
+			if cmd == nil || g.quitting {
+				return g, tea.Quit
+			}
+			g.quitting = true
+			return g, cmd
+
+		case g.keys.Matches(msg, "next"):
+			// A match being played would still get the messages of its
+			// moves.
+			if !g.gameover {
+				return g, nil
+			}
 			g.nextMatch()
 			if g.turn == P2 {
-				return g, aiMoveCmd(&g)
+				return g, g.aiMove()
 			}
 			return g, nil
 
-		case "1", "2", "3", "4", "5", "6", "7", "8", "9":
-			// There shouldn't be an error, because this is only called for integers
-			index, _ := strconv.Atoi(msg.String())
-			index -= 1
-			cell, err := g.board.GetCell(index)
-			if err != nil {
-				log.Fatal(err)
+		case g.keys.Matches(msg, "hint"):
+			if g.hints != nil {
+				g.hints = nil
+				return g, nil
 			}
-
-			if cell == EMPTY {
-				g.engine.PlayMove(g.board, P1, index)
-
-				isover, win := g.engine.CheckGameOver(g.board, index)
-
-				if isover {
-					if win > 0 {
-						g.winner = g.turn
-						// Update score
-						if g.winner == P1 {
-							g.scoreP1 += 1
-						} else if g.winner == P2 {
-							g.scoreP2 += 1
-						}
-					} else {
-						g.winner = 0
-					}
-
-					g.gameover = true
-					g.turn = g.engine.GetOpponent(g.turn)
-					return g, nil
-				}
-
-				return g, func() tea.Msg {
-					return nextTurnMsg{}
-				}
+			if g.gameover || g.turn != P1 {
+				return g, nil
 			}
+			return g, g.hintCmd()
+
+		case g.keys.Matches(msg, "place"):
+			return g.play(g.keys.Index(msg, "place"))
+
+		case g.keys.Matches(msg, "mark"):
+			return g.play(g.cursor)
+
+		case g.keys.Matches(msg, "up"):
+			g.moveCursor(-1, 0)
+		case g.keys.Matches(msg, "down"):
+			g.moveCursor(1, 0)
+		case g.keys.Matches(msg, "left"):
+			g.moveCursor(0, -1)
+		case g.keys.Matches(msg, "right"):
+			g.moveCursor(0, 1)
 		}
 	}
 
 	return g, nil
 }
 
-// Handle AI turn
-func aiMoveCmd(g *Game) tea.Cmd {
-	return func() tea.Msg {
-		rollout := g.board.Copy()
-		move := g.engine.ai.Solve(rollout)
-
-		g.engine.PlayMove(g.board, P2, move)
+// play puts the player's mark on the square at index, if it is their turn
+// and the square is free.
+func (g Game) play(index int) (tea.Model, tea.Cmd) {
+	if g.gameover || g.turn != P1 {
+		return g, nil
+	}
 
-		isover, win := g.engine.CheckGameOver(g.board, move)
-		if isover {
-			if win > 0 {
-				return gameOverMsg{winner: P2}
-			}
+	if g.engine.PlayMove(g.board, P1, index) != nil {
+		return g, nil
+	}
+	g.cursor = index
+	g.hints = nil
 
-			return gameOverMsg{winner: 0}
+	isover, win := g.engine.CheckGameOver(g.board, index)
+	if isover {
+		if win > 0 {
+			g.winner = g.turn
+			g.scoreP1 += 1
+		} else {
+			g.winner = 0
 		}
 
+		g.gameover = true
+		g.turn = g.engine.GetOpponent(g.turn)
+		return g, nil
+	}
+
+	return g, func() tea.Msg {
 		return nextTurnMsg{}
 	}
 }
 
-func (g *Game) nextMatch() {
-	g.board = NewBoard(size)
-	g.gameover = false
-	g.winner = 0
-	g.round += 1
+// hintCmd analyzes the board for the player. The search is as strong as
+// the hard AI, and draws from its own generator so the AI plays the same
+// moves for the seed whether hints were asked for or not.
+func (g Game) hintCmd() tea.Cmd {
+	board := g.board.Copy()
+	d := Hard
+	d.Think = g.difficulty.Think
+	analyzer := NewAnalyzer(g.engine, d, rand.New(rand.NewPCG(g.seed, uint64(g.round))))
+
+	return func() tea.Msg {
+		hints, err := analyzer.Analyze(board)
+		return hintMsg{cells: board.Cells, hints: hints, err: err}
+	}
+}
+
+// moveCursor moves the cursor by dr rows and dc columns, staying on the
+// board.
+func (g *Game) moveCursor(dr, dc int) {
+	row, col := g.cursor/g.size+dr, g.cursor%g.size+dc
+	if row < 0 || row >= g.size || col < 0 || col >= g.size {
+		return
+	}
+
+	g.cursor = row*g.size + col
+}
+
+// recordScore returns the command saving the wins and losses of this
+// session, or nil if no match was played.
+func (g *Game) recordScore() tea.Cmd {
+	if g.round == 1 && !g.gameover {
+		return nil
+	}
 
-	randLvl := rand.IntN(50) + 50
-	g.engine = NewEngine(randLvl)
+	return scores.RecordCmd("tictactoe-ai", scores.Result{
+		Score:    g.scoreP1,
+		Duration: time.Since(g.started),
+		Seed:     g.seed,
+		Detail:   g.detail(),
+	})
 }
 
-func printCell(board *Board, index int) string {
-	cell, err := board.GetCell(index)
-	if err != nil {
-		panic(err)
+// detail describes the session for the high scores, with the board when it
+// isn't the usual one.
+func (g *Game) detail() string {
+	d := fmt.Sprintf("W%d-L%d %s", g.scoreP1, g.scoreP2, g.difficulty.Name)
+	if g.size != 3 || g.engine.WinLength(g.board) != 3 {
+		d += fmt.Sprintf(" %dx%d/%d", g.size, g.size, g.engine.WinLength(g.board))
 	}
 
-	sign := printPlayer(cell)
+	return d
+}
+
+// aiMove asks the AI for its move, on a copy of the board seen from its
+// side as it always plays P1.
+func (g Game) aiMove() tea.Cmd {
+	board := g.board.Copy()
+	board.ChangePerspective()
 
-	if sign == "" {
-		return fmt.Sprintf("%d", index+1)
+	return func() tea.Msg {
+		move, err := g.engine.ai.Solve(board)
+		return aiMoveMsg{move: move, err: err}
 	}
+}
+
+func (g *Game) nextMatch() {
+	g.board = NewBoard(g.size)
+	g.hints = nil
+	g.gameover = false
+	g.winner = 0
+	g.round += 1
 
-	return sign
+	g.engine = NewEngine(g.winLength, g.difficulty, g.rng.Rand)
 }
 
 func printPlayer(cell int) string {
@@ -209,6 +361,19 @@ func printPlayer(cell int) string {
 }
 
 func (g Game) View() string {
+	// With hints, the squares of small boards widen to fit the chance to
+	// win. Larger boards show how often each move was tried instead, from
+	// 0 to 9, as their win rates barely differ early on.
+	width := 1
+	if g.hints != nil && g.size <= 5 {
+		width = 3
+	}
+	best := Best(g.hints)
+	most := 0
+	for _, s := range g.hints {
+		most = max(most, s.Visits)
+	}
+
 	renderCell := func(index int) string {
 		cell, _ := g.board.GetCell(index)
 		var style lipgloss.Style
@@ -221,9 +386,26 @@ func (g Game) View() string {
 		case P2:
 			style = g.colors["p2"]
 			content = "X"
-		default: // Empty cell, show index
+		default: // Empty cell, show index on boards played with the numbers
 			style = g.colors["text"]
-			content = strconv.Itoa(index + 1)
+			content = "·"
+			if g.size == 3 {
+				content = strconv.Itoa(index + 1)
+			}
+			if i := slices.IndexFunc(g.hints, func(s MoveStat) bool { return s.Move == index }); i >= 0 {
+				style = g.colors["hint"]
+				if index == best {
+					style = g.colors["best"]
+				}
+				content = formatHint(g.hints[i], most, width)
+			}
+		}
+		if width == 3 && len([]rune(content)) == 1 {
+			content = " " + content + " "
+		}
+
+		if index == g.cursor && !g.gameover {
+			style = style.Background(g.colors["cursor"].GetBackground())
 		}
 
 		return style.Render(content)
@@ -242,26 +424,72 @@ func (g Game) View() string {
 	}
 
 	board := ""
-	for i := 0; i < 3; i++ {
-		board += g.colors["board"].Render(" ")
-		board += renderCell(i * 3)
-		board += g.colors["line"].Render(" | ")
-		board += renderCell(i*3 + 1)
-		board += g.colors["line"].Render(" | ")
-		board += renderCell(i*3 + 2)
-		board += g.colors["board"].Render(" ")
-
-		if i < 2 {
-			board += "\n" + g.colors["line"].Render("---+---+---") + "\n"
+	if g.size <= 5 {
+		// Small boards are drawn with lines between the squares.
+		for i := 0; i < g.size; i++ {
+			for j := 0; j < g.size; j++ {
+				if j > 0 {
+					board += g.colors["line"].Render(" |")
+				}
+				board += g.colors["board"].Render(" ")
+				board += renderCell(i*g.size + j)
+			}
+			board += g.colors["board"].Render(" ")
+
+			if i < g.size-1 {
+				line := strings.Repeat("-", width+2)
+				board += "\n" + g.colors["line"].Render(strings.Repeat(line+"+", g.size-1)+line) + "\n"
+			}
+		}
+	} else {
+		// Larger ones wouldn't fit, so they only get a space between them.
+		for i := 0; i < g.size; i++ {
+			for j := 0; j < g.size; j++ {
+				board += g.colors["board"].Render(" ")
+				board += renderCell(i*g.size + j)
+			}
+			board += g.colors["board"].Render(" ")
+
+			if i < g.size-1 {
+				board += "\n"
+			}
 		}
 	}
 
-	status := g.colors["status"].Render(fmt.Sprintf("\n#%d:(W%d-L%d)", g.round, g.scoreP1, g.scoreP2))
+	if g.hints != nil {
+		legend := "hint: chance to win, the best move in bold"
+		if width == 1 {
+			legend = "hint: how often each move was tried, the best in bold"
+		}
+		board += "\n" + g.colors["status"].Render(legend)
+	}
+
+	if k := g.engine.WinLength(g.board); k != g.size {
+		board += "\n" + g.colors["status"].Render(fmt.Sprintf("%d in a row wins", k))
+	}
+
+	status := g.colors["status"].Render(fmt.Sprintf("\n#%d:(W%d-L%d, %s)", g.round, g.scoreP1, g.scoreP2, g.difficulty.Name))
 	if g.gameover {
-		status += g.colors["status"].Render("> [Q]uit - [N]ext match")
+		status += g.colors["status"].Render("> game over")
 	} else {
 		status += g.colors["status"].Render(fmt.Sprintf("> %s's turn", printPlayer(g.turn)))
 	}
+	status += "\n" + g.keys.Help()
+
+	if g.err != nil {
+		status += fmt.Sprintf("\nError: %v", g.err)
+	}
 
 	return winner + board + status
 }
+
+// formatHint writes the hint for a move in width characters: its chance to
+// win if there is room for a percentage, otherwise its visits as a digit
+// from 0 to 9, 9 being the most visits of any move.
+func formatHint(s MoveStat, most, width int) string {
+	if width < 3 {
+		return strconv.Itoa(s.Visits * 9 / most)
+	}
+
+	return fmt.Sprintf("%2d%%", min(99, int(s.WinRate*100)))
+}
@@ -0,0 +1,160 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+)
+
+// RolloutPolicy picks the moves of the games MCTS plays out to the end to
+// rate a position. Random moves are fast but play badly, so a move that
+// wins or saves the game is easily missed; a policy can know better.
+//
+// Policies are shared by the goroutines of a search, so they must not keep
+// any state of their own.
+type RolloutPolicy interface {
+	// Move picks the move player makes on board, out of moves, the legal
+	// moves. It returns false if it has no preference, leaving the choice
+	// to the next policy.
+	Move(game GameEngine, board *Board, player Player, moves []int, r *rand.Rand) (move int, ok bool)
+}
+
+// Locator is implemented by games whose moves aren't the index of the cell
+// they take, such as a column in connect 4, for policies that care where a
+// move puts a piece.
+type Locator interface {
+	// Locate returns the cell move takes on board.
+	Locate(board *Board, move int) int
+}
+
+// The policies that come with the engine.
+var (
+	// RandomRollout plays any legal move, as MCTS always did.
+	RandomRollout RolloutPolicy = randomPolicy{}
+	// TakeWins plays a move that wins at once, if there is one.
+	TakeWins RolloutPolicy = winPolicy{}
+	// BlockLosses plays where the opponent would win on their next move.
+	BlockLosses RolloutPolicy = blockPolicy{}
+	// PreferCenter plays a random move, likelier the closer it is to the
+	// center of the board.
+	PreferCenter RolloutPolicy = centerPolicy{}
+	// HeuristicRollout takes a win, else blocks a loss, else prefers the
+	// center.
+	HeuristicRollout = Rollout(TakeWins, BlockLosses, PreferCenter)
+)
+
+// Rollouts are the policies to choose from by name, e.g. in 'gg arena'.
+var Rollouts = map[string]RolloutPolicy{
+	"random":    RandomRollout,
+	"win":       Rollout(TakeWins),
+	"block":     Rollout(TakeWins, BlockLosses),
+	"center":    PreferCenter,
+	"heuristic": HeuristicRollout,
+}
+
+// LookupRollout returns the policy called name in Rollouts.
+func LookupRollout(name string) (RolloutPolicy, error) {
+	if p, ok := Rollouts[name]; ok {
+		return p, nil
+	}
+
+	names := make([]string, 0, len(Rollouts))
+	for name := range Rollouts {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return nil, fmt.Errorf("unknown rollout policy %q, choose one of %s", name, strings.Join(names, ", "))
+}
+
+// Rollout returns a policy asking each of policies in turn, and playing a
+// random move if none of them has a preference.
+func Rollout(policies ...RolloutPolicy) RolloutPolicy {
+	return chain(policies)
+}
+
+type chain []RolloutPolicy
+
+func (c chain) Move(game GameEngine, board *Board, player Player, moves []int, r *rand.Rand) (int, bool) {
+	for _, p := range c {
+		if move, ok := p.Move(game, board, player, moves, r); ok {
+			return move, true
+		}
+	}
+
+	return RandomRollout.Move(game, board, player, moves, r)
+}
+
+type randomPolicy struct{}
+
+func (randomPolicy) Move(_ GameEngine, _ *Board, _ Player, moves []int, r *rand.Rand) (int, bool) {
+	if len(moves) == 0 {
+		return -1, false
+	}
+
+	return moves[r.IntN(len(moves))], true
+}
+
+type winPolicy struct{}
+
+func (winPolicy) Move(game GameEngine, board *Board, player Player, moves []int, _ *rand.Rand) (int, bool) {
+	return winningMove(game, board, player, moves)
+}
+
+type blockPolicy struct{}
+
+func (blockPolicy) Move(game GameEngine, board *Board, player Player, moves []int, _ *rand.Rand) (int, bool) {
+	return winningMove(game, board, game.GetOpponent(player), moves)
+}
+
+// winningMove returns the first of moves with which player wins at once.
+func winningMove(game GameEngine, board *Board, player Player, moves []int) (int, bool) {
+	scratch := NewGrid(board.Width, board.Height)
+	for _, move := range moves {
+		copy(scratch.Cells, board.Cells)
+		if game.PlayMove(scratch, player, move) != nil {
+			continue
+		}
+		if over, win := game.CheckGameOver(scratch, move); over && win > 0 {
+			return move, true
+		}
+	}
+
+	return -1, false
+}
+
+type centerPolicy struct{}
+
+func (centerPolicy) Move(game GameEngine, board *Board, _ Player, moves []int, r *rand.Rand) (int, bool) {
+	if len(moves) == 0 {
+		return -1, false
+	}
+
+	locator, _ := game.(Locator)
+	centerRow, centerCol := float64(board.Height-1)/2, float64(board.Width-1)/2
+
+	// A move is weighted by 1 / (1 + its distance from the center).
+	weights := make([]float64, len(moves))
+	total := 0.0
+	for i, move := range moves {
+		cell := move
+		if locator != nil {
+			cell = locator.Locate(board, move)
+		}
+		row, col := float64(cell/board.Width), float64(cell%board.Width)
+		weights[i] = 1 / (1 + math.Abs(row-centerRow) + math.Abs(col-centerCol))
+		total += weights[i]
+	}
+
+	pick := r.Float64() * total
+	for i, w := range weights {
+		pick -= w
+		if pick < 0 {
+			return moves[i], true
+		}
+	}
+
+	return moves[len(moves)-1], true
+}
@@ -0,0 +1,291 @@
+package engine
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// The outcomes the solver proves, for the player to move.
+const (
+	Loss = -1
+	Draw = 0
+	Win  = 1
+)
+
+// MaxSolverCells is the size of the largest board the solver can encode,
+// 40 cells with three states each still fitting in a uint64.
+const MaxSolverCells = 40
+
+// bound tells how a score in the transposition table relates to the real
+// score of the position, which alpha-beta pruning doesn't always find.
+type bound int8
+
+const (
+	exact bound = iota
+	lower
+	upper
+)
+
+type entry struct {
+	score int
+	bound bound
+}
+
+// Solver is an AI that plays perfectly, by searching every move with
+// negamax and alpha-beta pruning. Positions are stored in a transposition
+// table under the smallest key of their 8 rotations and reflections, so a
+// position is only searched once however it is turned.
+//
+// Like every AI, it plays as P1 on the board it is given. It is only
+// practical on small square boards, whose rotations are the same game,
+// and the table is kept between moves.
+type Solver struct {
+	engine  GameEngine
+	table   map[uint64]entry
+	size    int
+	symKeys [8][]int
+	order   []int
+}
+
+// NewSolver returns a solver for games played with engine.
+func NewSolver(engine GameEngine) *Solver {
+	return &Solver{engine: engine, table: map[uint64]entry{}}
+}
+
+// Solve returns the best move for P1, the quickest win or the slowest loss.
+// It fails if the board isn't valid, is too large or not square, and with
+// ErrNoMoves if no move is left.
+func (s *Solver) Solve(board *Board) (int, error) {
+	scores, err := s.scores(board)
+	if err != nil {
+		return -1, err
+	}
+
+	best, bestScore := -1, math.MinInt
+	for move, score := range scores {
+		if score > bestScore || score == bestScore && move < best {
+			best, bestScore = move, score
+		}
+	}
+	if best < 0 {
+		return -1, ErrNoMoves
+	}
+
+	return best, nil
+}
+
+// Outcome returns the result of a game that isn't over yet, with perfect
+// play from both sides: Win if P1, the player to move, wins, Loss if they
+// lose, and Draw.
+func (s *Solver) Outcome(board *Board) (int, error) {
+	if err := s.check(board); err != nil {
+		return Draw, err
+	}
+
+	s.prepare(board.Width)
+	return sign(s.negamax(board.Copy(), math.MinInt+1, math.MaxInt)), nil
+}
+
+// MoveOutcomes returns the outcome of every legal move for P1.
+func (s *Solver) MoveOutcomes(board *Board) (map[int]int, error) {
+	scores, err := s.scores(board)
+	if err != nil {
+		return nil, err
+	}
+
+	outcomes := map[int]int{}
+	for move, score := range scores {
+		outcomes[move] = sign(score)
+	}
+
+	return outcomes, nil
+}
+
+// scores returns the score of every legal move for P1. Wins are positive,
+// higher the sooner they come, and losses are negative.
+func (s *Solver) scores(board *Board) (map[int]int, error) {
+	if err := s.check(board); err != nil {
+		return nil, err
+	}
+
+	s.prepare(board.Width)
+	scores := map[int]int{}
+	for _, move := range s.moves(board) {
+		scores[move] = s.play(board.Copy(), move, math.MinInt+1, math.MaxInt)
+	}
+
+	return scores, nil
+}
+
+// play plays move for P1 and returns its score for P1.
+func (s *Solver) play(board *Board, move, alpha, beta int) int {
+	s.engine.PlayMove(board, P1, move)
+
+	isOver, win := s.engine.CheckGameOver(board, move)
+	if isOver {
+		if win > 0 {
+			// The sooner the win, the more empty cells it leaves.
+			return 1 + empty(board)
+		}
+		return 0
+	}
+
+	board.ChangePerspective()
+	return -s.negamax(board, -beta, -alpha)
+}
+
+// negamax returns the score of the board for P1, the player to move, as
+// long as it lies between alpha and beta. Otherwise it returns a bound.
+func (s *Solver) negamax(board *Board, alpha, beta int) int {
+	key := s.key(board)
+	alphaIn := alpha
+
+	if e, ok := s.table[key]; ok {
+		switch e.bound {
+		case exact:
+			return e.score
+		case lower:
+			alpha = max(alpha, e.score)
+		case upper:
+			beta = min(beta, e.score)
+		}
+		if alpha >= beta {
+			return e.score
+		}
+	}
+
+	moves := s.moves(board)
+	if len(moves) == 0 {
+		return 0
+	}
+
+	best := math.MinInt + 1
+	for _, move := range moves {
+		score := s.play(board.Copy(), move, alpha, beta)
+		best = max(best, score)
+		alpha = max(alpha, score)
+		if alpha >= beta {
+			break
+		}
+	}
+
+	e := entry{score: best, bound: exact}
+	if best <= alphaIn {
+		e.bound = upper
+	} else if best >= beta {
+		e.bound = lower
+	}
+	s.table[key] = e
+
+	return best
+}
+
+// moves returns the legal moves, the central ones first as they tend to be
+// the best, which lets alpha-beta prune more.
+func (s *Solver) moves(board *Board) []int {
+	legal := s.engine.GetLegalMoves(board)
+
+	moves := make([]int, 0, len(legal))
+	for _, cell := range s.order {
+		if slices.Contains(legal, cell) {
+			moves = append(moves, cell)
+		}
+	}
+
+	return moves
+}
+
+// prepare computes the symmetries and the move order of a size×size board.
+// The table is emptied when the size changes.
+func (s *Solver) prepare(size int) {
+	if s.size == size {
+		return
+	}
+
+	s.size = size
+	s.table = map[uint64]entry{}
+
+	// The cell each cell goes to under every rotation and reflection.
+	n := size - 1
+	transforms := [8]func(r, c int) (int, int){
+		func(r, c int) (int, int) { return r, c },
+		func(r, c int) (int, int) { return c, n - r },
+		func(r, c int) (int, int) { return n - r, n - c },
+		func(r, c int) (int, int) { return n - c, r },
+		func(r, c int) (int, int) { return r, n - c },
+		func(r, c int) (int, int) { return n - r, c },
+		func(r, c int) (int, int) { return c, r },
+		func(r, c int) (int, int) { return n - c, n - r },
+	}
+	for i, t := range transforms {
+		s.symKeys[i] = make([]int, size*size)
+		for cell := range size * size {
+			r, c := t(cell/size, cell%size)
+			s.symKeys[i][cell] = r*size + c
+		}
+	}
+
+	s.order = make([]int, size*size)
+	for i := range s.order {
+		s.order[i] = i
+	}
+	center := float64(n) / 2
+	dist := func(cell int) float64 {
+		return math.Abs(float64(cell/size)-center) + math.Abs(float64(cell%size)-center)
+	}
+	slices.SortStableFunc(s.order, func(a, b int) int {
+		return cmp.Compare(dist(a), dist(b))
+	})
+}
+
+// key returns the hash of the board: the cells written as a number in base
+// 3, the smallest of the 8 symmetric boards.
+func (s *Solver) key(board *Board) uint64 {
+	key := uint64(math.MaxUint64)
+
+	for _, sym := range s.symKeys {
+		var k uint64
+		for cell := range board.Cells {
+			k = k*3 + uint64(board.Cells[sym[cell]]+1)
+		}
+		key = min(key, k)
+	}
+
+	return key
+}
+
+// check returns an error if the solver can't solve board.
+func (s *Solver) check(board *Board) error {
+	if err := board.Validate(); err != nil {
+		return err
+	}
+	if board.Width != board.Height || len(board.Cells) > MaxSolverCells {
+		return fmt.Errorf("the solver can't solve a %dx%d board", board.Width, board.Height)
+	}
+
+	return nil
+}
+
+func empty(board *Board) int {
+	n := 0
+	for _, cell := range board.Cells {
+		if cell == EMPTY {
+			n++
+		}
+	}
+
+	return n
+}
+
+func sign(score int) int {
+	switch {
+	case score > 0:
+		return Win
+	case score < 0:
+		return Loss
+	}
+
+	return Draw
+}
@@ -1,33 +1,122 @@
 package tictactoe
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/Kaamkiya/gg/internal/app/tictactoe/engine"
+	"github.com/Kaamkiya/gg/internal/keymap"
+	"github.com/Kaamkiya/gg/internal/netplay"
+	"github.com/Kaamkiya/gg/internal/registry"
+	"github.com/Kaamkiya/gg/internal/theme"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
-var winner = ' '
+func init() {
+	registry.Register(registry.Game{
+		Name:        "tictactoe",
+		Title:       "tictactoe",
+		Description: "get three in a row before your opponent does",
+		Players:     2,
+		Networked:   true,
+		New:         newModel,
+	})
+	registry.Register(registry.Game{
+		Name:        "tictactoe-ai",
+		Title:       "tictactoe (vs AI)",
+		Description: "get three in a row before the computer does, or five on a 15x15 board",
+		Players:     1,
+		Settings: []registry.Setting{
+			{Name: "size", Usage: "width and height of the board, from 3 to 15", Default: "3", Check: registry.CheckWith(parseSize)},
+			{Name: "win", Usage: "marks in a row needed to win, at most the size (0 for a whole row)", Default: "0"},
+			{Name: "difficulty", Usage: "how well the AI plays: " + strings.Join(difficulties(), ", "), Default: "medium", Choices: difficulties()},
+			{Name: "think", Usage: "time the AI thinks per move on every CPU, e.g. 500ms, instead of the difficulty's fixed search (0); such games can't be recorded", Default: "0", Wallclock: true, Check: registry.CheckWith(engine.ParseThink)},
+		},
+		New: newAIModel,
+	})
+}
+
+func newModel(registry.Options) (tea.Model, error) {
+	return initialModel(), nil
+}
+
+func newAIModel(opts registry.Options) (tea.Model, error) {
+	size, err := parseSize(opts.Get("size"))
+	if err != nil {
+		return nil, err
+	}
+
+	win, err := strconv.Atoi(opts.Get("win"))
+	if err != nil || win != 0 && (win < 3 || win > size) {
+		return nil, fmt.Errorf("invalid win length %q: expected 0 or a number from 3 to %d", opts.Get("win"), size)
+	}
+
+	difficulty, err := engine.LookupDifficulty(opts.Get("difficulty"))
+	if err != nil {
+		return nil, err
+	}
+
+	difficulty.Think, err = engine.ParseThink(opts.Get("think"))
+	if err != nil {
+		return nil, err
+	}
+
+	return engine.GetModel(opts.Seed, size, win, difficulty), nil
+}
+
+// parseSize parses the width and height of the board, from 3 to 15.
+func parseSize(s string) (int, error) {
+	size, err := strconv.Atoi(s)
+	if err != nil || size < 3 || size > 15 {
+		return 0, fmt.Errorf("invalid size %q: expected a number from 3 to 15", s)
+	}
+
+	return size, nil
+}
+
+// difficulties returns the names of the AI's difficulty levels.
+func difficulties() []string {
+	names := make([]string, 0, len(engine.Difficulties))
+	for _, d := range engine.Difficulties {
+		names = append(names, d.Name)
+	}
+
+	return names
+}
+
+// actions place a mark on a square, the n-th key of "place" on the n-th one.
+var actions = []keymap.Action{
+	{Name: "place", Keys: []string{"1", "2", "3", "4", "5", "6", "7", "8", "9"}, Help: "place a mark"},
+	keymap.Quit,
+}
+
+var _ netplay.Model = model{}
 
 type model struct {
 	turn   rune
+	winner rune
 	board  [9]rune
+	keys   *keymap.Map
 	xcolor lipgloss.Style
 	ocolor lipgloss.Style
 }
 
 func initialModel() tea.Model {
 	return model{
-		turn: 'x',
+		turn:   'x',
+		winner: ' ',
+		keys:   keymap.New("tictactoe", actions...),
 		board: [9]rune{
 			'1', '2', '3',
 			'4', '5', '6',
 			'7', '8', '9',
 		},
-		xcolor: lipgloss.NewStyle().Foreground(lipgloss.Color("#ff0000")),
-		ocolor: lipgloss.NewStyle().Foreground(lipgloss.Color("#0000ff")),
+		xcolor: lipgloss.NewStyle().Foreground(theme.Current().Player1),
+		ocolor: lipgloss.NewStyle().Foreground(theme.Current().Player2),
 	}
 }
 
@@ -38,33 +127,84 @@ func (m model) Init() tea.Cmd {
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
+		switch {
+		case m.keys.Matches(msg, "quit"):
 			return m, tea.Quit
-		case "1", "2", "3", "4", "5", "6", "7", "8", "9":
-			// There shouldn't be an error, because this is only called for integers
-			position, _ := strconv.Atoi(msg.String())
-
-			if m.board[position-1] != 'x' && m.board[position-1] != 'o' {
-				m.board[position-1] = m.turn
-
-				if m.turn == 'x' {
-					m.turn = 'o'
-				} else {
-					m.turn = 'x'
-				}
-			}
+		case m.keys.Matches(msg, "place"):
+			return m.place(m.keys.Index(msg, "place"))
+		}
+	}
 
-			if m.CheckForWin() != ' ' {
-				winner = m.CheckForWin()
-				return m, tea.Quit
-			}
+	return m, nil
+}
+
+// place puts the mark of the player whose turn it is on the square at
+// index, if there is one and it is free. More keys than squares may be
+// bound to "place".
+func (m model) place(index int) (tea.Model, tea.Cmd) {
+	if index < 0 || index >= len(m.board) {
+		return m, nil
+	}
+
+	if m.board[index] != 'x' && m.board[index] != 'o' {
+		m.board[index] = m.turn
+
+		if m.turn == 'x' {
+			m.turn = 'o'
+		} else {
+			m.turn = 'x'
 		}
 	}
 
+	if m.CheckForWin() != ' ' {
+		m.winner = m.CheckForWin()
+		return m, tea.Quit
+	}
+
 	return m, nil
 }
 
+// Action returns the square the player on side places a mark on with key.
+func (m model) Action(_ netplay.Side, key tea.KeyMsg) (netplay.Action, bool) {
+	index := m.keys.Index(key, "place")
+	if index < 0 || index >= len(m.board) {
+		return netplay.Action{}, false
+	}
+
+	return netplay.Action{Move: index}, true
+}
+
+// Play places the mark of the player on side, the host playing x, on the
+// square action.Move.
+func (m model) Play(side netplay.Side, action netplay.Action) (tea.Model, tea.Cmd, error) {
+	switch {
+	case action.Input != "" || action.Move < 0 || action.Move >= len(m.board):
+		return m, nil, fmt.Errorf("invalid move %+v", action)
+	case m.winner != ' ':
+		return m, nil, errors.New("the game is over")
+	case m.turn != mark(side):
+		return m, nil, fmt.Errorf("it is %c's turn", m.turn)
+	case m.board[action.Move] == 'x' || m.board[action.Move] == 'o':
+		return m, nil, fmt.Errorf("square %d is taken", action.Move+1)
+	}
+
+	model, cmd := m.place(action.Move)
+	return model, cmd, nil
+}
+
+func (m model) Player(side netplay.Side) string {
+	return string(mark(side))
+}
+
+// mark returns the mark of the player on side; the host plays x.
+func mark(side netplay.Side) rune {
+	if side == netplay.Host {
+		return 'x'
+	}
+
+	return 'o'
+}
+
 func (m model) View() string {
 	s := fmt.Sprintf("%c | %c | %c\n", m.board[0], m.board[1], m.board[2])
 	s += "---------\n"
@@ -72,7 +212,12 @@ func (m model) View() string {
 	s += "---------\n"
 	s += fmt.Sprintf("%c | %c | %c\n", m.board[6], m.board[7], m.board[8])
 
-	s += fmt.Sprintf("\n\n%c's turn", m.turn)
+	if m.winner != ' ' {
+		s += fmt.Sprintf("\n\n%c wins\n", m.winner)
+	} else {
+		s += fmt.Sprintf("\n\n%c's turn\n", m.turn)
+		s += m.keys.Help()
+	}
 
 	return s
 }
@@ -120,21 +265,3 @@ func (m model) CheckForWin() rune {
 
 	return ' '
 }
-
-func Run() {
-	p := tea.NewProgram(initialModel())
-
-	if _, err := p.Run(); err != nil {
-		panic(err)
-	}
-
-	fmt.Printf("%c wins\n", winner)
-}
-
-func RunVsAi() {
-	p := tea.NewProgram(engine.GetModel())
-
-	if _, err := p.Run(); err != nil {
-		panic(err)
-	}
-}
@@ -0,0 +1,154 @@
+package tictactoe
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Kaamkiya/gg/internal/gametest"
+	"github.com/Kaamkiya/gg/internal/keymap"
+	"github.com/Kaamkiya/gg/internal/netplay"
+	"github.com/Kaamkiya/gg/internal/registry"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestLargeBoard(t *testing.T) {
+	h := gametest.New(t, "tictactoe-ai", registry.Options{
+		Seed:     1,
+		Settings: map[string]string{"size": "15", "win": "5"},
+	})
+	h.Golden("large-start")
+
+	// The cursor starts in the middle, the player places a mark there and
+	// the AI answers.
+	h.Press("left", "up", "enter")
+	view := h.View()
+	if got := marks(view, "O"); got != 1 {
+		t.Fatalf("expected one mark of the player, got %d:\n%s", got, view)
+	}
+	if got := marks(view, "X"); got != 1 {
+		t.Fatalf("expected one mark of the AI, got %d:\n%s", got, view)
+	}
+	if !strings.Contains(view, "5 in a row wins") {
+		t.Fatalf("expected the win length to be shown:\n%s", view)
+	}
+}
+
+func TestSmallBoardNumbers(t *testing.T) {
+	h := gametest.New(t, "tictactoe-ai", registry.Options{Seed: 1})
+	h.Golden("small-start")
+
+	h.Press("5")
+	if got := marks(h.View(), "O"); got != 1 {
+		t.Fatalf("expected one mark of the player, got %d:\n%s", got, h.View())
+	}
+}
+
+func TestHint(t *testing.T) {
+	h := gametest.New(t, "tictactoe-ai", registry.Options{Seed: 1})
+	h.Press("5", "?")
+
+	view := h.View()
+	if got := strings.Count(view, "%"); got != 7 {
+		t.Fatalf("expected the chance to win of the 7 free squares, got %d:\n%s", got, view)
+	}
+	if !strings.Contains(view, "hint:") {
+		t.Fatalf("expected the hint legend:\n%s", view)
+	}
+
+	// Playing a move on a free square hides the hints.
+	for _, key := range []string{"1", "2", "3", "4", "6", "7", "8", "9"} {
+		h.Press(key)
+		if !strings.Contains(h.View(), "hint:") {
+			break
+		}
+	}
+	if strings.Contains(h.View(), "%") {
+		t.Fatalf("expected the hints to go away after a move:\n%s", h.View())
+	}
+}
+
+// marks counts mark on the board, above the status line.
+func marks(view, mark string) int {
+	board, _, _ := strings.Cut(view, "#")
+	return strings.Count(board, mark)
+}
+
+func TestInvalidSettings(t *testing.T) {
+	g, _ := registry.Lookup("tictactoe-ai")
+
+	for _, settings := range []map[string]string{
+		{"size": "2", "win": "0"},
+		{"size": "16", "win": "0"},
+		{"size": "4", "win": "5"},
+		{"size": "4", "win": "2"},
+		{"size": "four", "win": "0"},
+	} {
+		if _, err := g.New(registry.Options{Settings: settings}); err == nil {
+			t.Errorf("expected an error for %v", settings)
+		}
+	}
+}
+
+func TestNetwork(t *testing.T) {
+	host, guest := gametest.Connect(t, "tictactoe", registry.Options{})
+
+	// x goes first: the host drops a move of the guest out of turn.
+	guest.Press("1")
+	host.Receive()
+	if game(host).(model).board[0] != '1' {
+		t.Fatalf("expected the guest's move to be dropped:\n%s", host.View())
+	}
+
+	// The host's moves reach the guest, and the guest's moves reach the
+	// host, who sends them back.
+	for i, move := range []string{"5", "1", "2", "3", "8"} {
+		if i%2 == 0 {
+			host.Press(move)
+			guest.Receive()
+		} else {
+			guest.Press(move)
+			host.Receive()
+			guest.Receive()
+		}
+
+		if got, want := game(guest).View(), game(host).View(); got != want {
+			t.Fatalf("the games differ after %s:\n%s\n%s", move, got, want)
+		}
+	}
+
+	if !strings.Contains(guest.View(), "x wins") || !host.Quit() || !guest.Quit() {
+		t.Fatalf("expected x to win on both sides:\n%s", guest.View())
+	}
+	if !strings.Contains(guest.View(), "you play o") {
+		t.Fatalf("expected the guest to be told their mark:\n%s", guest.View())
+	}
+}
+
+// game returns the game of a harness running a netplay session.
+func game(h *gametest.Harness) tea.Model {
+	return h.Model().(*netplay.Session).Game()
+}
+
+func TestMorePlaceKeysThanSquares(t *testing.T) {
+	dir := t.TempDir()
+	bind := func(keys string) {
+		path := filepath.Join(dir, "keys.json")
+		if err := os.WriteFile(path, []byte(keys), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := keymap.Load(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bind(`{"tictactoe": {"place": ["1", "2", "3", "4", "5", "6", "7", "8", "9", "0"]}}`)
+	t.Cleanup(func() { bind(`{}`) })
+
+	h := gametest.New(t, "tictactoe", registry.Options{})
+	h.Press("0")
+	if got := marks(h.View(), "X"); got != 0 {
+		t.Fatalf("expected the tenth key to place no mark, got %d:\n%s", got, h.View())
+	}
+}
@@ -1,47 +1,102 @@
 package twenty48
 
 import (
-	"math/rand/v2"
+	"fmt"
 	"strconv"
 	"time"
 
+	"github.com/Kaamkiya/gg/internal/keymap"
+	"github.com/Kaamkiya/gg/internal/registry"
+	"github.com/Kaamkiya/gg/internal/rng"
+	"github.com/Kaamkiya/gg/internal/savegame"
+	"github.com/Kaamkiya/gg/internal/scores"
+	"github.com/Kaamkiya/gg/internal/theme"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
+func init() {
+	registry.Register(registry.Game{
+		Name:        "twenty48",
+		Title:       "2048",
+		Description: "slide and merge the tiles until you reach 2048",
+		Players:     1,
+		New:         newModel,
+		Resume:      resume,
+	})
+}
+
+// saveVersion is the version of saveState, which keeps the random number
+// generator too; a change to how it encodes needs a new version.
+const saveVersion = 1
+
+// saveState is what is kept of a suspended game.
+type saveState struct {
+	Grid   [4][4]int     `json:"grid"`
+	Score  int           `json:"score"`
+	Rand   *rng.Rand     `json:"rand"`
+	Seed   uint64        `json:"seed"`
+	Played time.Duration `json:"played"`
+}
+
+func newModel(opts registry.Options) (tea.Model, error) {
+	m := initialModel(opts.Rand())
+	m.seed = opts.Seed
+
+	// The board needs to start with two starting tiles.
+	m.AddTile()
+	m.AddTile()
+	return m, nil
+}
+
+func resume(s savegame.Save) (tea.Model, error) {
+	var state saveState
+	if err := s.Decode(saveVersion, &state); err != nil {
+		return nil, err
+	}
+
+	m := initialModel(state.Rand)
+	m.grid = state.Grid
+	m.score = state.Score
+	m.seed = state.Seed
+	m.started = time.Now().Add(-state.Played)
+	return m, nil
+}
+
 type model struct {
-	// TODO: add a score counter.
 	colors map[int]lipgloss.Style
 	grid   [4][4]int
+	// score is the sum of all the tiles created by merging.
+	score   int
+	rng     *rng.Rand
+	seed    uint64
+	keys    *keymap.Map
+	started time.Time
+	// over is set once the game is over, while the score is saved.
+	over bool
+	err  error
 }
 
-func initialModel() tea.Model {
-	defaultStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#f9f6f2"))
-	c := func(s string) lipgloss.Color {
-		return lipgloss.Color(s)
+// tileStyles returns the style of every tile, and of the empty one at 0.
+func tileStyles(t theme.Theme) map[int]lipgloss.Style {
+	styles := map[int]lipgloss.Style{0: t.Tiles[0].Style()}
+	for i, tile := range t.Tiles[1:] {
+		styles[2<<i] = tile.Style()
 	}
 
+	return styles
+}
+
+func initialModel(r *rng.Rand) model {
 	m := model{
-		colors: map[int]lipgloss.Style{
-			0:    defaultStyle.Background(c("#3c3a32")),
-			2:    defaultStyle.Background(c("#eee4da")).Foreground(c("#000000")),
-			4:    defaultStyle.Background(c("#ede0c8")).Foreground(c("#000000")),
-			8:    defaultStyle.Background(c("#f2b179")),
-			16:   defaultStyle.Background(c("#f59563")),
-			32:   defaultStyle.Background(c("#f67c5f")),
-			64:   defaultStyle.Background(c("#f65e3b")),
-			128:  defaultStyle.Background(c("#edcf72")),
-			256:  defaultStyle.Background(c("#edcc61")),
-			512:  defaultStyle.Background(c("#edc850")),
-			1024: defaultStyle.Background(c("#edc53f")),
-			2048: defaultStyle.Background(c("#edc22e")),
-		},
-		grid: [4][4]int{},
+		colors:  tileStyles(theme.Current()),
+		grid:    [4][4]int{},
+		rng:     r,
+		keys:    keymap.New("twenty48", keymap.Up, keymap.Down, keymap.Left, keymap.Right, keymap.Save, keymap.Quit),
+		started: time.Now(),
 	}
 
-	// The board needs to start with two starting tiles.
-	m.AddTile()
-	m.AddTile()
 	return m
 }
 
@@ -51,15 +106,32 @@ func (m model) Init() tea.Cmd {
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case scores.RecordedMsg:
+		m.err = msg.Err
+		return m, tea.Quit
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q":
+		switch {
+		case m.keys.Matches(msg, "quit"):
 			return m, tea.Quit
-		case "left", "h":
+		case m.over:
+			return m, nil
+		case m.keys.Matches(msg, "save"):
+			m.err = savegame.Write("twenty48", saveVersion, saveState{
+				Grid:   m.grid,
+				Score:  m.score,
+				Rand:   m.rng,
+				Seed:   m.seed,
+				Played: time.Since(m.started),
+			})
+			if m.err != nil {
+				return m, nil
+			}
+			return m, tea.Quit
+		case m.keys.Matches(msg, "left"):
 			beforeMerge := m.grid
 			m.MergeTilesLeft()
 			m.ValidateTile(beforeMerge)
-		case "down", "j":
+		case m.keys.Matches(msg, "down"):
 			/* Instead of creating a separate method to merge down,
 			 * we rotate the grid. This is because the
 			 * m.MergeTilesLeft() method is *much* more complex
@@ -72,13 +144,13 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.Rotate90(true)
 
 			m.ValidateTile(beforeMerge)
-		case "up", "k":
+		case m.keys.Matches(msg, "up"):
 			beforeMerge := m.grid
 			m.Rotate90(true)
 			m.MergeTilesLeft()
 			m.Rotate90(false)
 			m.ValidateTile(beforeMerge)
-		case "right", "l":
+		case m.keys.Matches(msg, "right"):
 			beforeMerge := m.grid
 			m.Rotate90(false)
 			m.Rotate90(false)
@@ -89,13 +161,15 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	}
 
-	if m.CheckForWin() {
-		return m, tea.Quit
-	}
-
-	// The game is over when there are no possible merges.
-	if !m.CanMove() {
-		return m, tea.Quit
+	// The game is over when 2048 is reached or there are no possible merges,
+	// and ends once the score is saved.
+	if !m.over && (m.CheckForWin() || !m.CanMove()) {
+		m.over = true
+		return m, scores.RecordCmd("twenty48", scores.Result{
+			Score:    m.score,
+			Duration: time.Since(m.started),
+			Seed:     m.seed,
+		})
 	}
 
 	return m, nil
@@ -140,7 +214,11 @@ func (m model) View() string {
 		s += "\n"
 	}
 
-	s += "\nhjkl or arrows to move"
+	s += fmt.Sprintf("\nScore: %d", m.score)
+	s += "\n" + m.keys.Help()
+	if m.err != nil {
+		s += fmt.Sprintf("\nCould not save: %v", m.err)
+	}
 
 	return s
 }
@@ -158,6 +236,7 @@ func (m *model) MergeTilesLeft() {
 					case m.grid[i][k-1] == m.grid[i][k]:
 						m.grid[i][k-1] += m.grid[i][k]
 						m.grid[i][k] = 0
+						m.score += m.grid[i][k-1]
 						stopMerge = k
 					default:
 						break
@@ -182,15 +261,9 @@ func (m *model) AddTile() bool {
 		return false
 	}
 
-	rndSrc := rand.NewPCG(
-		uint64(time.Now().UnixNano()),
-		uint64(time.Now().UnixMilli()),
-	)
-	rnd := rand.New(rndSrc)
-
-	cell := empty[rnd.IntN(len(empty))]
+	cell := empty[m.rng.IntN(len(empty))]
 
-	if rnd.IntN(10) < 9 {
+	if m.rng.IntN(10) < 9 {
 		m.grid[cell/len(m.grid)][cell%len(m.grid)] = 2
 	} else {
 		m.grid[cell/len(m.grid)][cell%len(m.grid)] = 4
@@ -269,11 +342,3 @@ func (m model) CanMove() bool {
 
 	return false
 }
-
-func Run() {
-	p := tea.NewProgram(initialModel())
-
-	if _, err := p.Run(); err != nil {
-		panic(err)
-	}
-}
@@ -0,0 +1,173 @@
+// Package arena measures AIs against each other. A Tournament plays many
+// seeded games between every pair of players, each going first in half of
+// them, and its Results hold a win, draw and loss table and the Elo rating
+// it gives every player.
+//
+// It is how a change to the engine is shown to be an improvement: put the
+// old and the new configuration in the same tournament and compare them.
+package arena
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"slices"
+	"sync"
+
+	"github.com/Kaamkiya/gg/internal/app/connect4"
+	"github.com/Kaamkiya/gg/internal/app/tictactoe/engine"
+)
+
+// Game is a game the players play.
+type Game struct {
+	// Name describes the game in the results.
+	Name string
+	// Engine holds the rules.
+	Engine engine.GameEngine
+	// NewBoard returns the board a game starts on.
+	NewBoard func() *engine.Board
+}
+
+// TicTacToe returns tictactoe on a size×size board, won with winLength
+// marks in a row, or a whole row if winLength is 0.
+func TicTacToe(size, winLength int) Game {
+	e := engine.NewEngine(winLength, engine.Medium, rand.New(rand.NewPCG(0, 0)))
+
+	return Game{
+		Name:     fmt.Sprintf("tictactoe %dx%d, %d in a row", size, size, e.WinLength(engine.NewBoard(size))),
+		Engine:   e,
+		NewBoard: func() *engine.Board { return engine.NewBoard(size) },
+	}
+}
+
+// Connect4 is connect 4 on its usual board.
+var Connect4 = Game{
+	Name:     "connect 4",
+	Engine:   connect4.Rules{},
+	NewBoard: connect4.NewBoard,
+}
+
+// Tournament is a round robin between players.
+type Tournament struct {
+	Game    Game
+	Players []Player
+	// Games is the number of games each pair of players plays. The first
+	// player of the pair goes first in the even games.
+	Games int
+	// Seed seeds every game, so the same tournament always ends the same
+	// way, as long as no player thinks for a set time.
+	Seed uint64
+	// Workers is the number of games played at once, one per CPU if 0.
+	Workers int
+}
+
+// match is a single game of a tournament.
+type match struct {
+	index  int
+	first  int
+	second int
+}
+
+// Run plays the tournament.
+func (t Tournament) Run() (*Results, error) {
+	if len(t.Players) < 2 {
+		return nil, errors.New("a tournament needs at least two players")
+	}
+	if t.Games < 1 {
+		return nil, errors.New("each pair of players must play at least one game")
+	}
+	for _, p := range t.Players {
+		if _, err := p.New(t.Game, rand.New(rand.NewPCG(t.Seed, 0))); err != nil {
+			return nil, fmt.Errorf("%s: %w", p.Name, err)
+		}
+	}
+
+	var matches []match
+	for i := range t.Players {
+		for j := i + 1; j < len(t.Players); j++ {
+			for k := range t.Games {
+				m := match{index: len(matches), first: i, second: j}
+				if k%2 == 1 {
+					m.first, m.second = j, i
+				}
+				matches = append(matches, m)
+			}
+		}
+	}
+
+	workers := t.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	// Every game writes its own result, so they can be played in any
+	// order without changing the outcome.
+	outcomes := make([]int, len(matches))
+	queue := make(chan match)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for m := range queue {
+				outcomes[m.index] = t.play(m)
+			}
+		}()
+	}
+	for _, m := range matches {
+		queue <- m
+	}
+	close(queue)
+	wg.Wait()
+
+	results := newResults(t.Players)
+	for i, m := range matches {
+		results.add(m.first, m.second, outcomes[i])
+	}
+
+	return results, nil
+}
+
+// play plays a match and returns its result for the player going first:
+// 1 for a win, -1 for a loss and 0 for a draw.
+func (t Tournament) play(m match) int {
+	r := rand.New(rand.NewPCG(t.Seed, uint64(m.index)))
+
+	// Both players were checked before the tournament started.
+	first, _ := t.Players[m.first].New(t.Game, rand.New(rand.NewPCG(r.Uint64(), r.Uint64())))
+	second, _ := t.Players[m.second].New(t.Game, rand.New(rand.NewPCG(r.Uint64(), r.Uint64())))
+
+	return Play(t.Game, first, second)
+}
+
+// Play plays a game of g between first, who goes first, and second, and
+// returns its result for first: 1 for a win, -1 for a loss and 0 for a
+// draw. A player who picks an illegal move, or whose AI fails, loses.
+func Play(g Game, first, second engine.AI) int {
+	board := g.NewBoard()
+	ais := [2]engine.AI{first, second}
+	sides := [2]engine.Player{engine.P1, engine.P2}
+
+	for turn := 0; ; turn = 1 - turn {
+		// Every AI plays as P1 on the board it is given.
+		view := board.Copy()
+		if sides[turn] == engine.P2 {
+			view.ChangePerspective()
+		}
+
+		move, err := ais[turn].Solve(view)
+		if err != nil || !slices.Contains(g.Engine.GetLegalMoves(board), move) ||
+			g.Engine.PlayMove(board, sides[turn], move) != nil {
+			return -sides[turn]
+		}
+
+		if over, win := g.Engine.CheckGameOver(board, move); over {
+			if win > 0 {
+				return sides[turn]
+			}
+			return 0
+		}
+	}
+}
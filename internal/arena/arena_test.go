@@ -0,0 +1,147 @@
+package arena
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	tour := Tournament{
+		Game:    TicTacToe(3, 0),
+		Players: []Player{Random, Solver},
+		Games:   20,
+		Seed:    1,
+	}
+
+	results, err := tour.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := results.Records[1][0]
+	if rec.Games() != 20 {
+		t.Fatalf("expected 20 games, got %d", rec.Games())
+	}
+	if rec.Losses != 0 {
+		t.Errorf("expected the solver never to lose, got %s", rec)
+	}
+	if results.Records[0][1] != (Record{rec.Losses, rec.Draws, rec.Wins}) {
+		t.Errorf("the records of both players disagree: %s and %s", results.Records[0][1], rec)
+	}
+
+	elo := results.Elo()
+	if elo[1] <= elo[0] {
+		t.Errorf("expected the solver to be rated higher, got %v", elo)
+	}
+}
+
+// TestDeterministic checks that a seeded tournament ends the same way
+// however many games are played at once.
+func TestDeterministic(t *testing.T) {
+	hard, err := ParsePlayer("hard:iterations=200")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var records [][][]Record
+	for _, workers := range []int{1, 4} {
+		results, err := Tournament{
+			Game:    Connect4,
+			Players: []Player{Random, hard},
+			Games:   6,
+			Seed:    7,
+			Workers: workers,
+		}.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, results.Records)
+	}
+
+	if !reflect.DeepEqual(records[0], records[1]) {
+		t.Fatalf("expected the same results, got %v and %v", records[0], records[1])
+	}
+}
+
+func TestRunInvalid(t *testing.T) {
+	tests := []Tournament{
+		{Game: Connect4, Players: []Player{Random, Solver}, Games: 1},
+		{Game: TicTacToe(7, 4), Players: []Player{Random, Solver}, Games: 1},
+		{Game: TicTacToe(3, 0), Players: []Player{Random}, Games: 1},
+		{Game: TicTacToe(3, 0), Players: []Player{Random, Solver}, Games: 0},
+	}
+
+	for _, tour := range tests {
+		if _, err := tour.Run(); err == nil {
+			t.Errorf("expected an error for %s with %d players and %d games", tour.Game.Name, len(tour.Players), tour.Games)
+		}
+	}
+}
+
+func TestElo(t *testing.T) {
+	even := &Results{
+		Players: []string{"a", "b"},
+		Records: [][]Record{{{}, {5, 10, 5}}, {{5, 10, 5}, {}}},
+	}
+	for _, rating := range even.Elo() {
+		if math.Abs(rating-1500) > 0.01 {
+			t.Errorf("expected even players to be rated 1500, got %v", even.Elo())
+		}
+	}
+
+	// a beats everyone, and b beats c three times out of four.
+	ordered := &Results{
+		Players: []string{"a", "b", "c"},
+		Records: [][]Record{
+			{{}, {10, 0, 0}, {10, 0, 0}},
+			{{0, 0, 10}, {}, {30, 0, 10}},
+			{{0, 0, 10}, {10, 0, 30}, {}},
+		},
+	}
+	elo := ordered.Elo()
+	if !(elo[0] > elo[1] && elo[1] > elo[2]) || math.IsInf(elo[0], 0) {
+		t.Fatalf("expected finite ratings in the order a, b, c, got %v", elo)
+	}
+	// Winning three games out of four is worth about 190 points.
+	if d := elo[1] - elo[2]; d < 150 || d > 210 {
+		t.Errorf("expected b to be rated about 190 above c, got %.0f", d)
+	}
+}
+
+func TestParsePlayer(t *testing.T) {
+	for _, spec := range []string{"random", "solver", "mcts", "easy", "hard:iterations=50,c=2", "perfect:exact=false,think=10ms", "medium:blunder=0.5"} {
+		p, err := ParsePlayer(spec)
+		if err != nil {
+			t.Errorf("%s: %v", spec, err)
+			continue
+		}
+		if p.Name != spec {
+			t.Errorf("expected the player to be called %s, got %s", spec, p.Name)
+		}
+	}
+
+	for _, spec := range []string{"", "grandmaster", "random:c=1", "hard:depth=3", "hard:iterations=0", "hard:c=high", "hard:c"} {
+		if _, err := ParsePlayer(spec); err == nil {
+			t.Errorf("expected an error for %q", spec)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	results := &Results{
+		Players: []string{"weak", "strong"},
+		Records: [][]Record{{{}, {1, 2, 7}}, {{7, 2, 1}, {}}},
+	}
+
+	var b strings.Builder
+	if err := results.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(b.String(), "\n")
+	if !strings.HasPrefix(lines[1], "strong") || !strings.Contains(lines[1], "7-2-1") {
+		t.Fatalf("expected the strong player first, with its record:\n%s", b.String())
+	}
+}
@@ -0,0 +1,152 @@
+package arena
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kaamkiya/gg/internal/app/tictactoe/engine"
+)
+
+// Player is an AI taking part in a tournament.
+type Player struct {
+	// Name identifies the player in the results.
+	Name string
+	// New returns the AI of the player for a game of g, drawing from r.
+	// Every game gets a new one, so that no game learns from another.
+	New func(g Game, r *rand.Rand) (engine.AI, error)
+}
+
+// Random is a player that picks any legal move.
+var Random = Player{
+	Name: "random",
+	New: func(g Game, r *rand.Rand) (engine.AI, error) {
+		return &randomAI{g.Engine, r}, nil
+	},
+}
+
+// Solver is a player that searches the whole game with the alpha-beta
+// solver. It only plays tictactoe on boards small enough to solve.
+var Solver = Player{
+	Name: "solver",
+	New: func(g Game, r *rand.Rand) (engine.AI, error) {
+		e, ok := g.Engine.(*engine.Engine)
+		if !ok {
+			return nil, fmt.Errorf("the solver can't play %s", g.Name)
+		}
+		if board := g.NewBoard(); board.Width != board.Height || len(board.Cells) > engine.MaxSolverCells {
+			return nil, fmt.Errorf("the solver can't play %s, its board is too large", g.Name)
+		}
+
+		return engine.NewSolver(e), nil
+	},
+}
+
+// MCTS returns a player searching with MCTS at difficulty d.
+func MCTS(name string, d engine.Difficulty) Player {
+	return Player{
+		Name: name,
+		New: func(g Game, r *rand.Rand) (engine.AI, error) {
+			return engine.NewAI(g.Engine, d, r), nil
+		},
+	}
+}
+
+// ParsePlayer returns the player described by spec: random, solver, mcts
+// or the name of a difficulty. The settings of mcts, which starts from the
+// medium difficulty, and of the difficulties can be changed after a colon,
+// e.g. "hard:iterations=5000,c=1". The settings are:
+//
+//	iterations=N   MCTS iterations per move
+//	c=F            exploration constant
+//	think=D        time to think per move instead, e.g. 100ms
+//	rollout=P      rollout policy, see engine.Rollouts
+//	blunder=F      chance of a random move
+//	exact=B        solve small tictactoe boards exactly
+func ParsePlayer(spec string) (Player, error) {
+	base, settings, _ := strings.Cut(spec, ":")
+
+	switch base {
+	case Random.Name, Solver.Name:
+		if settings != "" {
+			return Player{}, fmt.Errorf("%s: the %s player has no settings", spec, base)
+		}
+		if base == Random.Name {
+			return Random, nil
+		}
+		return Solver, nil
+	case "mcts":
+		base = engine.Medium.Name
+	}
+
+	d, err := engine.LookupDifficulty(base)
+	if err != nil {
+		return Player{}, fmt.Errorf("%s: unknown player, choose random, solver, mcts or a difficulty", spec)
+	}
+
+	for _, setting := range strings.Split(settings, ",") {
+		if setting == "" {
+			continue
+		}
+		if err := set(&d, setting); err != nil {
+			return Player{}, fmt.Errorf("%s: %w", spec, err)
+		}
+	}
+
+	return MCTS(spec, d), nil
+}
+
+// set changes the setting of d given as "name=value".
+func set(d *engine.Difficulty, setting string) error {
+	name, value, ok := strings.Cut(setting, "=")
+	if !ok {
+		return fmt.Errorf("invalid setting %q, expected name=value", setting)
+	}
+
+	var err error
+	switch name {
+	case "iterations":
+		d.Iterations, err = strconv.Atoi(value)
+		if err == nil && d.Iterations < 1 {
+			err = errors.New("at least 1 is needed")
+		}
+	case "c":
+		d.Exploration, err = strconv.ParseFloat(value, 64)
+	case "think":
+		d.Think, err = time.ParseDuration(value)
+	case "rollout":
+		d.Rollout, err = engine.LookupRollout(value)
+		if err != nil {
+			return err
+		}
+	case "blunder":
+		d.Blunder, err = strconv.ParseFloat(value, 64)
+	case "exact":
+		d.Exact, err = strconv.ParseBool(value)
+	default:
+		return fmt.Errorf("unknown setting %q", name)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %w", name, value, err)
+	}
+
+	return nil
+}
+
+// randomAI plays any legal move.
+type randomAI struct {
+	engine engine.GameEngine
+	rng    *rand.Rand
+}
+
+func (a *randomAI) Solve(board *engine.Board) (int, error) {
+	moves := a.engine.GetLegalMoves(board)
+	if len(moves) == 0 {
+		return -1, engine.ErrNoMoves
+	}
+
+	return moves[a.rng.IntN(len(moves))], nil
+}
@@ -0,0 +1,163 @@
+package arena
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"text/tabwriter"
+)
+
+// Record counts the results of a player against another.
+type Record struct {
+	Wins   int
+	Draws  int
+	Losses int
+}
+
+// Games returns the number of games played.
+func (r Record) Games() int {
+	return r.Wins + r.Draws + r.Losses
+}
+
+// Score returns the points scored, a draw counting as half a win.
+func (r Record) Score() float64 {
+	return float64(r.Wins) + float64(r.Draws)/2
+}
+
+func (r Record) String() string {
+	return fmt.Sprintf("%d-%d-%d", r.Wins, r.Draws, r.Losses)
+}
+
+// Results are the outcome of a tournament.
+type Results struct {
+	Players []string
+	// Records[i][j] is the record of player i against player j.
+	Records [][]Record
+}
+
+func newResults(players []Player) *Results {
+	r := &Results{Records: make([][]Record, len(players))}
+	for i, p := range players {
+		r.Players = append(r.Players, p.Name)
+		r.Records[i] = make([]Record, len(players))
+	}
+
+	return r
+}
+
+// add counts a game between players i and j that ended with outcome for i.
+func (r *Results) add(i, j, outcome int) {
+	switch {
+	case outcome > 0:
+		r.Records[i][j].Wins++
+		r.Records[j][i].Losses++
+	case outcome < 0:
+		r.Records[i][j].Losses++
+		r.Records[j][i].Wins++
+	default:
+		r.Records[i][j].Draws++
+		r.Records[j][i].Draws++
+	}
+}
+
+// Total returns the record of player i against everyone.
+func (r *Results) Total(i int) Record {
+	var total Record
+	for _, rec := range r.Records[i] {
+		total.Wins += rec.Wins
+		total.Draws += rec.Draws
+		total.Losses += rec.Losses
+	}
+
+	return total
+}
+
+// The average rating, and the number of rounds Elo improves the ratings.
+const (
+	averageElo = 1500
+	eloRounds  = 100
+)
+
+// Elo returns the Elo rating of every player that best explains the
+// results, the players averaging 1500. Each pair of players is counted as
+// having drawn one more game, so that a player who won every game still
+// gets a finite rating.
+func (r *Results) Elo() []float64 {
+	n := len(r.Players)
+	ratings := make([]float64, n)
+
+	// Newton's method on the log-likelihood of the results, one player at
+	// a time.
+	for range eloRounds {
+		for i := range n {
+			var score, expected, slope float64
+			for j := range n {
+				if i == j {
+					continue
+				}
+
+				games := float64(r.Records[i][j].Games() + 1)
+				e := expectedScore(ratings[i], ratings[j])
+				score += r.Records[i][j].Score() + 0.5
+				expected += games * e
+				slope += games * e * (1 - e) * math.Ln10 / 400
+			}
+			if slope > 0 {
+				ratings[i] += (score - expected) / slope
+			}
+		}
+	}
+
+	var mean float64
+	for _, rating := range ratings {
+		mean += rating / float64(n)
+	}
+	for i := range ratings {
+		ratings[i] += averageElo - mean
+	}
+
+	return ratings
+}
+
+// expectedScore returns the share of the points a player rated a is
+// expected to score against one rated b.
+func expectedScore(a, b float64) float64 {
+	return 1 / (1 + math.Pow(10, (b-a)/400))
+}
+
+// Write writes the results as a table, the best rated player first. Each
+// cell holds the wins, draws and losses of its row against its column.
+func (r *Results) Write(w io.Writer) error {
+	elo := r.Elo()
+	order := make([]int, len(r.Players))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(elo[b], elo[a])
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+
+	fmt.Fprint(tw, "PLAYER\tELO")
+	for _, j := range order {
+		fmt.Fprintf(tw, "\t%s", r.Players[j])
+	}
+	fmt.Fprintln(tw, "\tTOTAL (W-D-L)")
+
+	for _, i := range order {
+		fmt.Fprintf(tw, "%s\t%.0f", r.Players[i], elo[i])
+		for _, j := range order {
+			if i == j {
+				fmt.Fprint(tw, "\t-")
+				continue
+			}
+			fmt.Fprintf(tw, "\t%s", r.Records[i][j])
+		}
+		fmt.Fprintf(tw, "\t%s\n", r.Total(i))
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,96 @@
+// Package clock schedules the ticks that drive the real-time games. Games
+// take their clock from registry.Options, so tests can swap real time for a
+// fake clock and step through a game one tick at a time.
+package clock
+
+import (
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Clock schedules ticks.
+type Clock interface {
+	// Tick returns a command that waits for d and then produces the message
+	// returned by fn, like tea.Tick.
+	Tick(d time.Duration, fn func(time.Time) tea.Msg) tea.Cmd
+}
+
+// Real is the clock on the wall.
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Tick(d time.Duration, fn func(time.Time) tea.Msg) tea.Cmd {
+	return tea.Tick(d, fn)
+}
+
+// Fake is a clock that only moves when it is told to. Its ticks are started
+// when their command runs, and fire when the clock is advanced past them.
+// A Fake is not safe for concurrent use.
+type Fake struct {
+	now    time.Time
+	timers []timer
+}
+
+type timer struct {
+	at time.Time
+	fn func(time.Time) tea.Msg
+}
+
+// NewFake returns a fake clock set to now.
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Now returns the time of the clock.
+func (f *Fake) Now() time.Time {
+	return f.now
+}
+
+// Pending returns the number of ticks waiting to fire.
+func (f *Fake) Pending() int {
+	return len(f.timers)
+}
+
+func (f *Fake) Tick(d time.Duration, fn func(time.Time) tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		f.timers = append(f.timers, timer{f.now.Add(d), fn})
+		return nil
+	}
+}
+
+// Advance moves the clock forward by d. Every tick that fires on the way is
+// passed to send, in order, with the clock set to the time of the tick.
+// Ticks started by send fire as well if they are due before the end.
+func (f *Fake) Advance(d time.Duration, send func(tea.Msg)) {
+	end := f.now.Add(d)
+
+	for {
+		i := f.next()
+		if i < 0 || f.timers[i].at.After(end) {
+			break
+		}
+
+		t := f.timers[i]
+		f.timers = slices.Delete(f.timers, i, i+1)
+		f.now = t.at
+		send(t.fn(t.at))
+	}
+
+	f.now = end
+}
+
+// next returns the index of the earliest tick, or -1 if there is none.
+// Ticks due at the same time fire in the order they were started.
+func (f *Fake) next() int {
+	next := -1
+	for i, t := range f.timers {
+		if next < 0 || t.at.Before(f.timers[next].at) {
+			next = i
+		}
+	}
+
+	return next
+}
@@ -0,0 +1,54 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type tickMsg struct {
+	name string
+}
+
+func TestFakeFiresTicksInOrder(t *testing.T) {
+	f := NewFake(time.Time{})
+
+	var fired []string
+	send := func(msg tea.Msg) {
+		tick := msg.(tickMsg)
+		fired = append(fired, tick.name)
+
+		// Every tick starts the next one, like the games do.
+		if tick.name == "a" {
+			f.Tick(10*time.Millisecond, func(time.Time) tea.Msg { return tickMsg{"a"} })()
+		}
+	}
+
+	f.Tick(10*time.Millisecond, func(time.Time) tea.Msg { return tickMsg{"a"} })()
+	f.Tick(25*time.Millisecond, func(time.Time) tea.Msg { return tickMsg{"b"} })()
+
+	f.Advance(5*time.Millisecond, send)
+	if len(fired) != 0 {
+		t.Fatalf("expected no ticks yet, got %v", fired)
+	}
+
+	f.Advance(30*time.Millisecond, send)
+	want := []string{"a", "a", "b", "a"}
+	if len(fired) != len(want) {
+		t.Fatalf("expected %v, got %v", want, fired)
+	}
+	for i := range want {
+		if fired[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, fired)
+		}
+	}
+
+	if f.Now() != (time.Time{}).Add(35*time.Millisecond) {
+		t.Fatalf("expected the clock to be at 35ms, got %v", f.Now())
+	}
+
+	if f.Pending() != 1 {
+		t.Fatalf("expected 1 pending tick, got %d", f.Pending())
+	}
+}
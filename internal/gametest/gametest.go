@@ -0,0 +1,305 @@
+// Package gametest drives games in tests, without a terminal. A Harness
+// starts a registered game, sends it scripted key presses and runs its
+// commands the way Bubble Tea would. Real-time games get a fake clock, so a
+// test decides exactly when their ticks fire.
+//
+// Games for two players can also be played by two harnesses connected over
+// the loopback interface, one hosting the game and the other joining it.
+//
+// Views can be compared with golden files in the testdata directory of the
+// package under test. Run the tests with -update to write them.
+package gametest
+
+import (
+	"flag"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Kaamkiya/gg/internal/clock"
+	"github.com/Kaamkiya/gg/internal/netplay"
+	"github.com/Kaamkiya/gg/internal/registry"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+// Harness runs a single game.
+type Harness struct {
+	t     testing.TB
+	model tea.Model
+	clock *clock.Fake
+	queue []tea.Msg
+	quit  bool
+	// inbox holds what the other side of a connected game sent.
+	inbox chan tea.Msg
+}
+
+// New starts the game registered as name. Settings missing from opts get
+// their default value, and the game's ticks are scheduled on a fake clock.
+// High scores and saves go to a temporary data directory.
+func New(t testing.TB, name string, opts registry.Options) *Harness {
+	t.Helper()
+
+	g, opts := setup(t, name, opts)
+	h := newHarness(t)
+	opts.Clock = h.clock
+
+	m, err := g.New(opts)
+	if err != nil {
+		t.Fatalf("could not start %s: %v", name, err)
+	}
+
+	h.start(m)
+	return h
+}
+
+// Connect hosts the game registered as name on a loopback connection,
+// joins it, and returns the harnesses of the host and of the guest, both
+// running a netplay session. What one side sends reaches the other when
+// the test calls Receive on it. Only the host's ticks are scheduled on its
+// fake clock; the guest's come from the host.
+func Connect(t testing.TB, name string, opts registry.Options) (host, guest *Harness) {
+	t.Helper()
+
+	g, opts := setup(t, name, opts)
+	host, guest = newHarness(t), newHarness(t)
+	opts.Clock = host.clock
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	type accepted struct {
+		s   *netplay.Session
+		err error
+	}
+	done := make(chan accepted, 1)
+	go func() {
+		s, err := netplay.Accept(l, g, opts)
+		done <- accepted{s, err}
+	}()
+
+	joined, err := netplay.Join(l.Addr().String())
+	if err != nil {
+		t.Fatalf("could not join %s: %v", name, err)
+	}
+	hosted := <-done
+	if hosted.err != nil {
+		t.Fatalf("could not host %s: %v", name, hosted.err)
+	}
+
+	for h, s := range map[*Harness]*netplay.Session{host: hosted.s, guest: joined} {
+		t.Cleanup(func() { s.Close() })
+
+		inbox := make(chan tea.Msg, 100)
+		h.inbox = inbox
+		s.Run(func(msg tea.Msg) { inbox <- msg })
+		h.start(s)
+	}
+
+	return host, guest
+}
+
+// setup looks up the game registered as name and gives the settings
+// missing from opts their default value. High scores and saves go to a
+// temporary data directory.
+func setup(t testing.TB, name string, opts registry.Options) (registry.Game, registry.Options) {
+	t.Helper()
+
+	g, ok := registry.Lookup(name)
+	if !ok {
+		t.Fatalf("unknown game %q", name)
+	}
+
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	settings := g.DefaultOptions().Settings
+	for name, value := range opts.Settings {
+		settings[name] = value
+	}
+	opts.Settings = settings
+
+	return g, opts
+}
+
+func newHarness(t testing.TB) *Harness {
+	return &Harness{
+		t:     t,
+		clock: clock.NewFake(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)),
+	}
+}
+
+// start runs model, the game's model, until it waits for input.
+func (h *Harness) start(model tea.Model) {
+	h.model = model
+	h.run(model.Init())
+	h.drain()
+}
+
+// Model returns the game's current model.
+func (h *Harness) Model() tea.Model {
+	return h.model
+}
+
+// View returns the game's current view.
+func (h *Harness) View() string {
+	return h.model.View()
+}
+
+// Quit reports whether the game has quit. A game that quit receives no
+// more messages.
+func (h *Harness) Quit() bool {
+	return h.quit
+}
+
+// Send sends msgs to the game, one after the other. The messages of the
+// commands the game returns are sent as well, before Send returns.
+func (h *Harness) Send(msgs ...tea.Msg) {
+	for _, msg := range msgs {
+		h.queue = append(h.queue, msg)
+		h.drain()
+	}
+}
+
+// Press sends key presses, written the way tea.KeyMsg.String writes them,
+// e.g. "a", "up", "enter" or "ctrl+c".
+func (h *Harness) Press(keys ...string) {
+	for _, k := range keys {
+		h.Send(Key(k))
+	}
+}
+
+// Advance moves the fake clock forward by d, firing the game's ticks on
+// the way.
+func (h *Harness) Advance(d time.Duration) {
+	h.clock.Advance(d, func(msg tea.Msg) {
+		h.Send(msg)
+	})
+}
+
+// Receive waits for the next message from the other side of a connected
+// game, and sends it to the game.
+func (h *Harness) Receive() {
+	h.t.Helper()
+
+	select {
+	case msg := <-h.inbox:
+		h.Send(msg)
+	case <-time.After(5 * time.Second):
+		h.t.Fatal("nothing was received from the other side")
+	}
+}
+
+// Golden compares the game's view with the golden file testdata/name.golden.
+func (h *Harness) Golden(name string) {
+	h.t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+	got := h.View()
+
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			h.t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			h.t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		h.t.Fatalf("%v (run the tests with -update to create it)", err)
+	}
+
+	if got != string(want) {
+		h.t.Errorf("view doesn't match %s\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+// drain sends the queued messages to the game.
+func (h *Harness) drain() {
+	for len(h.queue) > 0 && !h.quit {
+		msg := h.queue[0]
+		h.queue = h.queue[1:]
+
+		m, cmd := h.model.Update(msg)
+		h.model = m
+		h.run(cmd)
+	}
+
+	h.queue = nil
+}
+
+var cmdType = reflect.TypeOf(tea.Cmd(nil))
+
+// run runs cmd and queues its message. Batches and sequences are run in
+// order; Bubble Tea's other messages are meant for the program and dropped.
+func (h *Harness) run(cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+
+	msg := cmd()
+	if msg == nil {
+		return
+	}
+
+	if _, ok := msg.(tea.QuitMsg); ok {
+		h.quit = true
+		return
+	}
+
+	// tea.Batch and tea.Sequence both produce a list of commands.
+	v := reflect.ValueOf(msg)
+	if v.Kind() == reflect.Slice && v.Type().Elem() == cmdType {
+		for i := range v.Len() {
+			h.run(v.Index(i).Interface().(tea.Cmd))
+		}
+		return
+	}
+
+	if v.Type().PkgPath() == reflect.TypeOf(tea.QuitMsg{}).PkgPath() {
+		return
+	}
+
+	h.queue = append(h.queue, msg)
+}
+
+// keyTypes maps the names of the special keys to their types.
+var keyTypes = func() map[string]tea.KeyType {
+	types := make(map[string]tea.KeyType)
+	for i := -256; i < 256; i++ {
+		if name := tea.KeyType(i).String(); name != "" && name != "runes" {
+			types[name] = tea.KeyType(i)
+		}
+	}
+	return types
+}()
+
+// Key returns the key press written as s, e.g. "a", "up" or "alt+enter".
+func Key(s string) tea.KeyMsg {
+	var k tea.KeyMsg
+
+	if rest, ok := strings.CutPrefix(s, "alt+"); ok && rest != "" {
+		k.Alt = true
+		s = rest
+	}
+
+	if t, ok := keyTypes[s]; ok {
+		k.Type = t
+		return k
+	}
+
+	k.Type = tea.KeyRunes
+	k.Runes = []rune(s)
+	return k
+}
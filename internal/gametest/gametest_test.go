@@ -0,0 +1,107 @@
+package gametest
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Kaamkiya/gg/internal/clock"
+	"github.com/Kaamkiya/gg/internal/registry"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// counter counts its ticks and moves a cursor with the arrow keys.
+type counter struct {
+	clock  clock.Clock
+	ticks  int
+	cursor int
+	step   string
+}
+
+type tickMsg struct{}
+
+func (c counter) tick() tea.Cmd {
+	return c.clock.Tick(100*time.Millisecond, func(time.Time) tea.Msg {
+		return tickMsg{}
+	})
+}
+
+func (c counter) Init() tea.Cmd {
+	return c.tick()
+}
+
+func (c counter) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q":
+			return c, tea.Quit
+		case "left":
+			c.cursor--
+		case "right":
+			c.cursor++
+		case " ":
+			return c, tea.Sequence(c.tick(), c.tick())
+		}
+	case tickMsg:
+		c.ticks++
+		return c, c.tick()
+	}
+
+	return c, nil
+}
+
+func (c counter) View() string {
+	return fmt.Sprintf("ticks: %d, cursor: %d, step: %s\n", c.ticks, c.cursor, c.step)
+}
+
+func init() {
+	registry.Register(registry.Game{
+		Name:     "gametest-counter",
+		Settings: []registry.Setting{{Name: "step", Default: "1"}},
+		New: func(opts registry.Options) (tea.Model, error) {
+			return counter{clock: opts.Ticker(), step: opts.Get("step")}, nil
+		},
+	})
+}
+
+func TestHarness(t *testing.T) {
+	h := New(t, "gametest-counter", registry.Options{})
+
+	if got := h.Model().(counter).step; got != "1" {
+		t.Fatalf("expected the default step, got %q", got)
+	}
+
+	h.Advance(350 * time.Millisecond)
+	if got := h.Model().(counter).ticks; got != 3 {
+		t.Fatalf("expected 3 ticks, got %d", got)
+	}
+
+	h.Press("right", "right", "left")
+	if got := h.Model().(counter).cursor; got != 1 {
+		t.Fatalf("expected the cursor at 1, got %d", got)
+	}
+
+	// Two more ticks are started by space, next to the running one.
+	h.Press(" ")
+	h.Advance(100 * time.Millisecond)
+	if got := h.Model().(counter).ticks; got != 6 {
+		t.Fatalf("expected 6 ticks, got %d", got)
+	}
+
+	h.Golden("counter")
+
+	h.Press("q")
+	if !h.Quit() {
+		t.Fatal("expected the game to quit")
+	}
+}
+
+func TestKey(t *testing.T) {
+	for _, s := range []string{"a", "up", "enter", "ctrl+c", "alt+x", " "} {
+		if got := Key(s).String(); got != s {
+			t.Errorf("expected %q, got %q", s, got)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+// Package keymap holds the key bindings of the games. Every game names the
+// actions a player can take, with their default keys, and players can bind
+// other keys to them in keys.json in the config directory:
+//
+//	{
+//	    "all": {"up": ["w"], "left": ["a"], "down": ["s"], "right": ["d"]},
+//	    "twenty48": {"save": ["ctrl+s"]}
+//	}
+//
+// The "all" entry applies to every game that has the action, and the entry
+// of a game wins over it. An empty list unbinds the action. Keys are written
+// the way tea.KeyMsg.String writes them, e.g. "a", "up", "enter" or "ctrl+c".
+package keymap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Kaamkiya/gg/internal/xdg"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Action is something a player can do in a game.
+type Action struct {
+	// Name identifies the action in the override file, e.g. "up".
+	Name string
+	// Keys are the keys bound to the action by default.
+	Keys []string
+	// Help describes the action in the help footer, e.g. "move up".
+	Help string
+}
+
+// The actions shared by most games.
+var (
+	Quit  = Action{"quit", []string{"q", "ctrl+c"}, "quit"}
+	Save  = Action{"save", []string{"s"}, "save and quit"}
+	Up    = Action{"up", []string{"up", "k"}, "up"}
+	Down  = Action{"down", []string{"down", "j"}, "down"}
+	Left  = Action{"left", []string{"left", "h"}, "left"}
+	Right = Action{"right", []string{"right", "l"}, "right"}
+)
+
+// Overrides maps game names to the keys of their actions. The "all" entry
+// applies to every game.
+type Overrides map[string]map[string][]string
+
+// all is the entry of Overrides that applies to every game.
+const all = "all"
+
+var overrides Overrides
+
+// File returns the path of the override file.
+func File() (string, error) {
+	dir, err := xdg.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "keys.json"), nil
+}
+
+// Load reads the override file at path, to be used by every Map created
+// afterwards. A missing file is not an error.
+func Load(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var o Overrides
+	if err := json.Unmarshal(data, &o); err != nil {
+		return fmt.Errorf("invalid key bindings in %s: %w", path, err)
+	}
+
+	overrides = o
+	return nil
+}
+
+// Map holds the key bindings of one game.
+type Map struct {
+	actions  []string
+	bindings map[string]key.Binding
+}
+
+// New returns the bindings of game's actions, with the overrides applied.
+func New(game string, actions ...Action) *Map {
+	m := &Map{bindings: make(map[string]key.Binding, len(actions))}
+
+	for _, a := range actions {
+		keys := a.Keys
+		if k, ok := overrides[all][a.Name]; ok {
+			keys = k
+		}
+		if k, ok := overrides[game][a.Name]; ok {
+			keys = k
+		}
+
+		b := key.NewBinding(key.WithKeys(keys...), key.WithHelp(helpKeys(keys), a.Help))
+		if len(keys) == 0 {
+			b.SetEnabled(false)
+		}
+
+		m.actions = append(m.actions, a.Name)
+		m.bindings[a.Name] = b
+	}
+
+	return m
+}
+
+// Binding returns the binding of action. An unknown action has an empty,
+// disabled binding.
+func (m *Map) Binding(action string) key.Binding {
+	return m.bindings[action]
+}
+
+// Matches reports whether msg is one of the keys bound to action.
+func (m *Map) Matches(msg tea.KeyMsg, action string) bool {
+	return key.Matches(msg, m.bindings[action])
+}
+
+// Index returns the position of msg among the keys bound to action, or -1.
+// Actions such as "drop a piece in column n" bind one key to every n.
+func (m *Map) Index(msg tea.KeyMsg, action string) int {
+	b := m.bindings[action]
+	if !b.Enabled() {
+		return -1
+	}
+
+	for i, k := range b.Keys() {
+		if msg.String() == k {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// ShortHelp returns the bindings shown in the help footer, and together with
+// FullHelp implements help.KeyMap.
+func (m *Map) ShortHelp() []key.Binding {
+	bindings := make([]key.Binding, 0, len(m.actions))
+	for _, a := range m.actions {
+		bindings = append(bindings, m.bindings[a])
+	}
+
+	return bindings
+}
+
+func (m *Map) FullHelp() [][]key.Binding {
+	return [][]key.Binding{m.ShortHelp()}
+}
+
+// Help returns the help footer, listing every action and its keys.
+func (m *Map) Help() string {
+	return help.New().ShortHelpView(m.ShortHelp())
+}
+
+// shortNames are the names of keys in the help footer, where they differ
+// from the way tea.KeyMsg.String writes them.
+var shortNames = map[string]string{
+	"up":    "↑",
+	"down":  "↓",
+	"left":  "←",
+	"right": "→",
+	" ":     "space",
+}
+
+// helpKeys writes keys the way the help footer shows them. Upper case
+// letters bound next to their lower case ones are left out, and long runs
+// of keys, such as the columns of connect 4, are shortened to the first and
+// the last.
+func helpKeys(keys []string) string {
+	var shown []string
+	for _, k := range keys {
+		if lower := strings.ToLower(k); lower != k && slices.Contains(keys, lower) {
+			continue
+		}
+		if name, ok := shortNames[k]; ok {
+			k = name
+		}
+		shown = append(shown, k)
+	}
+
+	if len(shown) > 3 {
+		return shown[0] + "-" + shown[len(shown)-1]
+	}
+
+	return strings.Join(shown, "/")
+}
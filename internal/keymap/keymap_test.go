@@ -0,0 +1,105 @@
+package keymap
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func press(s string) tea.KeyMsg {
+	if s == "up" {
+		return tea.KeyMsg{Type: tea.KeyUp}
+	}
+
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestDefaults(t *testing.T) {
+	overrides = nil
+	m := New("test", Up, Quit)
+
+	if !m.Matches(press("k"), "up") || !m.Matches(press("up"), "up") {
+		t.Fatal("expected k and the up arrow to move up")
+	}
+
+	if m.Matches(press("k"), "quit") || m.Matches(press("x"), "unknown") {
+		t.Fatal("expected k to only move up")
+	}
+
+	if help := m.Help(); !strings.Contains(help, "↑/k") || !strings.Contains(help, "q/ctrl+c") {
+		t.Fatalf("expected the help to list every key, got %q", help)
+	}
+}
+
+func TestLoadOverrides(t *testing.T) {
+	t.Cleanup(func() { overrides = nil })
+
+	path := filepath.Join(t.TempDir(), "keys.json")
+	err := os.WriteFile(path, []byte(`{
+		"all": {"up": ["w"], "quit": ["esc"]},
+		"test": {"up": ["i"], "save": []}
+	}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Load(path); err != nil {
+		t.Fatal(err)
+	}
+
+	m := New("test", Up, Quit, Save)
+	if !m.Matches(press("i"), "up") || m.Matches(press("w"), "up") || m.Matches(press("k"), "up") {
+		t.Fatal("expected the game's own binding to win")
+	}
+
+	if m.Matches(press("q"), "quit") || m.Binding("quit").Keys()[0] != "esc" {
+		t.Fatal("expected the binding for every game to apply")
+	}
+
+	if m.Binding("save").Enabled() || strings.Contains(m.Help(), "save") {
+		t.Fatal("expected save to be unbound")
+	}
+
+	other := New("other", Up)
+	if !other.Matches(press("w"), "up") {
+		t.Fatal("expected the binding for every game to apply to other games")
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	if err := Load(filepath.Join(t.TempDir(), "keys.json")); err != nil {
+		t.Fatalf("expected a missing file to be fine, got %v", err)
+	}
+}
+
+func TestIndex(t *testing.T) {
+	overrides = nil
+	m := New("test", Action{"drop", []string{"1", "2", "3", "4"}, "drop"})
+
+	if i := m.Index(press("3"), "drop"); i != 2 {
+		t.Fatalf("expected index 2, got %d", i)
+	}
+
+	if i := m.Index(press("5"), "drop"); i != -1 {
+		t.Fatalf("expected -1, got %d", i)
+	}
+}
+
+func TestHelpKeys(t *testing.T) {
+	tests := map[string][]string{
+		"↑/k":         {"up", "k"},
+		"h/←":         {"h", "H", "left"},
+		"1-7":         {"1", "2", "3", "4", "5", "6", "7"},
+		"q/ctrl+c":    {"q", "ctrl+c"},
+		"enter/space": {"enter", " "},
+	}
+
+	for want, keys := range tests {
+		if got := helpKeys(keys); got != want {
+			t.Errorf("expected %q for %v, got %q", want, keys, got)
+		}
+	}
+}
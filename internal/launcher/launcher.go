@@ -0,0 +1,361 @@
+// Package launcher hosts every game in a single Bubble Tea program. It shows
+// the game menu, runs the chosen game and returns to the menu once the game
+// is over, so the terminal is only set up and restored once.
+package launcher
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/Kaamkiya/gg/internal/registry"
+	"github.com/Kaamkiya/gg/internal/savegame"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+)
+
+type state int
+
+const (
+	choosing state = iota
+	playing
+	finished
+)
+
+// Model is the root model of gg.
+type Model struct {
+	state state
+	menu  *huh.Form
+
+	game tea.Model
+	// session is increased every time a game starts, so messages that are
+	// still in flight from a previous game never reach the next one.
+	session int
+	// direct is set when gg was started with a game from the command line,
+	// in which case gg exits as soon as that game is over.
+	direct bool
+
+	size tea.WindowSizeMsg
+	err  error
+}
+
+// sessionMsg is a message produced by one of the game's commands.
+type sessionMsg struct {
+	session int
+	msg     tea.Msg
+}
+
+// continuePrefix marks the menu entries that continue a saved game.
+const continuePrefix = "continue:"
+
+// endedMsg is sent instead of tea.QuitMsg when a game quits.
+type endedMsg struct {
+	session int
+}
+
+// New returns a launcher that starts at the game menu.
+func New() Model {
+	m := Model{}
+	m.showMenu()
+
+	return m
+}
+
+// NewWithGame returns a launcher that runs game right away and exits when
+// the game is over.
+func NewWithGame(game registry.Game, opts registry.Options) (Model, error) {
+	model, err := game.New(opts)
+	if err != nil {
+		return Model{}, err
+	}
+
+	return NewWithModel(model), nil
+}
+
+// NewWithModel is like NewWithGame for a game that was already created, such
+// as one that is being recorded or replayed.
+func NewWithModel(game tea.Model) Model {
+	m := Model{direct: true}
+	m.play(game)
+
+	return m
+}
+
+// LastView returns the last screen of the game that was played, if any.
+func (m Model) LastView() string {
+	if m.game == nil {
+		return ""
+	}
+
+	return m.game.View()
+}
+
+func (m Model) Init() tea.Cmd {
+	if m.state == playing {
+		return wrap(m.session, m.game.Init())
+	}
+
+	return m.menu.Init()
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		// ctrl+c always leaves gg, even in the middle of a game.
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+	case tea.WindowSizeMsg:
+		m.size = msg
+	case sessionMsg:
+		if msg.session != m.session || m.state != playing {
+			return m, nil
+		}
+		return m.updateGame(msg.msg)
+	case endedMsg:
+		if msg.session != m.session || m.state != playing {
+			return m, nil
+		}
+		if m.direct {
+			return m, tea.Quit
+		}
+		m.state = finished
+		return m, nil
+	}
+
+	switch m.state {
+	case choosing:
+		return m.updateMenu(msg)
+	case playing:
+		return m.updateGame(msg)
+	case finished:
+		if _, ok := msg.(tea.KeyMsg); ok {
+			return m, m.showMenu()
+		}
+	}
+
+	return m, nil
+}
+
+func (m Model) View() string {
+	switch m.state {
+	case playing:
+		return m.game.View()
+	case finished:
+		return m.game.View() + "\n\npress any key to return to the menu, ctrl+c to quit\n"
+	}
+
+	s := "gg - a tui for small offline games\n\n"
+	if m.err != nil {
+		s += fmt.Sprintf("Error: %v\n\n", m.err)
+	}
+
+	return s + m.menu.View()
+}
+
+func (m Model) updateGame(msg tea.Msg) (tea.Model, tea.Cmd) {
+	game, cmd := m.game.Update(msg)
+	m.game = game
+
+	return m, wrap(m.session, cmd)
+}
+
+func (m Model) updateMenu(msg tea.Msg) (tea.Model, tea.Cmd) {
+	form, cmd := m.menu.Update(msg)
+	m.menu = form.(*huh.Form)
+
+	switch m.menu.State {
+	case huh.StateAborted:
+		return m, tea.Quit
+	case huh.StateCompleted:
+		choice := m.menu.GetString("game")
+		name, resume := strings.CutPrefix(choice, continuePrefix)
+
+		game, ok := registry.Lookup(name)
+		if !ok {
+			m.err = fmt.Errorf("unknown game %q", name)
+			return m, m.showMenu()
+		}
+
+		var start tea.Cmd
+		var err error
+		if resume {
+			start, err = m.resume(game)
+		} else {
+			opts := game.DefaultOptions()
+			for _, s := range game.Settings {
+				if len(s.Choices) > 0 {
+					opts.Settings[s.Name] = m.menu.GetString(choiceKey(game, s))
+				}
+			}
+			start, err = m.start(game, opts)
+		}
+		if err != nil {
+			m.err = err
+			return m, m.showMenu()
+		}
+
+		return m, start
+	}
+
+	return m, cmd
+}
+
+// showMenu replaces whatever is on screen with a fresh game menu. Saved
+// games are offered first.
+func (m *Model) showMenu() tea.Cmd {
+	var options []huh.Option[string]
+
+	saves, err := savegame.List()
+	if err != nil && m.err == nil {
+		m.err = err
+	}
+
+	for _, s := range saves {
+		g, ok := registry.Lookup(s.Game)
+		if !ok || g.Resume == nil {
+			continue
+		}
+
+		label := fmt.Sprintf("continue %s (saved %s)", g.Title, s.Saved.Format("Jan 2 15:04"))
+		options = append(options, huh.NewOption(label, continuePrefix+g.Name))
+	}
+
+	for _, g := range registry.Games() {
+		options = append(options, huh.NewOption(g.Label(), g.Name))
+	}
+
+	chosen := new(string)
+	groups := []*huh.Group{huh.NewGroup(
+		huh.NewSelect[string]().
+			Key("game").
+			Title("choose a game:").
+			Options(options...).
+			Value(chosen),
+	)}
+
+	// Settings with a few choices are asked for once their game is chosen.
+	for _, g := range registry.Games() {
+		var fields []huh.Field
+		for _, s := range g.Settings {
+			if len(s.Choices) == 0 {
+				continue
+			}
+
+			value := s.Default
+			fields = append(fields, huh.NewSelect[string]().
+				Key(choiceKey(g, s)).
+				Title(s.Name+":").
+				Options(huh.NewOptions(s.Choices...)...).
+				Value(&value))
+		}
+
+		if len(fields) > 0 {
+			name := g.Name
+			groups = append(groups, huh.NewGroup(fields...).WithHideFunc(func() bool {
+				return *chosen != name
+			}))
+		}
+	}
+
+	m.menu = huh.NewForm(groups...)
+	m.state = choosing
+
+	if m.size.Width > 0 {
+		m.menu.Update(m.size)
+	}
+
+	return m.menu.Init()
+}
+
+// choiceKey is the key of the menu field asking for setting s of g.
+func choiceKey(g registry.Game, s registry.Setting) string {
+	return g.Name + "." + s.Name
+}
+
+// start creates a new game and makes it the active one.
+func (m *Model) start(g registry.Game, opts registry.Options) (tea.Cmd, error) {
+	game, err := g.New(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.play(game), nil
+}
+
+// resume continues the saved game of g and makes it the active one. The
+// save is removed once the game is restored; suspending the game again
+// writes a new one.
+func (m *Model) resume(g registry.Game) (tea.Cmd, error) {
+	s, err := savegame.Read(g.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	game, err := g.Resume(s)
+	if err != nil {
+		return nil, fmt.Errorf("could not continue %s: %w", g.Title, err)
+	}
+
+	if err := savegame.Remove(g.Name); err != nil {
+		return nil, err
+	}
+
+	return m.play(game), nil
+}
+
+// play makes game the active game. The game receives the current window
+// size, as it missed the one sent when gg started.
+func (m *Model) play(game tea.Model) tea.Cmd {
+	m.game = game
+	m.state = playing
+	m.err = nil
+	m.session++
+
+	cmds := []tea.Cmd{wrap(m.session, game.Init())}
+	if m.size.Width > 0 {
+		session, size := m.session, m.size
+		cmds = append(cmds, func() tea.Msg {
+			return sessionMsg{session, size}
+		})
+	}
+
+	return tea.Batch(cmds...)
+}
+
+// teaPackage is the import path of Bubble Tea. Its own messages, such as the
+// one clearing the screen, are meant for the program and are never wrapped.
+var teaPackage = reflect.TypeOf(tea.QuitMsg{}).PkgPath()
+
+// wrap tags the messages produced by a game's command with the game's
+// session, and turns tea.Quit into endedMsg so quitting a game doesn't quit
+// gg.
+func wrap(session int, cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+
+	return func() tea.Msg {
+		msg := cmd()
+
+		switch msg := msg.(type) {
+		case nil:
+			return nil
+		case tea.QuitMsg:
+			return endedMsg{session}
+		case tea.BatchMsg:
+			batch := make(tea.BatchMsg, len(msg))
+			for i, cmd := range msg {
+				batch[i] = wrap(session, cmd)
+			}
+			return batch
+		}
+
+		if reflect.TypeOf(msg).PkgPath() == teaPackage {
+			return msg
+		}
+
+		return sessionMsg{session, msg}
+	}
+}
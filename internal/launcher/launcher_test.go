@@ -0,0 +1,155 @@
+package launcher
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+
+	"github.com/Kaamkiya/gg/internal/registry"
+	"github.com/Kaamkiya/gg/internal/savegame"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type pingMsg struct{}
+
+// testGame counts the pings it receives and quits on "q".
+type testGame struct {
+	pings int
+}
+
+func (g testGame) Init() tea.Cmd { return nil }
+
+func (g testGame) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case pingMsg:
+		g.pings++
+	case tea.KeyMsg:
+		if msg.String() == "q" {
+			return g, tea.Quit
+		}
+	}
+
+	return g, nil
+}
+
+func (g testGame) View() string { return "test game" }
+
+var game = registry.Game{
+	Name: "launcher-test",
+	New: func(registry.Options) (tea.Model, error) {
+		return testGame{}, nil
+	},
+	Resume: func(s savegame.Save) (tea.Model, error) {
+		var g testGame
+		err := s.Decode(1, &g.pings)
+		return g, err
+	},
+}
+
+func press(m Model, key string) (Model, tea.Cmd) {
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+	return next.(Model), cmd
+}
+
+func TestQuittingAGameReturnsToTheMenu(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	m := New()
+	if _, err := m.start(game, registry.Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	m, cmd := press(m, "q")
+	msg := cmd()
+	if _, ok := msg.(endedMsg); !ok {
+		t.Fatalf("expected the game's quit to become endedMsg, got %T", msg)
+	}
+
+	next, _ := m.Update(msg)
+	m = next.(Model)
+	if m.state != finished {
+		t.Fatalf("expected the game to be finished, got state %d", m.state)
+	}
+
+	m, _ = press(m, "x")
+	if m.state != choosing {
+		t.Fatalf("expected to be back at the menu, got state %d", m.state)
+	}
+}
+
+func TestMessagesFromAnOldGameAreDropped(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	m := New()
+	if _, err := m.start(game, registry.Options{}); err != nil {
+		t.Fatal(err)
+	}
+	old := m.session
+
+	if _, err := m.start(game, registry.Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	next, _ := m.Update(sessionMsg{old, pingMsg{}})
+	next, _ = next.Update(sessionMsg{m.session, pingMsg{}})
+	m = next.(Model)
+
+	if pings := m.game.(testGame).pings; pings != 1 {
+		t.Fatalf("expected only the current game's ping, got %d", pings)
+	}
+}
+
+func TestResumeRemovesTheSave(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	if err := savegame.Write(game.Name, 1, 5); err != nil {
+		t.Fatal(err)
+	}
+
+	m := New()
+	if _, err := m.resume(game); err != nil {
+		t.Fatal(err)
+	}
+
+	if pings := m.game.(testGame).pings; pings != 5 {
+		t.Fatalf("expected the saved state to be restored, got %d pings", pings)
+	}
+
+	if _, err := savegame.Read(game.Name); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected the save to be removed, got %v", err)
+	}
+}
+
+func TestDirectGameQuitsProgram(t *testing.T) {
+	m, err := NewWithGame(game, registry.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, cmd := press(m, "q")
+	_, cmd = m.Update(cmd())
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Fatal("expected gg to quit when a game started from the command line ends")
+	}
+}
+
+func TestWrapBatch(t *testing.T) {
+	cmd := wrap(3, tea.Batch(
+		func() tea.Msg { return pingMsg{} },
+		tea.Quit,
+	))
+
+	batch, ok := cmd().(tea.BatchMsg)
+	if !ok {
+		t.Fatal("expected a batch to stay a batch")
+	}
+
+	if msg := batch[0](); msg != (sessionMsg{3, pingMsg{}}) {
+		t.Fatalf("expected a tagged ping, got %#v", msg)
+	}
+
+	if msg := batch[1](); msg != (endedMsg{3}) {
+		t.Fatalf("expected endedMsg, got %#v", msg)
+	}
+}
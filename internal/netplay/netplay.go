@@ -0,0 +1,97 @@
+// Package netplay lets two people play a game over TCP, each at their own
+// terminal: one hosts the game with 'gg host', the other joins it with
+// 'gg join'. Both run the same game, and the host is the authority on it:
+// it decides whether a move is allowed, and in which order the moves and
+// the ticks of a real-time game happen. The guest sends its moves to the
+// host and only plays what the host sends back, so both games stay the
+// same.
+//
+// The protocol is line-delimited JSON, one message per line, each with a
+// type:
+//
+//	hello    guest → host   {"type":"hello","version":1}
+//	welcome  host → guest   the game, its seed and its settings
+//	error    host → guest   why the guest was turned away
+//	move     both ways      a move of a turn-based game, e.g. {"type":"move","player":1,"move":3}
+//	input    both ways      an input of a real-time game, e.g. {"type":"input","player":2,"input":"left","frame":12}
+//	tick     host → guest   a tick of a real-time game, e.g. {"type":"tick","frame":13}
+//	bye      both ways      the player quit
+//
+// The frame of a message is the number of ticks so far, which the guest
+// checks against its own to be sure that nothing was missed. A guest
+// speaking another version of the protocol is turned away.
+package netplay
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Version is the version of the protocol, increased whenever it changes.
+const Version = 1
+
+// Side is one of the two players.
+type Side int
+
+const (
+	// Host is the player hosting the game, who plays first.
+	Host Side = 1
+	// Guest is the player who joined the game.
+	Guest Side = 2
+)
+
+// Action is what a player does: a move of a turn-based game, such as the
+// column a piece of connect 4 is dropped in, or an input of a real-time
+// game, such as "left" for a paddle of pong. Input is empty for a move.
+type Action struct {
+	Move  int
+	Input string
+}
+
+// Model is the model of a game that can be played over the network. The
+// session gives it the actions of both players instead of their keys.
+type Model interface {
+	tea.Model
+	// Action returns the action the player on side takes by pressing key,
+	// if any.
+	Action(side Side, key tea.KeyMsg) (Action, bool)
+	// Play returns the game once the player on side took action, or an
+	// error if they can't take it now, such as a move out of turn.
+	Play(side Side, action Action) (tea.Model, tea.Cmd, error)
+	// Player names what the player on side plays with, e.g. "x".
+	Player(side Side) string
+}
+
+// The types of the messages.
+const (
+	typeHello   = "hello"
+	typeWelcome = "welcome"
+	typeError   = "error"
+	typeMove    = "move"
+	typeInput   = "input"
+	typeTick    = "tick"
+	typeBye     = "bye"
+)
+
+// message is a line of the protocol. Only the fields of its type are set.
+type message struct {
+	Type     string            `json:"type"`
+	Version  int               `json:"version,omitempty"`
+	Game     string            `json:"game,omitempty"`
+	Seed     uint64            `json:"seed,omitempty"`
+	Settings map[string]string `json:"settings,omitempty"`
+	Error    string            `json:"error,omitempty"`
+	Player   Side              `json:"player,omitempty"`
+	Move     int               `json:"move,omitempty"`
+	Input    string            `json:"input,omitempty"`
+	Frame    int               `json:"frame,omitempty"`
+}
+
+// handshakeTimeout is how long either side waits for the other to say
+// hello or welcome.
+const handshakeTimeout = 10 * time.Second
+
+// outgoing is how many messages may wait to be sent to the other player
+// before the game stops, as they no longer read them.
+const outgoing = 256
@@ -0,0 +1,291 @@
+package netplay
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Kaamkiya/gg/internal/registry"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// adder is a game for two players taking turns to add to a sum.
+type adder struct {
+	sum  int
+	turn Side
+}
+
+func init() {
+	registry.Register(registry.Game{
+		Name:      "netplay-adder",
+		Players:   2,
+		Networked: true,
+		Settings: []registry.Setting{
+			{Name: "goal", Default: "100", Check: func(value string) error {
+				if n, err := strconv.Atoi(value); err != nil || n < 1 {
+					return fmt.Errorf("invalid goal %q", value)
+				}
+				return nil
+			}},
+		},
+		New: func(registry.Options) (tea.Model, error) {
+			return adder{turn: Host}, nil
+		},
+	})
+	registry.Register(registry.Game{
+		Name:    "netplay-solo",
+		Players: 1,
+		New: func(registry.Options) (tea.Model, error) {
+			soloBuilt++
+			return adder{turn: Host}, nil
+		},
+	})
+}
+
+// soloBuilt counts the games of netplay-solo built.
+var soloBuilt int
+
+func (a adder) Init() tea.Cmd                       { return nil }
+func (a adder) Update(tea.Msg) (tea.Model, tea.Cmd) { return a, nil }
+func (a adder) View() string                        { return fmt.Sprintf("sum: %d", a.sum) }
+func (a adder) Player(side Side) string             { return fmt.Sprintf("player %d", side) }
+
+func (a adder) Action(_ Side, key tea.KeyMsg) (Action, bool) {
+	return Action{Move: len(key.String())}, key.String() != "q"
+}
+
+func (a adder) Play(side Side, action Action) (tea.Model, tea.Cmd, error) {
+	if side != a.turn {
+		return a, nil, errors.New("out of turn")
+	}
+
+	a.sum += action.Move
+	a.turn = 3 - a.turn
+	return a, nil, nil
+}
+
+// listen returns a listener on the loopback interface.
+func listen(t *testing.T) net.Listener {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	return l
+}
+
+// peer is the other end of a connection, speaking the protocol by hand.
+type peer struct {
+	t     *testing.T
+	conn  net.Conn
+	lines *bufio.Scanner
+}
+
+func (p peer) send(line string) {
+	p.t.Helper()
+
+	if _, err := fmt.Fprintln(p.conn, line); err != nil {
+		p.t.Fatal(err)
+	}
+}
+
+func (p peer) read() message {
+	p.t.Helper()
+
+	if !p.lines.Scan() {
+		p.t.Fatalf("expected a message: %v", p.lines.Err())
+	}
+	var m message
+	if err := json.Unmarshal(p.lines.Bytes(), &m); err != nil {
+		p.t.Fatal(err)
+	}
+
+	return m
+}
+
+func TestAcceptOtherVersion(t *testing.T) {
+	l := listen(t)
+	g, _ := registry.Lookup("netplay-adder")
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := Accept(l, g, g.DefaultOptions())
+		done <- err
+	}()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	guest := peer{t, conn, bufio.NewScanner(conn)}
+
+	guest.send(`{"type":"hello","version":99}`)
+	if m := guest.read(); m.Type != typeError || !strings.Contains(m.Error, "version 99") {
+		t.Errorf("expected the guest to be turned away, got %+v", m)
+	}
+	if err := <-done; err == nil {
+		t.Error("expected Accept to fail")
+	}
+}
+
+func TestAcceptSoloGame(t *testing.T) {
+	g, _ := registry.Lookup("netplay-solo")
+
+	if _, err := Accept(listen(t), g, g.DefaultOptions()); err == nil {
+		t.Fatal("expected an error for a game for one player")
+	}
+}
+
+// host accepts the guest dialing into l by hand, and welcomes them to the
+// game called name.
+func host(t *testing.T, l net.Listener, name string) peer {
+	t.Helper()
+
+	return hostWith(t, l, name, "{}")
+}
+
+// hostWith is host, with the settings of the game written as a JSON object.
+func hostWith(t *testing.T, l net.Listener, name, settings string) peer {
+	t.Helper()
+
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	p := peer{t, conn, bufio.NewScanner(conn)}
+
+	if m := p.read(); m.Type != typeHello || m.Version != Version {
+		t.Fatalf("expected a hello, got %+v", m)
+	}
+	p.send(fmt.Sprintf(`{"type":"welcome","version":%d,"game":%q,"settings":%s}`, Version, name, settings))
+
+	return p
+}
+
+// joinAsync joins the game hosted at l in the background.
+func joinAsync(l net.Listener) <-chan joined {
+	done := make(chan joined, 1)
+	go func() {
+		s, err := Join(l.Addr().String())
+		done <- joined{s, err}
+	}()
+
+	return done
+}
+
+type joined struct {
+	s   *Session
+	err error
+}
+
+func TestJoinUnknownGame(t *testing.T) {
+	l := listen(t)
+	done := joinAsync(l)
+	host(t, l, "netplay-missing")
+
+	if j := <-done; j.err == nil || !strings.Contains(j.err.Error(), "netplay-missing") {
+		t.Fatalf("expected an error for an unknown game, got %v", j.err)
+	}
+}
+
+func TestJoinSoloGame(t *testing.T) {
+	l := listen(t)
+	done := joinAsync(l)
+	host(t, l, "netplay-solo")
+
+	if j := <-done; j.err == nil {
+		t.Fatal("expected an error for a game for one player")
+	}
+	if soloBuilt != 0 {
+		t.Fatal("expected the game to be turned down before it is built")
+	}
+}
+
+func TestJoinUnknownSetting(t *testing.T) {
+	l := listen(t)
+	done := joinAsync(l)
+	hostWith(t, l, "netplay-adder", `{"size":"100000x100000"}`)
+
+	if j := <-done; j.err == nil || !strings.Contains(j.err.Error(), "size") {
+		t.Fatalf("expected an error for a setting the game doesn't have, got %v", j.err)
+	}
+}
+
+func TestGuestFollowsHost(t *testing.T) {
+	l := listen(t)
+	done := joinAsync(l)
+	h := host(t, l, "netplay-adder")
+
+	j := <-done
+	if j.err != nil {
+		t.Fatal(j.err)
+	}
+	s := j.s
+	defer s.Close()
+
+	// The guest's moves go to the host, and are only played once the host
+	// sends them back.
+	s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("ab")})
+	if m := h.read(); m.Type != typeMove || m.Move != 2 {
+		t.Fatalf("expected the guest's move, got %+v", m)
+	}
+	if view := s.Game().View(); view != "sum: 0" {
+		t.Fatalf("expected the move to wait for the host, got %q", view)
+	}
+
+	for _, m := range []message{
+		{Type: typeMove, Player: Host, Move: 5},
+		{Type: typeMove, Player: Guest, Move: 2},
+	} {
+		s.Update(receivedMsg{m})
+	}
+	if view := s.Game().View(); view != "sum: 7" {
+		t.Fatalf("expected the host's moves to be played, got %q", view)
+	}
+
+	// A tick the guest didn't expect means the games no longer match.
+	s.Update(receivedMsg{message{Type: typeTick, Frame: 3}})
+	if view := s.View(); !strings.Contains(view, "Error: out of sync") {
+		t.Fatalf("expected the guest to be out of sync:\n%s", view)
+	}
+}
+
+func TestCloseSendsWhatIsLeft(t *testing.T) {
+	l := listen(t)
+	done := joinAsync(l)
+	h := host(t, l, "netplay-adder")
+
+	j := <-done
+	if j.err != nil {
+		t.Fatal(j.err)
+	}
+
+	j.s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	if err := j.s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if m := h.read(); m.Type != typeBye {
+		t.Fatalf("expected the guest to say bye, got %+v", m)
+	}
+}
+
+func TestJoinInvalidSetting(t *testing.T) {
+	l := listen(t)
+	done := joinAsync(l)
+	hostWith(t, l, "netplay-adder", `{"goal":"-1"}`)
+
+	if j := <-done; j.err == nil || !strings.Contains(j.err.Error(), "goal") {
+		t.Fatalf("expected an error for a setting out of range, got %v", j.err)
+	}
+}
@@ -0,0 +1,484 @@
+package netplay
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/Kaamkiya/gg/internal/clock"
+	"github.com/Kaamkiya/gg/internal/keymap"
+	"github.com/Kaamkiya/gg/internal/registry"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Session runs one side of a game played over the network.
+type Session struct {
+	side  Side
+	game  Model
+	conn  net.Conn
+	lines *bufio.Scanner
+	enc   *json.Encoder
+	keys  *keymap.Map
+
+	// out are the messages waiting to be sent, written in the background
+	// so that Update never waits on the network. The writer reports the
+	// error it stopped on to failed, and closes written once it stopped.
+	out     chan message
+	failed  chan error
+	written chan struct{}
+
+	// ticks are the ticks the guest's game waits for, fired when the host
+	// sends them. frame counts the ticks so far on either side.
+	ticks *remoteClock
+	frame int
+
+	// over is set once the other player left or the connection broke, and
+	// left if they left.
+	over bool
+	left bool
+	err  error
+}
+
+// receivedMsg is a message from the other player.
+type receivedMsg struct{ m message }
+
+// disconnectedMsg tells that reading from the other player failed.
+type disconnectedMsg struct{ err error }
+
+// tickMsg is a tick of the host's game, to be sent to the guest before the
+// game gets msg.
+type tickMsg struct{ msg tea.Msg }
+
+// Accept waits on l for a player to join a game of g started with opts,
+// and returns the host's session once they did.
+func Accept(l net.Listener, g registry.Game, opts registry.Options) (*Session, error) {
+	opts.Clock = hostClock{opts.Ticker()}
+	game, err := newGame(g, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := l.Accept()
+	if err != nil {
+		return nil, err
+	}
+	s := newSession(Host, g.Name, game, conn)
+
+	hello, err := s.handshake()
+	switch {
+	case err != nil:
+	case hello.Type != typeHello:
+		err = fmt.Errorf("expected a hello from the other player, got %q", hello.Type)
+	case hello.Version != Version:
+		err = fmt.Errorf("the other player's gg speaks version %d of the protocol, this one %d", hello.Version, Version)
+	}
+	if err == nil {
+		err = s.enc.Encode(message{
+			Type:     typeWelcome,
+			Version:  Version,
+			Game:     g.Name,
+			Seed:     opts.Seed,
+			Settings: opts.Settings,
+		})
+	}
+	if err != nil {
+		s.enc.Encode(message{Type: typeError, Error: err.Error()})
+		conn.Close()
+		return nil, err
+	}
+	go s.write()
+
+	return s, nil
+}
+
+// Join joins the game hosted at addr, written as host:port, and returns
+// the guest's session.
+func Join(addr string) (*Session, error) {
+	conn, err := net.DialTimeout("tcp", addr, handshakeTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := join(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func join(conn net.Conn) (*Session, error) {
+	s := newSession(Guest, "", nil, conn)
+	if err := s.enc.Encode(message{Type: typeHello, Version: Version}); err != nil {
+		return nil, err
+	}
+
+	welcome, err := s.handshake()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case welcome.Type == typeError:
+		return nil, fmt.Errorf("the host turned us away: %s", welcome.Error)
+	case welcome.Type != typeWelcome:
+		return nil, fmt.Errorf("expected a welcome from the host, got %q", welcome.Type)
+	case welcome.Version != Version:
+		return nil, fmt.Errorf("the host's gg speaks version %d of the protocol, this one %d", welcome.Version, Version)
+	}
+
+	g, ok := registry.Lookup(welcome.Game)
+	if !ok {
+		return nil, fmt.Errorf("the host plays %q, a game this gg doesn't have", welcome.Game)
+	}
+	// The host picks the game and its settings, so both are checked before
+	// the game is built.
+	if !Playable(g) {
+		return nil, fmt.Errorf("the host plays %s, which can't be played over the network", g.Name)
+	}
+	if err := g.CheckSettings(welcome.Settings); err != nil {
+		return nil, fmt.Errorf("the host's settings: %w", err)
+	}
+
+	s.ticks = &remoteClock{}
+	opts := g.DefaultOptions()
+	opts.Seed = welcome.Seed
+	opts.Clock = s.ticks
+	for name, value := range welcome.Settings {
+		opts.Settings[name] = value
+	}
+
+	s.game, err = newGame(g, opts)
+	if err != nil {
+		return nil, err
+	}
+	s.keys = keymap.New(g.Name, keymap.Quit)
+	go s.write()
+
+	return s, nil
+}
+
+func newSession(side Side, name string, game Model, conn net.Conn) *Session {
+	return &Session{
+		side:  side,
+		game:  game,
+		conn:  conn,
+		lines: bufio.NewScanner(conn),
+		enc:   json.NewEncoder(conn),
+		keys:  keymap.New(name, keymap.Quit),
+
+		out:     make(chan message, outgoing),
+		failed:  make(chan error, 1),
+		written: make(chan struct{}),
+	}
+}
+
+// newGame starts a game of g, which must be one for two players that can
+// be played over the network.
+func newGame(g registry.Game, opts registry.Options) (Model, error) {
+	if !Playable(g) {
+		return nil, fmt.Errorf("%s can't be played over the network", g.Name)
+	}
+
+	m, err := g.New(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	game, ok := m.(Model)
+	if !ok {
+		return nil, fmt.Errorf("%s can't be played over the network", g.Name)
+	}
+
+	return game, nil
+}
+
+// Playable reports whether g can be played over the network.
+func Playable(g registry.Game) bool {
+	return g.Networked
+}
+
+// handshake reads the first message of the other player, waiting for it
+// no longer than handshakeTimeout.
+func (s *Session) handshake() (message, error) {
+	s.conn.SetReadDeadline(time.Now().Add(handshakeTimeout))
+	defer s.conn.SetReadDeadline(time.Time{})
+
+	return s.read()
+}
+
+// read reads the next message of the other player.
+func (s *Session) read() (message, error) {
+	if !s.lines.Scan() {
+		if err := s.lines.Err(); err != nil {
+			return message{}, err
+		}
+		return message{}, io.EOF
+	}
+
+	var m message
+	if err := json.Unmarshal(s.lines.Bytes(), &m); err != nil {
+		return message{}, fmt.Errorf("invalid message: %w", err)
+	}
+
+	return m, nil
+}
+
+// Run reads the messages of the other player in the background and gives
+// them to send, usually the Send method of the program running the
+// session, until the connection is closed.
+func (s *Session) Run(send func(tea.Msg)) {
+	go func() {
+		for {
+			m, err := s.read()
+			if err != nil {
+				send(disconnectedMsg{err})
+				return
+			}
+			send(receivedMsg{m})
+		}
+	}()
+	go func() {
+		if err, ok := <-s.failed; ok {
+			send(disconnectedMsg{err})
+		}
+	}()
+}
+
+// write sends the messages queued by send until Close, or until it fails.
+func (s *Session) write() {
+	defer close(s.written)
+	defer close(s.failed)
+
+	for m := range s.out {
+		if err := s.enc.Encode(m); err != nil {
+			s.failed <- err
+			// The rest can't be sent, but Close waits for them.
+			for range s.out {
+			}
+			return
+		}
+	}
+}
+
+// Close sends what is left to the other player, waiting no longer than
+// handshakeTimeout, and closes the connection.
+func (s *Session) Close() error {
+	close(s.out)
+	s.conn.SetWriteDeadline(time.Now().Add(handshakeTimeout))
+	<-s.written
+
+	return s.conn.Close()
+}
+
+// Game returns the model of the game being played.
+func (s *Session) Game() tea.Model {
+	return s.game
+}
+
+func (s *Session) Init() tea.Cmd {
+	return s.game.Init()
+}
+
+func (s *Session) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if s.keys.Matches(msg, "quit") {
+			if !s.over {
+				s.send(message{Type: typeBye})
+			}
+			return s, tea.Quit
+		}
+
+		action, ok := s.game.Action(s.side, msg)
+		if !ok || s.over {
+			return s, nil
+		}
+		if s.side == Guest {
+			s.send(s.message(Guest, action))
+			return s, nil
+		}
+		// A move the host can't make is ignored, as it would be if both
+		// players shared a keyboard.
+		cmd, _ := s.play(Host, action)
+		return s, cmd
+
+	case tickMsg:
+		s.frame++
+		s.send(message{Type: typeTick, Frame: s.frame})
+		return s.update(msg.msg)
+
+	case receivedMsg:
+		return s, s.receive(msg.m)
+
+	case disconnectedMsg:
+		if !s.over {
+			s.over = true
+			if errors.Is(msg.err, io.EOF) {
+				s.left = true
+			} else {
+				s.err = fmt.Errorf("lost the connection: %w", msg.err)
+			}
+		}
+		return s, nil
+	}
+
+	return s.update(msg)
+}
+
+func (s *Session) View() string {
+	v := s.game.View()
+	v += fmt.Sprintf("\nyou play %s against %s\n", s.game.Player(s.side), s.conn.RemoteAddr())
+
+	switch {
+	case s.left:
+		v += "the other player left, press q to quit\n"
+	case s.err != nil:
+		v += fmt.Sprintf("Error: %v\n", s.err)
+	}
+
+	return v
+}
+
+// receive handles a message of the other player.
+func (s *Session) receive(m message) tea.Cmd {
+	switch m.Type {
+	case typeBye:
+		s.over, s.left = true, true
+
+	case typeMove, typeInput:
+		action := Action{Move: m.Move, Input: m.Input}
+		if s.side == Host {
+			// The host has the last word: an action the guest can't take
+			// is dropped.
+			cmd, _ := s.play(Guest, action)
+			return cmd
+		}
+
+		if m.Player != Host && m.Player != Guest {
+			return s.desync(fmt.Errorf("unknown player %d", m.Player))
+		}
+		if m.Type == typeInput && m.Frame != s.frame {
+			return s.desync(fmt.Errorf("got an input of frame %d in frame %d", m.Frame, s.frame))
+		}
+		cmd, err := s.play(m.Player, action)
+		if err != nil {
+			return s.desync(err)
+		}
+		return cmd
+
+	case typeTick:
+		if s.side == Host {
+			return nil
+		}
+
+		s.frame++
+		if m.Frame != s.frame {
+			return s.desync(fmt.Errorf("got tick %d in frame %d", m.Frame, s.frame))
+		}
+		if fn, ok := s.ticks.next(); ok {
+			_, cmd := s.update(fn(time.Now()))
+			return cmd
+		}
+	}
+
+	return nil
+}
+
+// play makes the player on side take action. The host sends every action
+// it allows to the guest.
+func (s *Session) play(side Side, action Action) (tea.Cmd, error) {
+	game, cmd, err := s.game.Play(side, action)
+	if err != nil {
+		return nil, err
+	}
+	s.game = game.(Model)
+
+	if s.side == Host {
+		s.send(s.message(side, action))
+	}
+
+	return cmd, nil
+}
+
+// message returns the message telling that the player on side took action.
+func (s *Session) message(side Side, action Action) message {
+	if action.Input != "" {
+		return message{Type: typeInput, Player: side, Input: action.Input, Frame: s.frame}
+	}
+
+	return message{Type: typeMove, Player: side, Move: action.Move}
+}
+
+// update gives msg to the game.
+func (s *Session) update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	game, cmd := s.game.Update(msg)
+	s.game = game.(Model)
+
+	return s, cmd
+}
+
+// send queues m for the other player. The game stops once it can't, or
+// once writing fails.
+func (s *Session) send(m message) {
+	if s.over {
+		return
+	}
+
+	select {
+	case s.out <- m:
+	default:
+		s.over = true
+		s.err = errors.New("lost the connection: the other player stopped reading")
+	}
+}
+
+// desync stops a guest whose game no longer matches the host's.
+func (s *Session) desync(err error) tea.Cmd {
+	s.over = true
+	s.err = fmt.Errorf("out of sync with the host: %w", err)
+
+	return nil
+}
+
+// hostClock ticks on the host's clock, and marks the ticks so that the
+// host sends them to the guest.
+type hostClock struct {
+	clock clock.Clock
+}
+
+func (c hostClock) Tick(d time.Duration, fn func(time.Time) tea.Msg) tea.Cmd {
+	return c.clock.Tick(d, func(t time.Time) tea.Msg {
+		return tickMsg{fn(t)}
+	})
+}
+
+// remoteClock keeps the ticks of the guest's game until the host sends
+// them. Games wait for one tick at a time, so the tick the host sends is
+// always the oldest one waiting.
+type remoteClock struct {
+	waiting []func(time.Time) tea.Msg
+}
+
+// Tick keeps fn right away, as games call it from Update, and returns no
+// command.
+func (c *remoteClock) Tick(_ time.Duration, fn func(time.Time) tea.Msg) tea.Cmd {
+	c.waiting = append(c.waiting, fn)
+	return nil
+}
+
+// next removes the oldest tick waiting and returns it.
+func (c *remoteClock) next() (func(time.Time) tea.Msg, bool) {
+	if len(c.waiting) == 0 {
+		return nil, false
+	}
+
+	fn := c.waiting[0]
+	c.waiting = c.waiting[1:]
+	return fn, true
+}
@@ -0,0 +1,208 @@
+// Package registry keeps the list of games gg knows about. Every game
+// registers itself from an init function, and the menu and the command line
+// are both built from that single list.
+package registry
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Kaamkiya/gg/internal/clock"
+	"github.com/Kaamkiya/gg/internal/rng"
+	"github.com/Kaamkiya/gg/internal/savegame"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Game describes a playable game.
+type Game struct {
+	// Name identifies the game and must be unique, e.g. "tictactoe-ai".
+	Name string
+	// Title is the human readable name shown in menus, e.g. "tictactoe (vs AI)".
+	Title string
+	// Description is a short, one line summary of the game.
+	Description string
+	// Players is the number of people needed at the keyboard.
+	Players int
+	// Networked tells that the two players can play the game at their own
+	// computers, its model being a netplay.Model.
+	Networked bool
+	// Settings are the game specific options the game understands.
+	Settings []Setting
+	// New creates the game's model, ready to be run.
+	New func(opts Options) (tea.Model, error)
+	// Resume continues a suspended game from its save. It is nil for games
+	// that can't be suspended.
+	Resume func(s savegame.Save) (tea.Model, error)
+}
+
+// Setting is a game specific option, set on the command line with
+// `gg play <game> --<name> <value>`.
+type Setting struct {
+	Name    string
+	Usage   string
+	Default string
+	// Choices are the values of a setting that takes one of a few, such as
+	// a difficulty. The menu asks for them before the game starts.
+	Choices []string
+	// Wallclock tells that any value but the default makes the game depend
+	// on how fast the computer is, such as a time to think, so that it
+	// can't be recorded and played back the same.
+	Wallclock bool
+	// Check returns an error if value is one the setting doesn't take,
+	// such as a number out of range. It is nil for settings that take any
+	// value, or one of their Choices.
+	Check func(value string) error
+}
+
+// Options are the values a game is started with.
+type Options struct {
+	// Seed seeds the game's random number generator, so the same seed
+	// always gives the same game.
+	Seed uint64
+	// Settings maps every setting of the game to its value.
+	Settings map[string]string
+	// Clock schedules the ticks of real-time games. It is only set by tests;
+	// nil means real time.
+	Clock clock.Clock
+}
+
+// Get returns the value of the named setting.
+func (o Options) Get(name string) string {
+	return o.Settings[name]
+}
+
+// Rand returns a random number generator seeded with o.Seed.
+func (o Options) Rand() *rng.Rand {
+	return rng.New(o.Seed)
+}
+
+// Ticker returns the clock the game's ticks are scheduled with.
+func (o Options) Ticker() clock.Clock {
+	if o.Clock == nil {
+		return clock.Real
+	}
+
+	return o.Clock
+}
+
+// Label returns the text used for the game in menus.
+func (g Game) Label() string {
+	if g.Players > 1 {
+		return fmt.Sprintf("%s (%d player)", g.Title, g.Players)
+	}
+
+	return g.Title
+}
+
+// DefaultOptions returns options with a random seed and every setting at
+// its default value.
+func (g Game) DefaultOptions() Options {
+	opts := Options{
+		Seed:     rand.Uint64(),
+		Settings: make(map[string]string, len(g.Settings)),
+	}
+
+	for _, s := range g.Settings {
+		opts.Settings[s.Name] = s.Default
+	}
+
+	return opts
+}
+
+// CheckSettings returns an error if settings name a setting g doesn't have,
+// or give one of its settings a value it doesn't take.
+func (g Game) CheckSettings(settings map[string]string) error {
+	for name, value := range settings {
+		i := slices.IndexFunc(g.Settings, func(s Setting) bool { return s.Name == name })
+		if i < 0 {
+			return fmt.Errorf("%s has no setting %q", g.Name, name)
+		}
+		if choices := g.Settings[i].Choices; len(choices) > 0 && !slices.Contains(choices, value) {
+			return fmt.Errorf("invalid %s %q, choose one of %s", name, value, strings.Join(choices, ", "))
+		}
+		if check := g.Settings[i].Check; check != nil {
+			if err := check(value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// CheckWith returns a Check for a setting whose values parse parses.
+func CheckWith[T any](parse func(string) (T, error)) func(string) error {
+	return func(value string) error {
+		_, err := parse(value)
+		return err
+	}
+}
+
+// ParseSize parses a size written as WIDTHxHEIGHT, e.g. "40x20".
+func ParseSize(s string) (width, height int, err error) {
+	w, h, ok := strings.Cut(strings.ToLower(s), "x")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid size %q: expected WIDTHxHEIGHT", s)
+	}
+
+	width, err = strconv.Atoi(w)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid size %q: %w", s, err)
+	}
+
+	height, err = strconv.Atoi(h)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid size %q: %w", s, err)
+	}
+
+	return width, height, nil
+}
+
+var games = map[string]Game{}
+
+// Register adds a game to the registry. Registering two games with the same
+// name, or a game without a constructor, is a programming error and panics.
+func Register(g Game) {
+	if g.Name == "" || g.New == nil {
+		panic("registry: game needs a name and a constructor")
+	}
+
+	if g.Networked && g.Players != 2 {
+		panic("registry: networked game needs two players: " + g.Name)
+	}
+
+	if _, ok := games[g.Name]; ok {
+		panic("registry: game registered twice: " + g.Name)
+	}
+
+	games[g.Name] = g
+}
+
+// Lookup returns the game registered under name.
+func Lookup(name string) (Game, bool) {
+	g, ok := games[name]
+	return g, ok
+}
+
+// Games returns every registered game. Single player games come first and
+// each group is sorted by title, so menus have a stable order.
+func Games() []Game {
+	list := make([]Game, 0, len(games))
+	for _, g := range games {
+		list = append(list, g)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Players != list[j].Players {
+			return list[i].Players < list[j].Players
+		}
+		return list[i].Title < list[j].Title
+	})
+
+	return list
+}
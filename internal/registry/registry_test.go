@@ -0,0 +1,118 @@
+package registry
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func newNil(Options) (tea.Model, error) { return nil, nil }
+
+func TestGamesOrder(t *testing.T) {
+	saved := games
+	defer func() { games = saved }()
+	games = map[string]Game{}
+
+	Register(Game{Name: "b", Title: "b", Players: 2, New: newNil})
+	Register(Game{Name: "z", Title: "z", Players: 1, New: newNil})
+	Register(Game{Name: "a", Title: "a", Players: 1, New: newNil})
+
+	got := ""
+	for _, g := range Games() {
+		got += g.Name
+	}
+
+	if got != "azb" {
+		t.Fatalf("expected order azb, got %s", got)
+	}
+}
+
+func TestRegisterTwicePanics(t *testing.T) {
+	saved := games
+	defer func() { games = saved }()
+	games = map[string]Game{}
+
+	Register(Game{Name: "a", New: newNil})
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic when registering a name twice")
+		}
+	}()
+	Register(Game{Name: "a", New: newNil})
+}
+
+func TestLabel(t *testing.T) {
+	g := Game{Title: "pong", Players: 2}
+	if g.Label() != "pong (2 player)" {
+		t.Fatalf("unexpected label %q", g.Label())
+	}
+}
+
+func TestCheckSettings(t *testing.T) {
+	g := Game{Name: "g", Settings: []Setting{
+		{Name: "size", Default: "3", Check: func(value string) error {
+			if n, err := strconv.Atoi(value); err != nil || n < 3 {
+				return fmt.Errorf("invalid size %q", value)
+			}
+			return nil
+		}},
+		{Name: "difficulty", Default: "easy", Choices: []string{"easy", "hard"}},
+	}}
+
+	if err := g.CheckSettings(map[string]string{"size": "5", "difficulty": "hard"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, settings := range []map[string]string{{"levels": "3"}, {"difficulty": "nightmare"}, {"size": "2"}, {"size": "big"}} {
+		if err := g.CheckSettings(settings); err == nil {
+			t.Errorf("expected an error for %v", settings)
+		}
+	}
+}
+
+func TestRegisterSoloNetworkedPanics(t *testing.T) {
+	saved := games
+	defer func() { games = saved }()
+	games = map[string]Game{}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic when a networked game isn't for two players")
+		}
+	}()
+	Register(Game{Name: "a", Players: 1, Networked: true, New: newNil})
+}
+
+func TestParseSize(t *testing.T) {
+	w, h, err := ParseSize("40x20")
+	if err != nil || w != 40 || h != 20 {
+		t.Fatalf("expected 40x20, got %dx%d (%v)", w, h, err)
+	}
+
+	for _, s := range []string{"", "40", "40x", "ax20", "40x20x3"} {
+		if _, _, err := ParseSize(s); err == nil {
+			t.Errorf("expected an error for %q", s)
+		}
+	}
+}
+
+func TestDefaultOptions(t *testing.T) {
+	g := Game{Settings: []Setting{{Name: "size", Default: "20x20"}}}
+
+	if got := g.DefaultOptions().Get("size"); got != "20x20" {
+		t.Fatalf("expected the default size, got %q", got)
+	}
+}
+
+func TestSameSeedSameRand(t *testing.T) {
+	a := Options{Seed: 42}.Rand()
+	b := Options{Seed: 42}.Rand()
+
+	for range 10 {
+		if a.Uint64() != b.Uint64() {
+			t.Fatal("expected the same seed to give the same numbers")
+		}
+	}
+}
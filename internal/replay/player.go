@@ -0,0 +1,195 @@
+package replay
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Kaamkiya/gg/internal/registry"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Player plays a replay back. It runs a new game with the recorded seed and
+// settings, and sends it the recorded key presses and window sizes at the
+// time they happened. The game's commands run as usual, but their messages
+// are held back and delivered in the order they were recorded in, so the
+// game sees the exact same sequence of messages.
+type Player struct {
+	game   tea.Model
+	title  string
+	events []Event
+	// msgs holds the key presses and window sizes of the events, ready to
+	// be sent. It is nil for the messages of the game's commands.
+	msgs []tea.Msg
+	next int
+
+	start time.Time
+	// pending holds the messages of the game's commands that are waiting
+	// for their turn.
+	pending []tea.Msg
+	// due is set when the next event is late, because the command that
+	// produces its message hasn't finished yet.
+	due bool
+}
+
+// resultMsg is a message produced by one of the game's commands.
+type resultMsg struct {
+	msg tea.Msg
+}
+
+// stepMsg is sent when the next event is due.
+type stepMsg struct{}
+
+// NewPlayer starts a new game for rep and returns the player running it.
+func NewPlayer(rep *Replay) (*Player, error) {
+	g, ok := registry.Lookup(rep.Game)
+	if !ok {
+		return nil, fmt.Errorf("the replay is of an unknown game %q", rep.Game)
+	}
+
+	msgs := make([]tea.Msg, len(rep.Events))
+	for i, e := range rep.Events {
+		switch {
+		case e.Key != nil:
+			key, err := e.Key.Msg()
+			if err != nil {
+				return nil, err
+			}
+			msgs[i] = key
+		case e.Size != nil:
+			msgs[i] = tea.WindowSizeMsg{Width: e.Size.Width, Height: e.Size.Height}
+		case e.Msg == "":
+			return nil, fmt.Errorf("event %d of the replay is empty", i+1)
+		}
+	}
+
+	game, err := g.New(rep.Options())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Player{
+		game:   game,
+		title:  g.Title,
+		events: rep.Events,
+		msgs:   msgs,
+		start:  time.Now(),
+	}, nil
+}
+
+func (p *Player) Init() tea.Cmd {
+	p.start = time.Now()
+	return tea.Batch(wrap(p.game.Init()), p.schedule())
+}
+
+func (p *Player) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case resultMsg:
+		p.pending = append(p.pending, msg.msg)
+		if p.due {
+			return p, p.advance()
+		}
+	case stepMsg:
+		p.due = true
+		return p, p.advance()
+	case tea.KeyMsg:
+		// The keys pressed while watching aren't part of the game.
+		if msg.String() == "q" {
+			return p, tea.Quit
+		}
+	}
+
+	return p, nil
+}
+
+func (p *Player) View() string {
+	status := fmt.Sprintf("replay of %s, q to stop", p.title)
+	if p.next == len(p.events) {
+		status = fmt.Sprintf("end of the replay of %s, q to leave", p.title)
+	}
+
+	return p.game.View() + "\n\n" + status + "\n"
+}
+
+// advance sends the game every event that is due, and schedules the next
+// one. It stops early when the message of an event hasn't arrived yet; it
+// is sent as soon as it does.
+func (p *Player) advance() tea.Cmd {
+	var cmds []tea.Cmd
+
+	for p.next < len(p.events) {
+		if p.events[p.next].T > time.Since(p.start) {
+			p.due = false
+			cmds = append(cmds, p.schedule())
+			break
+		}
+
+		msg, ok := p.message(p.next)
+		if !ok {
+			break
+		}
+		p.next++
+
+		game, cmd := p.game.Update(msg)
+		p.game = game
+		cmds = append(cmds, wrap(cmd))
+	}
+
+	return tea.Batch(cmds...)
+}
+
+// message returns the message of the i-th event, if it is available.
+func (p *Player) message(i int) (tea.Msg, bool) {
+	if p.msgs[i] != nil {
+		return p.msgs[i], true
+	}
+
+	for j, msg := range p.pending {
+		if typeName(msg) == p.events[i].Msg {
+			p.pending = append(p.pending[:j], p.pending[j+1:]...)
+			return msg, true
+		}
+	}
+
+	return nil, false
+}
+
+// schedule waits for the next event.
+func (p *Player) schedule() tea.Cmd {
+	if p.next == len(p.events) {
+		return nil
+	}
+
+	return tea.Tick(p.events[p.next].T-time.Since(p.start), func(time.Time) tea.Msg {
+		return stepMsg{}
+	})
+}
+
+// wrap holds back the messages of a game's command until the player sends
+// them. Bubble Tea's own messages, such as tea.Quit, go straight through.
+func wrap(cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+
+	return func() tea.Msg {
+		msg := cmd()
+
+		switch msg := msg.(type) {
+		case nil:
+			return nil
+		case tea.BatchMsg:
+			batch := make(tea.BatchMsg, len(msg))
+			for i, cmd := range msg {
+				batch[i] = wrap(cmd)
+			}
+			return batch
+		}
+
+		if isTeaMsg(msg) {
+			return msg
+		}
+
+		return resultMsg{msg}
+	}
+}
@@ -0,0 +1,100 @@
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Kaamkiya/gg/internal/registry"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Recorder runs a game and writes everything it receives to a replay.
+type Recorder struct {
+	game  tea.Model
+	enc   *json.Encoder
+	start time.Time
+	err   error
+}
+
+// NewRecorder starts a new game of g and records it to w. Games whose
+// settings make them depend on how fast the computer is can't be played
+// back the same, and aren't recorded.
+func NewRecorder(w io.Writer, g registry.Game, opts registry.Options) (*Recorder, error) {
+	for _, s := range g.Settings {
+		if s.Wallclock && opts.Get(s.Name) != s.Default {
+			return nil, fmt.Errorf("%s can't be recorded with %s %s, as it wouldn't play back the same", g.Name, s.Name, opts.Get(s.Name))
+		}
+	}
+
+	game, err := g.New(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Recorder{
+		game:  game,
+		enc:   json.NewEncoder(w),
+		start: time.Now(),
+	}
+
+	err = r.enc.Encode(Header{
+		Version:  Version,
+		Game:     g.Name,
+		Seed:     opts.Seed,
+		Settings: opts.Settings,
+		Recorded: r.start,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *Recorder) Init() tea.Cmd {
+	r.start = time.Now()
+	return r.game.Init()
+}
+
+func (r *Recorder) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	r.record(msg)
+
+	game, cmd := r.game.Update(msg)
+	r.game = game
+
+	return r, cmd
+}
+
+func (r *Recorder) View() string {
+	if r.err != nil {
+		return r.game.View() + fmt.Sprintf("\nCould not record the replay: %v\n", r.err)
+	}
+
+	return r.game.View()
+}
+
+// record writes msg to the replay. Once writing failed, the game carries on
+// unrecorded.
+func (r *Recorder) record(msg tea.Msg) {
+	if r.err != nil || msg == nil {
+		return
+	}
+
+	e := Event{T: time.Since(r.start)}
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		e.Key = newKey(msg)
+	case tea.WindowSizeMsg:
+		e.Size = &Size{msg.Width, msg.Height}
+	default:
+		if isTeaMsg(msg) {
+			return
+		}
+		e.Msg = typeName(msg)
+	}
+
+	r.err = r.enc.Encode(e)
+}
@@ -0,0 +1,153 @@
+// Package replay records games and plays them back. A replay holds the seed
+// and settings a game was started with, followed by every message the game
+// received: key presses and window sizes as they were, and the messages of
+// the game's own commands, such as ticks, by their type. Games are
+// deterministic for a given seed, so feeding the same messages to a new game
+// in the same order plays it exactly as it was.
+//
+// Replays are stored as JSON lines: a header, then one event per line. The
+// file is written as the game goes, so a game that crashes still leaves a
+// replay behind.
+package replay
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/Kaamkiya/gg/internal/registry"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Version is the version of the replay format.
+const Version = 1
+
+// ErrIncompatible is returned when a replay was written with a format
+// version this gg doesn't understand.
+var ErrIncompatible = errors.New("replay is from an incompatible version of gg")
+
+// Header describes the game that was recorded.
+type Header struct {
+	Version  int               `json:"version"`
+	Game     string            `json:"game"`
+	Seed     uint64            `json:"seed"`
+	Settings map[string]string `json:"settings,omitempty"`
+	Recorded time.Time         `json:"recorded"`
+}
+
+// Options returns the options the game was started with.
+func (h Header) Options() registry.Options {
+	return registry.Options{Seed: h.Seed, Settings: h.Settings}
+}
+
+// Event is a message received by the game, T after it started. Exactly one
+// of Key, Size and Msg is set.
+type Event struct {
+	T    time.Duration `json:"t"`
+	Key  *Key          `json:"key,omitempty"`
+	Size *Size         `json:"size,omitempty"`
+	// Msg is the type of a message produced by one of the game's commands.
+	Msg string `json:"msg,omitempty"`
+}
+
+// Key is a key press.
+type Key struct {
+	Type  string `json:"type"`
+	Runes string `json:"runes,omitempty"`
+	Alt   bool   `json:"alt,omitempty"`
+	Paste bool   `json:"paste,omitempty"`
+}
+
+// Size is the size of the terminal.
+type Size struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// Replay is a recorded game.
+type Replay struct {
+	Header
+	Events []Event
+}
+
+// Load reads a replay.
+func Load(r io.Reader) (*Replay, error) {
+	dec := json.NewDecoder(bufio.NewReader(r))
+
+	var rep Replay
+	if err := dec.Decode(&rep.Header); err != nil {
+		return nil, fmt.Errorf("invalid replay: %w", err)
+	}
+
+	if rep.Version != Version {
+		return nil, fmt.Errorf("%w: got version %d, want %d", ErrIncompatible, rep.Version, Version)
+	}
+
+	for {
+		var e Event
+		err := dec.Decode(&e)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid replay: %w", err)
+		}
+		rep.Events = append(rep.Events, e)
+	}
+
+	return &rep, nil
+}
+
+// keyTypes maps the names of the key types back to the types.
+var keyTypes = func() map[string]tea.KeyType {
+	types := make(map[string]tea.KeyType)
+	for i := -256; i < 256; i++ {
+		if name := tea.KeyType(i).String(); name != "" {
+			types[name] = tea.KeyType(i)
+		}
+	}
+	return types
+}()
+
+func newKey(k tea.KeyMsg) *Key {
+	return &Key{
+		Type:  k.Type.String(),
+		Runes: string(k.Runes),
+		Alt:   k.Alt,
+		Paste: k.Paste,
+	}
+}
+
+// Msg returns the key press.
+func (k Key) Msg() (tea.KeyMsg, error) {
+	t, ok := keyTypes[k.Type]
+	if !ok {
+		return tea.KeyMsg{}, fmt.Errorf("unknown key type %q", k.Type)
+	}
+
+	msg := tea.KeyMsg{Type: t, Alt: k.Alt, Paste: k.Paste}
+	if k.Runes != "" {
+		msg.Runes = []rune(k.Runes)
+	}
+
+	return msg, nil
+}
+
+// typeName names the type of a message produced by a game's command.
+func typeName(msg tea.Msg) string {
+	return fmt.Sprintf("%T", msg)
+}
+
+// teaPackage is the import path of Bubble Tea. Its own messages are meant
+// for the program rather than the game, so they are neither recorded nor
+// held back during playback.
+var teaPackage = reflect.TypeOf(tea.QuitMsg{}).PkgPath()
+
+func isTeaMsg(msg tea.Msg) bool {
+	return reflect.TypeOf(msg).PkgPath() == teaPackage
+}
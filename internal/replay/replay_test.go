@@ -0,0 +1,176 @@
+package replay
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Kaamkiya/gg/internal/registry"
+	"github.com/Kaamkiya/gg/internal/rng"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// testGame logs the keys it receives and a random number on every tick, and
+// quits after a few ticks.
+type testGame struct {
+	rng *rng.Rand
+	log []string
+}
+
+type tickMsg struct{}
+
+func tick() tea.Msg {
+	return tickMsg{}
+}
+
+func (g testGame) Init() tea.Cmd {
+	return tea.Batch(tick, tick)
+}
+
+func (g testGame) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		g.log = append(g.log, msg.String())
+	case tea.WindowSizeMsg:
+		g.log = append(g.log, fmt.Sprintf("%dx%d", msg.Width, msg.Height))
+	case tickMsg:
+		g.log = append(g.log, fmt.Sprint(g.rng.IntN(1000)))
+		if len(g.log) >= 8 {
+			return g, tea.Quit
+		}
+		return g, tick
+	}
+
+	return g, nil
+}
+
+func (g testGame) View() string {
+	return strings.Join(g.log, " ")
+}
+
+var game = registry.Game{
+	Name:  "replay-test",
+	Title: "replay test",
+	New: func(opts registry.Options) (tea.Model, error) {
+		return testGame{rng: opts.Rand()}, nil
+	},
+}
+
+func init() {
+	registry.Register(game)
+}
+
+// drive runs m the way a Bubble Tea program would, until it quits or has
+// nothing left to do. The given messages are sent first, before the
+// messages of any command.
+func drive(m tea.Model, msgs ...tea.Msg) tea.Model {
+	cmds := []tea.Cmd{m.Init()}
+
+	for len(msgs) > 0 || len(cmds) > 0 {
+		var msg tea.Msg
+		if len(msgs) > 0 {
+			msg, msgs = msgs[0], msgs[1:]
+		} else {
+			var cmd tea.Cmd
+			cmd, cmds = cmds[0], cmds[1:]
+			if cmd == nil {
+				continue
+			}
+
+			msg = cmd()
+		}
+
+		switch msg := msg.(type) {
+		case nil:
+			continue
+		case tea.QuitMsg:
+			return m
+		case tea.BatchMsg:
+			cmds = append(cmds, msg...)
+			continue
+		}
+
+		var cmd tea.Cmd
+		m, cmd = m.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+
+	return m
+}
+
+func TestRecordAndPlay(t *testing.T) {
+	opts := registry.Options{Seed: 42, Settings: map[string]string{"size": "10x10"}}
+
+	var buf bytes.Buffer
+	rec, err := NewRecorder(&buf, game, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorded := drive(rec,
+		tea.WindowSizeMsg{Width: 80, Height: 24},
+		tea.KeyMsg{Type: tea.KeyUp},
+		tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x"), Alt: true},
+	).View()
+
+	rep, err := Load(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rep.Game != game.Name || rep.Seed != 42 || rep.Settings["size"] != "10x10" {
+		t.Fatalf("unexpected header %+v", rep.Header)
+	}
+
+	p, err := NewPlayer(rep)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	played := drive(p).(*Player).game.View()
+	if played != recorded {
+		t.Fatalf("expected the replay to show\n%s\ngot\n%s", recorded, played)
+	}
+}
+
+func TestLoadRejectsOtherVersions(t *testing.T) {
+	_, err := Load(strings.NewReader(`{"version": 99, "game": "snake"}`))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestKeysSurviveTheRoundTrip(t *testing.T) {
+	keys := []tea.KeyMsg{
+		{Type: tea.KeyEnter},
+		{Type: tea.KeyCtrlC},
+		{Type: tea.KeyRunes, Runes: []rune("ä")},
+		{Type: tea.KeyLeft, Alt: true},
+	}
+
+	for _, want := range keys {
+		got, err := newKey(want).Msg()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got.String() != want.String() {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	}
+}
+
+func TestRecordWallclock(t *testing.T) {
+	g := game
+	g.Settings = []registry.Setting{{Name: "think", Default: "0", Wallclock: true}}
+
+	var buf bytes.Buffer
+	if _, err := NewRecorder(&buf, g, registry.Options{Settings: map[string]string{"think": "0"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewRecorder(&buf, g, registry.Options{Settings: map[string]string{"think": "1s"}}); err == nil {
+		t.Fatal("expected a game thinking for a time not to be recorded")
+	}
+}
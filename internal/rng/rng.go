@@ -0,0 +1,49 @@
+// Package rng provides the random number generator used by the games. It is
+// a math/rand/v2 generator whose state can be saved and restored, so a
+// suspended game continues with exactly the same random numbers.
+package rng
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+)
+
+// Rand is a seeded random number generator.
+type Rand struct {
+	*rand.Rand
+	src *rand.PCG
+}
+
+// New returns a generator seeded with seed. The same seed always gives the
+// same numbers.
+func New(seed uint64) *Rand {
+	src := rand.NewPCG(seed, seed)
+	return &Rand{rand.New(src), src}
+}
+
+// MarshalJSON encodes the current state of the generator.
+func (r *Rand) MarshalJSON() ([]byte, error) {
+	state, err := r.src.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(state)
+}
+
+// UnmarshalJSON restores a state encoded by MarshalJSON.
+func (r *Rand) UnmarshalJSON(data []byte) error {
+	var state []byte
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+
+	src := &rand.PCG{}
+	if err := src.UnmarshalBinary(state); err != nil {
+		return err
+	}
+
+	r.Rand = rand.New(src)
+	r.src = src
+	return nil
+}
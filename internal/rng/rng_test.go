@@ -0,0 +1,37 @@
+package rng
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSameSeedSameNumbers(t *testing.T) {
+	a, b := New(42), New(42)
+
+	for range 10 {
+		if a.Uint64() != b.Uint64() {
+			t.Fatal("expected the same seed to give the same numbers")
+		}
+	}
+}
+
+func TestRestoredStateContinues(t *testing.T) {
+	r := New(7)
+	r.IntN(100)
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var restored Rand
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatal(err)
+	}
+
+	for range 10 {
+		if r.Uint64() != restored.Uint64() {
+			t.Fatal("expected the restored generator to continue where the original was")
+		}
+	}
+}
@@ -0,0 +1,174 @@
+// Package savegame stores suspended games so they can be continued later.
+// Each game has at most one save, a JSON file in the saves directory under
+// the data directory. The state inside is versioned by the game itself, so
+// a game can refuse, or convert, saves written by an older gg.
+package savegame
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Kaamkiya/gg/internal/xdg"
+)
+
+// Save is a suspended game.
+type Save struct {
+	Game    string          `json:"game"`
+	Version int             `json:"version"`
+	Saved   time.Time       `json:"saved"`
+	State   json.RawMessage `json:"state"`
+}
+
+// ErrIncompatible is returned when a save was written with a state version
+// the game doesn't understand.
+var ErrIncompatible = errors.New("save is from an incompatible version of gg")
+
+// Decode unmarshals the state into v, which must be of the given version.
+func (s Save) Decode(version int, v any) error {
+	if s.Version != version {
+		return fmt.Errorf("%w: got version %d, want %d", ErrIncompatible, s.Version, version)
+	}
+
+	return json.Unmarshal(s.State, v)
+}
+
+// Dir returns the directory the saves are kept in.
+func Dir() (string, error) {
+	dir, err := xdg.DataDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "saves"), nil
+}
+
+func path(game string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, game+".json"), nil
+}
+
+// Write saves the state of game, replacing any earlier save of that game.
+func Write(game string, version int, state any) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	data, err = json.MarshalIndent(Save{
+		Game:    game,
+		Version: version,
+		Saved:   time.Now(),
+		State:   data,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	p, err := path(game)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so a crash never leaves half a save.
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".save-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), p)
+}
+
+// Read returns the save of game.
+func Read(game string) (Save, error) {
+	p, err := path(game)
+	if err != nil {
+		return Save{}, err
+	}
+
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return Save{}, err
+	}
+
+	var s Save
+	if err := json.Unmarshal(data, &s); err != nil {
+		return Save{}, fmt.Errorf("reading save of %s: %w", game, err)
+	}
+
+	return s, nil
+}
+
+// Remove deletes the save of game. Removing a save that doesn't exist is not
+// an error.
+func Remove(game string) error {
+	p, err := path(game)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// List returns every save, the most recent first. Saves that can't be read
+// are skipped.
+func List() ([]Save, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var saves []Save
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || strings.HasPrefix(name, ".") {
+			continue
+		}
+
+		if s, err := Read(name); err == nil {
+			saves = append(saves, s)
+		}
+	}
+
+	sort.Slice(saves, func(i, j int) bool {
+		return saves[i].Saved.After(saves[j].Saved)
+	})
+
+	return saves, nil
+}
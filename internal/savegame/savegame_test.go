@@ -0,0 +1,83 @@
+package savegame
+
+import (
+	"errors"
+	"testing"
+)
+
+type state struct {
+	Grid  [2][2]int
+	Score int
+}
+
+func TestWriteReadRemove(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	want := state{Grid: [2][2]int{{2, 4}, {0, 8}}, Score: 12}
+	if err := Write("twenty48", 1, want); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Read("twenty48")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got state
+	if err := s.Decode(1, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got != want {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	if err := Remove("twenty48"); err != nil {
+		t.Fatal(err)
+	}
+
+	saves, err := List()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(saves) != 0 {
+		t.Fatalf("expected no saves left, got %d", len(saves))
+	}
+}
+
+func TestDecodeChecksVersion(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	if err := Write("sudoku", 1, state{}); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Read("sudoku")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Decode(2, &state{}); !errors.Is(err, ErrIncompatible) {
+		t.Fatalf("expected ErrIncompatible, got %v", err)
+	}
+}
+
+func TestListNewestFirst(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	for _, game := range []string{"sudoku", "tetris", "connect4"} {
+		if err := Write(game, 1, state{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	saves, err := List()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(saves) != 3 || saves[0].Game != "connect4" {
+		t.Fatalf("expected connect4 to be the newest of 3 saves, got %v", saves)
+	}
+}
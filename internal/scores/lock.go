@@ -0,0 +1,94 @@
+package scores
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	// lockTimeout is how long to wait for another gg process to finish
+	// writing before giving up.
+	lockTimeout = 5 * time.Second
+	// staleLockAge is the age after which a lock is considered left behind
+	// by a process that crashed, and removed.
+	staleLockAge = 10 * time.Second
+)
+
+// lock takes an exclusive lock by creating path, waiting while another
+// process holds it. The lock holds a token of its own, so that the returned
+// function only releases it if it is still ours.
+func lock(path string) (func(), error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(lockTimeout)
+
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			_, err = f.WriteString(token)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(path)
+				return nil, err
+			}
+			return func() { unlock(path, token) }, nil
+		}
+
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			breakLock(path, token)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for %s", path)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// breakLock removes the stale lock at path. Another process may have taken
+// the lock since it was found stale, so the lock is moved aside first, and
+// given back if it turns out to be a fresh one.
+func breakLock(path, token string) {
+	aside := path + "." + token
+	if err := os.Rename(path, aside); err != nil {
+		return
+	}
+
+	if info, err := os.Stat(aside); err == nil && time.Since(info.ModTime()) <= staleLockAge {
+		os.Link(aside, path)
+	}
+	os.Remove(aside)
+}
+
+// unlock releases the lock at path if it still holds token. A lock held for
+// longer than staleLockAge may have been broken and taken by another
+// process, whose lock is left alone.
+func unlock(path, token string) {
+	if held, err := os.ReadFile(path); err == nil && string(held) == token {
+		os.Remove(path)
+	}
+}
+
+// newToken returns a random token telling the locks of processes apart.
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
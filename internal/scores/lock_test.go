@@ -0,0 +1,60 @@
+package scores
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBreakStaleLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scores.json.lock")
+	if err := os.WriteFile(path, []byte("crashed"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * staleLockAge)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	unlock, err := lock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected the lock to be released, got %v", err)
+	}
+}
+
+func TestBreakLockGivesBackAFreshOne(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scores.json.lock")
+	if err := os.WriteFile(path, []byte("other"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The lock was found stale, but another process took it since.
+	breakLock(path, "ours")
+	if held, err := os.ReadFile(path); err != nil || string(held) != "other" {
+		t.Fatalf("expected the other process to keep its lock, got %q (%v)", held, err)
+	}
+}
+
+func TestUnlockLeavesOthersLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scores.json.lock")
+
+	unlock, err := lock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Our lock was broken as stale, and another process took it.
+	if err := os.WriteFile(path, []byte("other"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	unlock()
+	if held, err := os.ReadFile(path); err != nil || string(held) != "other" {
+		t.Fatalf("expected the other process to keep its lock, got %q (%v)", held, err)
+	}
+}
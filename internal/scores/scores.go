@@ -0,0 +1,215 @@
+// Package scores keeps the high scores and statistics of every game in a
+// JSON file under the data directory. Several gg processes can record
+// results at the same time: writers take a lock file and replace the scores
+// file atomically, so readers never see a half written file.
+package scores
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/Kaamkiya/gg/internal/xdg"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// MaxBest is the number of results kept on each game's leaderboard.
+const MaxBest = 10
+
+// Result is the outcome of a single game.
+type Result struct {
+	Score    int           `json:"score"`
+	Duration time.Duration `json:"duration"`
+	Seed     uint64        `json:"seed"`
+	Time     time.Time     `json:"time"`
+	// Detail optionally describes the result further, e.g. "3 wins, 1 loss".
+	Detail string `json:"detail,omitempty"`
+}
+
+// Stats are the statistics of one game.
+type Stats struct {
+	Played   int           `json:"played"`
+	Playtime time.Duration `json:"playtime"`
+	// Best holds the highest scores, best first.
+	Best []Result `json:"best"`
+}
+
+type file struct {
+	Version int              `json:"version"`
+	Games   map[string]Stats `json:"games"`
+}
+
+const fileVersion = 1
+
+// Store is a scores file.
+type Store struct {
+	path string
+}
+
+// Open returns the store at path. The file is created on the first write.
+func Open(path string) *Store {
+	return &Store{path}
+}
+
+var defaultStore *Store
+
+// Default returns the store in gg's data directory.
+func Default() (*Store, error) {
+	if defaultStore != nil {
+		return defaultStore, nil
+	}
+
+	dir, err := xdg.DataDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return Open(filepath.Join(dir, "scores.json")), nil
+}
+
+// SetDefault replaces the store returned by Default, e.g. to keep tests from
+// writing to the real data directory.
+func SetDefault(s *Store) {
+	defaultStore = s
+}
+
+// Record adds the result of a finished game to the default store. Games call
+// it when they are over. A zero Time is set to the current time.
+func Record(game string, r Result) error {
+	s, err := Default()
+	if err != nil {
+		return err
+	}
+
+	return s.Record(game, r)
+}
+
+// RecordedMsg tells that the result of a game was recorded, or why it
+// couldn't be.
+type RecordedMsg struct {
+	Err error
+}
+
+// RecordCmd returns a command adding the result of a finished game to the
+// default store, like Record, and answering with a RecordedMsg. Games return
+// it from Update rather than calling Record there, as writing the file may
+// wait on another gg holding its lock.
+func RecordCmd(game string, r Result) tea.Cmd {
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+
+	return func() tea.Msg {
+		return RecordedMsg{Record(game, r)}
+	}
+}
+
+// Record adds the result of a finished game.
+func (s *Store) Record(game string, r Result) error {
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+
+	unlock, err := lock(s.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	f, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	stats := f.Games[game]
+	stats.Played++
+	stats.Playtime += r.Duration
+	stats.Best = append(stats.Best, r)
+	sort.SliceStable(stats.Best, func(i, j int) bool {
+		return stats.Best[i].Score > stats.Best[j].Score
+	})
+	if len(stats.Best) > MaxBest {
+		stats.Best = stats.Best[:MaxBest]
+	}
+	f.Games[game] = stats
+
+	return s.write(f)
+}
+
+// Stats returns the statistics of game. A game that was never played has
+// zero stats.
+func (s *Store) Stats(game string) (Stats, error) {
+	f, err := s.read()
+	if err != nil {
+		return Stats{}, err
+	}
+
+	return f.Games[game], nil
+}
+
+// All returns the statistics of every game that was played.
+func (s *Store) All() (map[string]Stats, error) {
+	f, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+
+	return f.Games, nil
+}
+
+func (s *Store) read() (file, error) {
+	f := file{Version: fileVersion, Games: map[string]Stats{}}
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return f, err
+	}
+
+	if err := json.Unmarshal(data, &f); err != nil {
+		return f, err
+	}
+
+	if f.Games == nil {
+		f.Games = map[string]Stats{}
+	}
+
+	return f, nil
+}
+
+// write replaces the scores file. The new content is written to a temporary
+// file first and renamed over the old one, which is atomic.
+func (s *Store) write(f file) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".scores-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
@@ -0,0 +1,98 @@
+package scores
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRecordKeepsBestScores(t *testing.T) {
+	s := Open(filepath.Join(t.TempDir(), "scores.json"))
+
+	for i := range MaxBest + 5 {
+		if err := s.Record("snake", Result{Score: i, Duration: time.Second}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats, err := s.Stats("snake")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Played != MaxBest+5 {
+		t.Errorf("expected %d games played, got %d", MaxBest+5, stats.Played)
+	}
+
+	if stats.Playtime != time.Duration(MaxBest+5)*time.Second {
+		t.Errorf("unexpected playtime %v", stats.Playtime)
+	}
+
+	if len(stats.Best) != MaxBest {
+		t.Fatalf("expected %d best results, got %d", MaxBest, len(stats.Best))
+	}
+
+	if stats.Best[0].Score != MaxBest+4 || stats.Best[MaxBest-1].Score != 5 {
+		t.Errorf("expected the best scores in order, got %v", stats.Best)
+	}
+}
+
+func TestStatsOfUnplayedGame(t *testing.T) {
+	s := Open(filepath.Join(t.TempDir(), "scores.json"))
+
+	stats, err := s.Stats("tetris")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Played != 0 || len(stats.Best) != 0 {
+		t.Errorf("expected empty stats, got %v", stats)
+	}
+}
+
+func TestConcurrentWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scores.json")
+
+	const writers = 20
+	var wg sync.WaitGroup
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Every writer opens the file on its own, like separate gg
+			// processes would.
+			if err := Open(path).Record("dodger", Result{Score: i}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats, err := Open(path).Stats("dodger")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Played != writers {
+		t.Fatalf("expected %d results, got %d", writers, stats.Played)
+	}
+}
+
+func TestRecordCmd(t *testing.T) {
+	s := Open(filepath.Join(t.TempDir(), "scores.json"))
+	SetDefault(s)
+	defer SetDefault(nil)
+
+	cmd := RecordCmd("snake", Result{Score: 3})
+	if stats, _ := s.Stats("snake"); stats.Played != 0 {
+		t.Fatal("expected nothing to be written before the command runs")
+	}
+
+	if msg, ok := cmd().(RecordedMsg); !ok || msg.Err != nil {
+		t.Fatalf("expected the result to be recorded, got %#v", msg)
+	}
+	if stats, _ := s.Stats("snake"); stats.Played != 1 || stats.Best[0].Score != 3 || stats.Best[0].Time.IsZero() {
+		t.Fatalf("expected the result to be recorded, got %+v", stats)
+	}
+}
@@ -0,0 +1,286 @@
+// Package theme holds the color palettes of the games. Games don't pick
+// colors themselves, they ask the current theme for the color of a role,
+// such as the first player or the background of a board, so that a player
+// can switch every game to another palette at once.
+package theme
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// Theme is a named palette.
+type Theme struct {
+	// Name identifies the theme, e.g. on the command line.
+	Name string
+	// Profile is the least capable color profile the palette is meant for.
+	// On a terminal with fewer colors the monochrome theme is used instead.
+	Profile termenv.Profile
+
+	// Text is the color of text drawn on Surface.
+	Text lipgloss.TerminalColor
+	// Muted is used for borders, grid lines and scenery.
+	Muted lipgloss.TerminalColor
+	// Surface is the background of boards.
+	Surface lipgloss.TerminalColor
+	// Cursor is the background of the selected cell.
+	Cursor lipgloss.TerminalColor
+
+	// Player1 and Player2 tell the two sides of a game apart, such as the
+	// pieces of connect 4 or the paddles of pong.
+	Player1 lipgloss.TerminalColor
+	Player2 lipgloss.TerminalColor
+
+	// Good and Bad mark wins and dangers, such as food and losses.
+	Good lipgloss.TerminalColor
+	Bad  lipgloss.TerminalColor
+	// Info is used for status lines.
+	Info lipgloss.TerminalColor
+
+	// Tiles are the colors of the 2048 tiles, from the empty tile to 2048.
+	Tiles [12]Tile
+	// Pieces are the background colors of the tetris shapes.
+	Pieces [8]lipgloss.TerminalColor
+}
+
+// Tile is the text and background color of a tile.
+type Tile struct {
+	Fg lipgloss.TerminalColor
+	Bg lipgloss.TerminalColor
+}
+
+// Style returns a style drawing text in fg on bg.
+func (t Tile) Style() lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(t.Fg).Background(t.Bg)
+}
+
+// Default is the palette gg has always used.
+var Default = Theme{
+	Name:    "default",
+	Profile: termenv.ANSI256,
+
+	Text:    lipgloss.Color("#f9f6f2"),
+	Muted:   lipgloss.Color("#717c7c"),
+	Surface: lipgloss.Color("#3c3a32"),
+	Cursor:  lipgloss.Color("#0000ff"),
+
+	Player1: lipgloss.Color("#ff9e3b"),
+	Player2: lipgloss.Color("#e63d3d"),
+
+	Good: lipgloss.Color("#98bb6c"),
+	Bad:  lipgloss.Color("#ff0000"),
+	Info: lipgloss.Color("#7e9cd8"),
+
+	Tiles: [12]Tile{
+		{lipgloss.Color("#f9f6f2"), lipgloss.Color("#3c3a32")},
+		{lipgloss.Color("#000000"), lipgloss.Color("#eee4da")},
+		{lipgloss.Color("#000000"), lipgloss.Color("#ede0c8")},
+		{lipgloss.Color("#f9f6f2"), lipgloss.Color("#f2b179")},
+		{lipgloss.Color("#f9f6f2"), lipgloss.Color("#f59563")},
+		{lipgloss.Color("#f9f6f2"), lipgloss.Color("#f67c5f")},
+		{lipgloss.Color("#f9f6f2"), lipgloss.Color("#f65e3b")},
+		{lipgloss.Color("#f9f6f2"), lipgloss.Color("#edcf72")},
+		{lipgloss.Color("#f9f6f2"), lipgloss.Color("#edcc61")},
+		{lipgloss.Color("#f9f6f2"), lipgloss.Color("#edc850")},
+		{lipgloss.Color("#f9f6f2"), lipgloss.Color("#edc53f")},
+		{lipgloss.Color("#f9f6f2"), lipgloss.Color("#edc22e")},
+	},
+	Pieces: [8]lipgloss.TerminalColor{
+		lipgloss.Color("#063970"),
+		lipgloss.Color("#4ca74f"),
+		lipgloss.Color("#cf6209"),
+		lipgloss.Color("#d85b85"),
+		lipgloss.Color("#2692e8"),
+		lipgloss.Color("#9047a3"),
+		lipgloss.Color("#ca1f7b"),
+		lipgloss.Color("#fffdd0"),
+	},
+}
+
+// HighContrast uses the bright colors of 16 color terminals on black.
+var HighContrast = Theme{
+	Name:    "high-contrast",
+	Profile: termenv.ANSI,
+
+	Text:    lipgloss.Color("15"),
+	Muted:   lipgloss.Color("7"),
+	Surface: lipgloss.Color("0"),
+	Cursor:  lipgloss.Color("12"),
+
+	Player1: lipgloss.Color("11"),
+	Player2: lipgloss.Color("14"),
+
+	Good: lipgloss.Color("10"),
+	Bad:  lipgloss.Color("9"),
+	Info: lipgloss.Color("15"),
+
+	Tiles: [12]Tile{
+		{lipgloss.Color("15"), lipgloss.Color("0")},
+		{lipgloss.Color("0"), lipgloss.Color("15")},
+		{lipgloss.Color("0"), lipgloss.Color("7")},
+		{lipgloss.Color("0"), lipgloss.Color("11")},
+		{lipgloss.Color("0"), lipgloss.Color("3")},
+		{lipgloss.Color("0"), lipgloss.Color("9")},
+		{lipgloss.Color("15"), lipgloss.Color("1")},
+		{lipgloss.Color("0"), lipgloss.Color("14")},
+		{lipgloss.Color("15"), lipgloss.Color("4")},
+		{lipgloss.Color("0"), lipgloss.Color("10")},
+		{lipgloss.Color("15"), lipgloss.Color("5")},
+		{lipgloss.Color("0"), lipgloss.Color("13")},
+	},
+	Pieces: [8]lipgloss.TerminalColor{
+		lipgloss.Color("12"),
+		lipgloss.Color("10"),
+		lipgloss.Color("11"),
+		lipgloss.Color("13"),
+		lipgloss.Color("14"),
+		lipgloss.Color("9"),
+		lipgloss.Color("5"),
+		lipgloss.Color("15"),
+	},
+}
+
+// Colorblind uses the Okabe-Ito palette, whose colors stay apart with
+// every common kind of color blindness. The 2048 tiles go from dark to
+// light, so they can be told apart by brightness alone.
+var Colorblind = Theme{
+	Name:    "colorblind",
+	Profile: termenv.ANSI256,
+
+	Text:    lipgloss.Color("#ffffff"),
+	Muted:   lipgloss.Color("#999999"),
+	Surface: lipgloss.Color("#222222"),
+	Cursor:  lipgloss.Color("#0072b2"),
+
+	Player1: lipgloss.Color("#e69f00"),
+	Player2: lipgloss.Color("#56b4e9"),
+
+	Good: lipgloss.Color("#009e73"),
+	Bad:  lipgloss.Color("#d55e00"),
+	Info: lipgloss.Color("#56b4e9"),
+
+	Tiles: [12]Tile{
+		{lipgloss.Color("#ffffff"), lipgloss.Color("#222222")},
+		{lipgloss.Color("#ffffff"), lipgloss.Color("#440154")},
+		{lipgloss.Color("#ffffff"), lipgloss.Color("#482475")},
+		{lipgloss.Color("#ffffff"), lipgloss.Color("#414487")},
+		{lipgloss.Color("#ffffff"), lipgloss.Color("#355f8d")},
+		{lipgloss.Color("#ffffff"), lipgloss.Color("#2a788e")},
+		{lipgloss.Color("#ffffff"), lipgloss.Color("#21918c")},
+		{lipgloss.Color("#000000"), lipgloss.Color("#22a884")},
+		{lipgloss.Color("#000000"), lipgloss.Color("#44bf70")},
+		{lipgloss.Color("#000000"), lipgloss.Color("#7ad151")},
+		{lipgloss.Color("#000000"), lipgloss.Color("#bddf26")},
+		{lipgloss.Color("#000000"), lipgloss.Color("#fde725")},
+	},
+	Pieces: [8]lipgloss.TerminalColor{
+		lipgloss.Color("#0072b2"),
+		lipgloss.Color("#009e73"),
+		lipgloss.Color("#e69f00"),
+		lipgloss.Color("#cc79a7"),
+		lipgloss.Color("#56b4e9"),
+		lipgloss.Color("#d55e00"),
+		lipgloss.Color("#f0e442"),
+		lipgloss.Color("#ffffff"),
+	},
+}
+
+// Monochrome only uses black, white and the two grays, which every 16
+// color terminal has. The players are told apart by their marks.
+var Monochrome = Theme{
+	Name:    "monochrome",
+	Profile: termenv.ANSI,
+
+	Text:    lipgloss.Color("15"),
+	Muted:   lipgloss.Color("8"),
+	Surface: lipgloss.Color("0"),
+	Cursor:  lipgloss.Color("8"),
+
+	Player1: lipgloss.Color("15"),
+	Player2: lipgloss.Color("7"),
+
+	Good: lipgloss.Color("15"),
+	Bad:  lipgloss.Color("15"),
+	Info: lipgloss.Color("7"),
+
+	Tiles: [12]Tile{
+		{lipgloss.Color("15"), lipgloss.Color("0")},
+		{lipgloss.Color("15"), lipgloss.Color("8")},
+		{lipgloss.Color("0"), lipgloss.Color("7")},
+		{lipgloss.Color("0"), lipgloss.Color("15")},
+		{lipgloss.Color("15"), lipgloss.Color("8")},
+		{lipgloss.Color("0"), lipgloss.Color("7")},
+		{lipgloss.Color("0"), lipgloss.Color("15")},
+		{lipgloss.Color("15"), lipgloss.Color("8")},
+		{lipgloss.Color("0"), lipgloss.Color("7")},
+		{lipgloss.Color("0"), lipgloss.Color("15")},
+		{lipgloss.Color("15"), lipgloss.Color("8")},
+		{lipgloss.Color("0"), lipgloss.Color("15")},
+	},
+	Pieces: [8]lipgloss.TerminalColor{
+		lipgloss.Color("7"),
+		lipgloss.Color("8"),
+		lipgloss.Color("15"),
+		lipgloss.Color("7"),
+		lipgloss.Color("8"),
+		lipgloss.Color("15"),
+		lipgloss.Color("7"),
+		lipgloss.Color("8"),
+	},
+}
+
+// Themes are the themes to choose from.
+var Themes = []Theme{Default, HighContrast, Colorblind, Monochrome}
+
+var current = Default
+
+// Current returns the theme games should draw with.
+func Current() Theme {
+	return current
+}
+
+// Use makes the theme named name current. An empty name picks the default
+// theme. If the terminal, with color profile p, has fewer colors than the
+// theme needs, the monochrome theme is used instead.
+func Use(name string, p termenv.Profile) error {
+	t, err := Select(name, p)
+	if err != nil {
+		return err
+	}
+
+	current = t
+	return nil
+}
+
+// Select returns the theme named name, or the theme to fall back to on a
+// terminal with color profile p. Less capable profiles are greater.
+func Select(name string, p termenv.Profile) (Theme, error) {
+	if name == "" {
+		name = Default.Name
+	}
+
+	i := slices.IndexFunc(Themes, func(t Theme) bool { return t.Name == name })
+	if i < 0 {
+		return Theme{}, fmt.Errorf("unknown theme %q, choose one of %s", name, strings.Join(Names(), ", "))
+	}
+
+	if p > Themes[i].Profile {
+		return Monochrome, nil
+	}
+
+	return Themes[i], nil
+}
+
+// Names returns the names of the themes.
+func Names() []string {
+	names := make([]string, 0, len(Themes))
+	for _, t := range Themes {
+		names = append(names, t.Name)
+	}
+
+	return names
+}
@@ -0,0 +1,81 @@
+package theme
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/muesli/termenv"
+)
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile termenv.Profile
+		want    string
+	}{
+		{"", termenv.TrueColor, "default"},
+		{"colorblind", termenv.ANSI256, "colorblind"},
+		{"default", termenv.ANSI, "monochrome"},
+		{"colorblind", termenv.Ascii, "monochrome"},
+		{"high-contrast", termenv.ANSI, "high-contrast"},
+		{"monochrome", termenv.TrueColor, "monochrome"},
+	}
+
+	for _, tt := range tests {
+		got, err := Select(tt.name, tt.profile)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.name, err)
+		}
+		if got.Name != tt.want {
+			t.Errorf("%q on %v: expected %s, got %s", tt.name, tt.profile, tt.want, got.Name)
+		}
+	}
+}
+
+func TestSelectUnknown(t *testing.T) {
+	if _, err := Select("solarized", termenv.TrueColor); err == nil {
+		t.Fatal("expected an error for an unknown theme")
+	}
+}
+
+func TestUse(t *testing.T) {
+	defer func() { current = Default }()
+
+	if err := Use("high-contrast", termenv.TrueColor); err != nil {
+		t.Fatal(err)
+	}
+	if got := Current().Name; got != "high-contrast" {
+		t.Fatalf("expected high-contrast, got %s", got)
+	}
+
+	if err := Use("solarized", termenv.TrueColor); err == nil {
+		t.Fatal("expected an error for an unknown theme")
+	}
+	if got := Current().Name; got != "high-contrast" {
+		t.Fatalf("an unknown theme replaced the current one with %s", got)
+	}
+}
+
+// TestComplete checks that every theme sets every color.
+func TestComplete(t *testing.T) {
+	for _, th := range Themes {
+		v := reflect.ValueOf(th)
+		for i := range v.NumField() {
+			f := v.Field(i)
+			if f.Kind() == reflect.Interface && f.IsNil() {
+				t.Errorf("%s: %s is not set", th.Name, v.Type().Field(i).Name)
+			}
+		}
+
+		for i, tile := range th.Tiles {
+			if tile.Fg == nil || tile.Bg == nil {
+				t.Errorf("%s: tile %d is not set", th.Name, i)
+			}
+		}
+		for i, piece := range th.Pieces {
+			if piece == nil {
+				t.Errorf("%s: piece %d is not set", th.Name, i)
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Package xdg finds the directories gg keeps its files in, following the
+// XDG base directory specification where it applies.
+package xdg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// DataDir returns the directory for gg's data, such as high scores. It is
+// $XDG_DATA_HOME/gg, falling back to ~/.local/share/gg, or %LocalAppData%\gg
+// on Windows. The directory isn't created.
+func DataDir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "gg"), nil
+	}
+
+	if runtime.GOOS == "windows" {
+		dir := os.Getenv("LocalAppData")
+		if dir == "" {
+			return "", errors.New("%LocalAppData% is not set")
+		}
+		return filepath.Join(dir, "gg"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".local", "share", "gg"), nil
+}
+
+// ConfigDir returns the directory for gg's configuration, such as key
+// bindings. It is $XDG_CONFIG_HOME/gg, falling back to ~/.config/gg, or
+// %AppData%\gg on Windows. The directory isn't created.
+func ConfigDir() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gg"), nil
+	}
+
+	if runtime.GOOS == "windows" {
+		dir := os.Getenv("AppData")
+		if dir == "" {
+			return "", errors.New("%AppData% is not set")
+		}
+		return filepath.Join(dir, "gg"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".config", "gg"), nil
+}